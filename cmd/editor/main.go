@@ -10,6 +10,7 @@ import (
 	"github.com/thomaspoignant/go-feature-flag/internal/utils"
 	"github.com/thomaspoignant/go-feature-flag/model"
 	"net/http"
+	"time"
 )
 
 // This service is an API used to evaluate a flag with an evaluation context
@@ -50,6 +51,7 @@ func EvaluateHandler(c echo.Context) error {
 		utils.ConvertEvaluationCtxFromRequest(u.Context.Key, u.Context.Custom),
 		flag.Context{
 			DefaultSdkValue: nil,
+			EvaluationDate:  u.GetEvaluationDate(),
 		},
 	)
 	resp := model.VariationResult[interface{}]{
@@ -74,6 +76,17 @@ type editorEvaluateRequest struct {
 	Context  ContextWrapper `json:"context,omitempty"`
 	Flag     dto.DTO        `json:"flag,omitempty"`
 	FlagName string         `json:"flagName,omitempty"`
+	// EvaluationDate (optional) is the date used to evaluate the flag, to preview a scheduled rollout,
+	// a progressive rollout, an experimentation or time windows. Default: the current date.
+	EvaluationDate *time.Time `json:"evaluationDate,omitempty"`
+}
+
+// GetEvaluationDate returns the date used to evaluate the flag, a zero date means the current date.
+func (r *editorEvaluateRequest) GetEvaluationDate() time.Time {
+	if r.EvaluationDate == nil {
+		return time.Time{}
+	}
+	return *r.EvaluationDate
 }
 
 // ContextWrapper is a struct to migrate the API request to an actual evaluation context.
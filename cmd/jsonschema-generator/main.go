@@ -25,8 +25,14 @@ func main() {
 		log.Fatal("schema-location is required")
 	}
 
-	a := map[string]dto.DTO{}
-	d := jsonschema.Reflect(a)
+	// The configuration file contains the shared objects (segments, ...) and the flags,
+	// the flags being all the top-level keys that are not reserved.
+	reflector := &jsonschema.Reflector{Anonymous: true}
+	d := reflector.Reflect(&dto.Configuration{})
+	for name, definition := range reflector.Reflect(&dto.DTO{}).Definitions {
+		d.Definitions[name] = definition
+	}
+	d.Definitions["Configuration"].AdditionalProperties = &jsonschema.Schema{Ref: "#/$defs/DTO"}
 	jsonSchema, err := d.MarshalJSON()
 	if err != nil {
 		log.Fatal("impossible to parse jsonschema", err)
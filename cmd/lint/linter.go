@@ -1,14 +1,11 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 
-	"github.com/BurntSushi/toml"
 	"github.com/thomaspoignant/go-feature-flag/internal/dto"
-	"k8s.io/apimachinery/pkg/util/yaml"
 )
 
 type Linter struct {
@@ -22,27 +19,65 @@ func (l *Linter) Lint() []error {
 		return []error{err}
 	}
 
-	var flags map[string]dto.DTO
 	switch strings.ToLower(l.InputFormat) {
-	case "toml":
-		err = toml.Unmarshal(dat, &flags)
-	case "json":
-		err = json.Unmarshal(dat, &flags)
-	case "yaml":
-		err = yaml.Unmarshal(dat, &flags)
+	case "toml", "json", "yaml":
 	default:
 		return []error{fmt.Errorf("%s: invalid input format: %s", l.InputFile, l.InputFormat)}
 	}
+
+	config, err := dto.Unmarshal(dat, l.InputFormat)
 	if err != nil {
 		return []error{fmt.Errorf("%s: could not parse file: %w", l.InputFile, err)}
 	}
 
 	errs := make([]error, 0)
-	for key, flagDto := range flags {
+	for _, err := range config.SkippedEntries {
+		errs = append(errs, fmt.Errorf("%s: %w", l.InputFile, err))
+	}
+	for segmentName, segment := range config.Segments {
+		if err := segment.IsValid(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid segment %s: %w", l.InputFile, segmentName, err))
+		}
+	}
+
+	for layerName, layer := range config.Layers {
+		if err := layer.IsValid(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid layer %s: %w", l.InputFile, layerName, err))
+		}
+	}
+	if config.Holdout != nil {
+		if err := config.Holdout.IsValid(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid holdout: %w", l.InputFile, err))
+		}
+	}
+
+	schema := config.ContextSchema
+	if schema != nil {
+		if err := schema.IsValid(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid context schema: %w", l.InputFile, err))
+			schema = nil
+		}
+	}
+
+	for key, flagDto := range config.Flags {
 		flag := flagDto.Convert()
+		flag.LinkSegments(config.Segments)
+		if err := flag.LinkLayer(key, config.Layers, config.Holdout); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid flag %s: %w", l.InputFile, key, err))
+			continue
+		}
 		if err := flag.IsValid(); err != nil {
 			errs = append(errs, fmt.Errorf("%s: invalid flag %s: %w", l.InputFile, key, err))
 		}
+		if schema == nil {
+			continue
+		}
+		for _, attribute := range flag.QueryAttributes() {
+			if !schema.IsKnownAttribute(attribute) {
+				errs = append(errs, fmt.Errorf("%s: invalid flag %s: the attribute %s is not in the context schema",
+					l.InputFile, key, attribute))
+			}
+		}
 	}
 
 	return errs
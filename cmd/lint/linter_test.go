@@ -92,6 +92,54 @@ func TestLinter_Lint(t *testing.T) {
 			},
 			wantErr: assert.Error,
 		},
+		{
+			name: "unknown segment",
+			linter: Linter{
+				InputFile:   "testdata/unknown-segment.yaml",
+				InputFormat: "yaml",
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name: "invalid layer",
+			linter: Linter{
+				InputFile:   "testdata/invalid-layer.yaml",
+				InputFormat: "yaml",
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name: "variations not matching the type and the schema",
+			linter: Linter{
+				InputFile:   "testdata/invalid-variation-schema.yaml",
+				InputFormat: "yaml",
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name: "invalid environment override",
+			linter: Linter{
+				InputFile:   "testdata/invalid-environment.yaml",
+				InputFormat: "yaml",
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name: "valid context schema",
+			linter: Linter{
+				InputFile:   "testdata/valid-context-schema.yaml",
+				InputFormat: "yaml",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "query using an attribute not in the context schema",
+			linter: Linter{
+				InputFile:   "testdata/unknown-context-attribute.yaml",
+				InputFormat: "yaml",
+			},
+			wantErr: assert.Error,
+		},
 		{
 			name: "invalid file",
 			linter: Linter{
@@ -110,3 +158,11 @@ func TestLinter_Lint(t *testing.T) {
 		})
 	}
 }
+
+func TestLinter_LintContextSchema(t *testing.T) {
+	linter := Linter{InputFile: "testdata/unknown-context-attribute.yaml", InputFormat: "yaml"}
+	errs := linter.Lint()
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0],
+		"testdata/unknown-context-attribute.yaml: invalid flag flag: the attribute Plan is not in the context schema")
+}
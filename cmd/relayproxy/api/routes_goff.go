@@ -10,6 +10,7 @@ import (
 func (s *Server) addGOFFRoutes(
 	cAllFlags controller.Controller,
 	cFlagEval controller.Controller,
+	cFlagExplain controller.Controller,
 	cEvalDataCollector controller.Controller) {
 	// Grouping the routes
 	v1 := s.apiEcho.Group("/v1")
@@ -23,6 +24,9 @@ func (s *Server) addGOFFRoutes(
 	}
 	v1.POST("/allflags", cAllFlags.Handler)
 	v1.POST("/feature/:flagKey/eval", cFlagEval.Handler)
+	if s.config.EnableExplain {
+		v1.POST("/feature/:flagKey/explain", cFlagExplain.Handler)
+	}
 	v1.POST("/data/collector", cEvalDataCollector.Handler)
 
 	// Swagger - only available if option is enabled
@@ -74,12 +74,13 @@ func (s *Server) initRoutes() {
 	// Init controllers
 	cAllFlags := controller.NewAllFlags(s.services.GOFeatureFlagService, s.services.Metrics)
 	cFlagEval := controller.NewFlagEval(s.services.GOFeatureFlagService, s.services.Metrics)
+	cFlagExplain := controller.NewFlagExplain(s.services.GOFeatureFlagService)
 	cFlagEvalOFREP := ofrep.NewOFREPEvaluate(s.services.GOFeatureFlagService, s.services.Metrics)
 	cEvalDataCollector := controller.NewCollectEvalData(s.services.GOFeatureFlagService, s.services.Metrics)
 	cRetrieverRefresh := controller.NewForceFlagsRefresh(s.services.GOFeatureFlagService, s.services.Metrics)
 
 	// Init routes
-	s.addGOFFRoutes(cAllFlags, cFlagEval, cEvalDataCollector)
+	s.addGOFFRoutes(cAllFlags, cFlagEval, cFlagExplain, cEvalDataCollector)
 	s.addOFREPRoutes(cFlagEvalOFREP)
 	s.addWebsocketRoutes()
 	s.addMonitoringRoutes()
@@ -125,6 +125,10 @@ type Config struct {
 	// EnableSwagger (optional) to have access to the swagger
 	EnableSwagger bool `mapstructure:"enableSwagger" koanf:"enableswagger"`
 
+	// EnableExplain (optional) to expose the endpoint explaining the evaluation of a flag (default is false).
+	// The explanation contains the details of your flag configuration, enable it only if needed.
+	EnableExplain bool `mapstructure:"enableExplain" koanf:"enableexplain"`
+
 	// Host should be set if you are using swagger (default is localhost)
 	Host string `mapstructure:"host" koanf:"host"`
 
@@ -164,6 +168,10 @@ type Config struct {
 	// after we will use the order of Retrievers.
 	Retrievers *[]RetrieverConf `mapstructure:"retrievers" koanf:"retrievers"`
 
+	// MembershipLists (optional) are large lists of values used by the inList operator in the rules,
+	// each list is loaded by its own retriever and refreshed at the same time as the flags.
+	MembershipLists map[string]RetrieverConf `mapstructure:"membershipLists" koanf:"membershiplists"`
+
 	// Exporter is the configuration on how to export data
 	Exporter *ExporterConf `mapstructure:"exporter" koanf:"exporter"`
 
@@ -195,6 +203,12 @@ type Config struct {
 	// Default: nil
 	EvaluationContextEnrichment map[string]interface{} `mapstructure:"evaluationContextEnrichment" koanf:"evaluationcontextenrichment"` //nolint: lll
 
+	// EvaluationCacheSize (optional) is the maximum number of evaluation results kept in an LRU cache,
+	// the results that can be cached are reused for the same flag and the same evaluation context
+	// until the flags are updated.
+	// Default: 0, no cache
+	EvaluationCacheSize int `mapstructure:"evaluationCacheSize" koanf:"evaluationcachesize"`
+
 	// OpenTelemetryOtlpEndpoint (optional) is the endpoint of the OpenTelemetry collector
 	// Default: ""
 	OpenTelemetryOtlpEndpoint string `mapstructure:"openTelemetryOtlpEndpoint" koanf:"opentelemetryotlpendpoint"`
@@ -280,6 +294,12 @@ func (c *Config) IsValid() error {
 		}
 	}
 
+	for name, retriever := range c.MembershipLists {
+		if err := retriever.IsValid(); err != nil {
+			return fmt.Errorf("invalid retriever for the membership list %s: %w", name, err)
+		}
+	}
+
 	// Exporter is optional
 	if c.Exporter != nil {
 		if err := c.Exporter.IsValid(); err != nil {
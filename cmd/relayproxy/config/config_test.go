@@ -310,6 +310,7 @@ func TestConfig_IsValid(t *testing.T) {
 		StartWithRetrieverError bool
 		Retriever               *config.RetrieverConf
 		Retrievers              *[]config.RetrieverConf
+		MembershipLists         map[string]config.RetrieverConf
 		Exporter                *config.ExporterConf
 		Notifiers               []config.NotifierConf
 	}
@@ -425,6 +426,22 @@ func TestConfig_IsValid(t *testing.T) {
 			},
 			wantErr: assert.Error,
 		},
+		{
+			name: "invalid retriever for a membership list",
+			fields: fields{
+				ListenPort: 8080,
+				Retriever: &config.RetrieverConf{
+					Kind: "file",
+					Path: "../testdata/config/valid-file.yaml",
+				},
+				MembershipLists: map[string]config.RetrieverConf{
+					"paying-accounts": {
+						Kind: "file",
+					},
+				},
+			},
+			wantErr: assert.Error,
+		},
 		{
 			name: "invalid exporter",
 			fields: fields{
@@ -471,6 +488,7 @@ func TestConfig_IsValid(t *testing.T) {
 				Exporter:                tt.fields.Exporter,
 				Notifiers:               tt.fields.Notifiers,
 				Retrievers:              tt.fields.Retrievers,
+				MembershipLists:         tt.fields.MembershipLists,
 			}
 			if tt.name == "empty config" {
 				c = nil
@@ -52,7 +52,7 @@ func (h *allFlags) Handler(c echo.Context) error {
 		return err
 	}
 
-	evaluationCtx, err := evaluationContextFromRequest(reqBody)
+	evaluationCtx, err := evaluationContextFromRequest(reqBody, h.goFF.GetContextSchema())
 	if err != nil {
 		return err
 	}
@@ -63,7 +63,7 @@ func (h *flagEval) Handler(c echo.Context) error {
 	if err := assertRequest(&reqBody.AllFlagRequest); err != nil {
 		return err
 	}
-	evaluationCtx, err := evaluationContextFromRequest(&reqBody.AllFlagRequest)
+	evaluationCtx, err := evaluationContextFromRequest(&reqBody.AllFlagRequest, h.goFF.GetContextSchema())
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	ffclient "github.com/thomaspoignant/go-feature-flag"
+	"github.com/thomaspoignant/go-feature-flag/cmd/relayproxy/model"
+)
+
+type flagExplain struct {
+	goFF *ffclient.GoFeatureFlag
+}
+
+func NewFlagExplain(goFF *ffclient.GoFeatureFlag) Controller {
+	return &flagExplain{
+		goFF: goFF,
+	}
+}
+
+// Handler is the entry point for the flag explain endpoint
+// @Summary     Explain the evaluation of a feature flag
+// @Tags GO Feature Flag Evaluation API
+// @Description Making a **POST** request to the URL `/v1/feature/<your_flag_name>/explain` will evaluate the flag
+// @Description for this user and return a step-by-step trace of the evaluation.
+// @Description
+// @Description The trace contains all the checks done during the evaluation (disabled flag, experimentation,
+// @Description scheduled steps, ...), each rule with its query and if it matched (and which attributes were
+// @Description missing in the evaluation context), and the bucket used to serve a percentage.
+// @Description
+// @Description This endpoint is only available if `enableExplain` is set to `true` in the configuration,
+// @Description no event is sent to the exporter when calling it.
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Accept	 	 json
+// @Param 		 data body model.EvalFlagRequest true "Payload of the user we want to explain the evaluation for."
+// @Param        flag_key path string true "Name of your feature flag"
+// @Success      200  {object} modeldocs.ExplainFlagDoc "Success"
+// @Failure      400 {object}  modeldocs.HTTPErrorDoc "Bad Request"
+// @Failure      404 {object}  modeldocs.HTTPErrorDoc "Flag Not Found"
+// @Failure      500 {object}  modeldocs.HTTPErrorDoc "Internal server error"
+// @Router       /v1/feature/{flag_key}/explain [post]
+func (h *flagExplain) Handler(c echo.Context) error {
+	flagKey := c.Param("flagKey")
+	if flagKey == "" {
+		return fmt.Errorf("impossible to find the flag key in the URL")
+	}
+
+	reqBody := new(model.EvalFlagRequest)
+	if err := c.Bind(reqBody); err != nil {
+		return err
+	}
+
+	// validation that we have a reqBody key
+	if err := assertRequest(&reqBody.AllFlagRequest); err != nil {
+		return err
+	}
+	evaluationCtx, err := evaluationContextFromRequest(&reqBody.AllFlagRequest, h.goFF.GetContextSchema())
+	if err != nil {
+		return err
+	}
+
+	explanation, err := h.goFF.Explain(flagKey, evaluationCtx, reqBody.DefaultValue)
+	if err != nil {
+		switch {
+		case errors.Is(err, ffclient.ErrFlagNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case errors.Is(err, ffclient.ErrInvalidEvaluationContext):
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+	return c.JSON(http.StatusOK, explanation)
+}
@@ -0,0 +1,116 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	ffclient "github.com/thomaspoignant/go-feature-flag"
+	"github.com/thomaspoignant/go-feature-flag/cmd/relayproxy/controller"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/model"
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
+)
+
+func Test_flag_explain_Handler(t *testing.T) {
+	tests := []struct {
+		name        string
+		flagKey     string
+		body        string
+		configFlags string
+		offline     bool
+		wantCode    int
+		handlerErr  bool
+		errorCode   int
+	}{
+		{
+			name:     "valid flag",
+			flagKey:  "test-flag-rule-apply",
+			body:     `{"evaluationContext": {"key": "random-key", "custom": {"custom1": "value1"}}}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:       "flag does not exist",
+			flagKey:    "random-key-does-not-exist",
+			body:       `{"evaluationContext": {"key": "random-key"}}`,
+			handlerErr: true,
+			errorCode:  http.StatusNotFound,
+		},
+		{
+			name:       "no user key in payload",
+			flagKey:    "test-flag-rule-apply",
+			body:       `{"evaluationContext": {"key": ""}}`,
+			handlerErr: true,
+			errorCode:  http.StatusBadRequest,
+		},
+		{
+			name:        "evaluation context rejected by the context schema",
+			flagKey:     "number-flag",
+			body:        `{"evaluationContext": {"key": "random-key", "custom": {"plan": 1}}}`,
+			configFlags: "../testdata/controller/config_flags_context_schema.yaml",
+			handlerErr:  true,
+			errorCode:   http.StatusBadRequest,
+		},
+		{
+			name:       "impossible to explain the flag",
+			flagKey:    "test-flag-rule-apply",
+			body:       `{"evaluationContext": {"key": "random-key"}}`,
+			offline:    true,
+			handlerErr: true,
+			errorCode:  http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFlags := configFlagsLocation
+			if tt.configFlags != "" {
+				configFlags = tt.configFlags
+			}
+			goFF, _ := ffclient.New(ffclient.Config{
+				PollingInterval: 10 * time.Second,
+				Context:         context.Background(),
+				Offline:         tt.offline,
+				Retriever: &fileretriever.Retriever{
+					Path: configFlags,
+				},
+			})
+			defer goFF.Close()
+
+			flagExplain := controller.NewFlagExplain(goFF)
+
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(echo.POST, "/v1/feature/"+tt.flagKey+"/explain", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			c := e.NewContext(req, rec)
+			c.SetPath("/v1/feature/:flagKey/explain")
+			c.SetParamNames("flagKey")
+			c.SetParamValues(tt.flagKey)
+			handlerErr := flagExplain.Handler(c)
+
+			if tt.handlerErr {
+				assert.Error(t, handlerErr, "handler should return an error")
+				he, ok := handlerErr.(*echo.HTTPError)
+				assert.True(t, ok)
+				assert.Equal(t, tt.errorCode, he.Code)
+				return
+			}
+
+			assert.NoError(t, handlerErr)
+			assert.Equal(t, tt.wantCode, rec.Code, "Invalid HTTP Code")
+			var got model.ExplainResult
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tt.flagKey, got.Trace.FlagKey)
+			lastStep := got.Trace.Steps[len(got.Trace.Steps)-1]
+			assert.Equal(t, flag.TraceStepRule, lastStep.Type)
+			assert.True(t, lastStep.Rule.Matched)
+			assert.Equal(t, got.VariationType, lastStep.Rule.Variation)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"fmt"
 	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/contextschema"
 	"github.com/thomaspoignant/go-feature-flag/internal/utils"
 	"net/http"
 
@@ -34,24 +35,38 @@ func assertContextKey(key string) *echo.HTTPError {
 	return nil
 }
 
-func evaluationContextFromRequest(req *model.AllFlagRequest) (ffcontext.Context, error) {
+// evaluationContextFromRequest converts the request into an evaluation context, if the context schema is in
+// strict mode an echo.HTTPError is return for an evaluation context not following the schema.
+func evaluationContextFromRequest(req *model.AllFlagRequest, schema *contextschema.Schema) (ffcontext.Context, error) {
 	if req == nil {
 		return ffcontext.EvaluationContext{},
 			echo.NewHTTPError(http.StatusBadRequest, "evaluationContextFromRequest: impossible to convert the request, req nil")
 	}
 	if req.EvaluationContext != nil {
 		u := req.EvaluationContext
-		return utils.ConvertEvaluationCtxFromRequest(u.Key, u.Custom), nil
+		return contextFromRequest(u.Key, u.Custom, u.Kinds, schema)
 	}
-	return userRequestToUser(req.User) // nolint: staticcheck
+	return userRequestToUser(req.User, schema) // nolint: staticcheck
 }
 
 // userRequestToUser convert a user from the request model.AllFlagRequest to a go-feature-flag ffuser.User
 // nolint: staticcheck
-func userRequestToUser(u *model.UserRequest) (ffcontext.Context, error) {
+func userRequestToUser(u *model.UserRequest, schema *contextschema.Schema) (ffcontext.Context, error) {
 	if u == nil {
 		return ffcontext.EvaluationContext{}, fmt.Errorf("userRequestToUser: impossible to convert user, userRequest nil")
 	}
 	u.Custom["anonymous"] = u.Anonymous
-	return utils.ConvertEvaluationCtxFromRequest(u.Key, u.Custom), nil
+	return contextFromRequest(u.Key, u.Custom, nil, schema)
+}
+
+// contextFromRequest converts the attributes of a request into an evaluation context validated against the
+// context schema, an echo.HTTPError is return if the evaluation context is invalid or rejected by the schema.
+func contextFromRequest(key string, custom map[string]interface{}, kinds map[string]map[string]interface{},
+	schema *contextschema.Schema,
+) (ffcontext.Context, error) {
+	evaluationCtx, err := utils.ConvertEvaluationCtxFromRequestWithSchema(key, custom, kinds, schema)
+	if err != nil {
+		return evaluationCtx, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid evaluation context: %s", err))
+	}
+	return evaluationCtx, nil
 }
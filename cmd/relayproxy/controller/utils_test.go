@@ -6,6 +6,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/thomaspoignant/go-feature-flag/cmd/relayproxy/model"
 	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/contextschema"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
 	"net/http"
 	"testing"
 )
@@ -83,6 +85,7 @@ func Test_evaluationContextFromRequest(t *testing.T) {
 	tests := []struct {
 		name    string
 		req     *model.AllFlagRequest
+		schema  *contextschema.Schema
 		wantErr error
 		want    ffcontext.Context
 	}{
@@ -132,11 +135,61 @@ func Test_evaluationContextFromRequest(t *testing.T) {
 				AddCustom("custom-field", true).
 				Build(),
 		},
+		{
+			name: "EvaluationContext with a kind colliding with an attribute",
+			req: &model.AllFlagRequest{
+				EvaluationContext: &model.EvaluationContextRequest{
+					Key:    "key-1",
+					Custom: map[string]interface{}{"organization": "acme"},
+					Kinds:  map[string]map[string]interface{}{"organization": {"targetingKey": "org-1"}},
+				},
+			},
+			wantErr: echo.NewHTTPError(
+				http.StatusBadRequest,
+				"invalid evaluation context: impossible to create the multi-kind context: "+
+					"the kind organization is also an attribute of the kind user"),
+		},
+		{
+			name: "EvaluationContext rejected by a strict context schema",
+			req: &model.AllFlagRequest{
+				EvaluationContext: &model.EvaluationContextRequest{
+					Key:    "key-1",
+					Custom: map[string]interface{}{"Plan": "pro"},
+				},
+			},
+			schema: &contextschema.Schema{
+				Mode: testconvert.String(contextschema.ModeStrict),
+				Attributes: map[string]contextschema.Attribute{
+					"plan": {Required: testconvert.Bool(true)},
+				},
+			},
+			wantErr: echo.NewHTTPError(
+				http.StatusBadRequest,
+				"invalid evaluation context: missing required attribute plan"),
+		},
+		{
+			name: "EvaluationContext following a strict context schema",
+			req: &model.AllFlagRequest{
+				EvaluationContext: &model.EvaluationContextRequest{
+					Key:    "key-1",
+					Custom: map[string]interface{}{"plan": "pro"},
+				},
+			},
+			schema: &contextschema.Schema{
+				Mode: testconvert.String(contextschema.ModeStrict),
+				Attributes: map[string]contextschema.Attribute{
+					"plan": {Required: testconvert.Bool(true)},
+				},
+			},
+			want: ffcontext.NewEvaluationContextBuilder("key-1").
+				AddCustom("plan", "pro").
+				Build(),
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := evaluationContextFromRequest(tt.req)
+			got, err := evaluationContextFromRequest(tt.req, tt.schema)
 			if err != nil {
 				assert.Error(t, err)
 				assert.Equal(t, tt.wantErr, err)
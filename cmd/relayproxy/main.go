@@ -85,6 +85,12 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	if err := metricsV2.RegisterEvaluationCache(goff.GetEvaluationCacheStats); err != nil {
+		zapLog.Error("impossible to initialize the evaluation cache metrics", zap.Error(err))
+	}
+	if err := metricsV2.RegisterInvalidEvaluationContexts(goff.GetInvalidEvaluationContextCount); err != nil {
+		zapLog.Error("impossible to initialize the invalid evaluation contexts metric", zap.Error(err))
+	}
 
 	services := service.Services{
 		MonitoringService:    service.NewMonitoring(goff),
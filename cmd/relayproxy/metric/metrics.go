@@ -2,6 +2,7 @@ package metric
 
 import (
 	prom "github.com/prometheus/client_golang/prometheus"
+	ffclient "github.com/thomaspoignant/go-feature-flag"
 )
 
 // GOFFSubSystem is the name of the prefix we are using for all the metrics
@@ -200,3 +201,55 @@ func (m *Metrics) IncFlagChange() {
 		m.flagChange.Inc()
 	}
 }
+
+// RegisterEvaluationCache exposes the counters of the evaluation cache of go-feature-flag,
+// the stats function is called each time the metrics are collected.
+func (m *Metrics) RegisterEvaluationCache(stats func() ffclient.EvaluationCacheStats) error {
+	if m.Registry == nil {
+		return nil
+	}
+
+	// counts the number of evaluations served from the evaluation cache
+	hitsCounter := prom.NewCounterFunc(prom.CounterOpts{
+		Name:      "evaluation_cache_hits_total",
+		Help:      "Counter of the evaluations served from the evaluation cache.",
+		Subsystem: GOFFSubSystem,
+	}, func() float64 { return float64(stats().Hits) })
+
+	// counts the number of evaluations not found in the evaluation cache
+	missesCounter := prom.NewCounterFunc(prom.CounterOpts{
+		Name:      "evaluation_cache_misses_total",
+		Help:      "Counter of the evaluations not found in the evaluation cache.",
+		Subsystem: GOFFSubSystem,
+	}, func() float64 { return float64(stats().Misses) })
+
+	// number of evaluation results in the evaluation cache
+	sizeGauge := prom.NewGaugeFunc(prom.GaugeOpts{
+		Name:      "evaluation_cache_size",
+		Help:      "Number of evaluation results in the evaluation cache.",
+		Subsystem: GOFFSubSystem,
+	}, func() float64 { return float64(stats().Size) })
+
+	for _, metric := range []prom.Collector{hitsCounter, missesCounter, sizeGauge} {
+		if err := m.Registry.Register(metric); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterInvalidEvaluationContexts exposes the number of evaluations done with an evaluation context not
+// following the context schema, the count function is called each time the metrics are collected.
+func (m *Metrics) RegisterInvalidEvaluationContexts(count func() uint64) error {
+	if m.Registry == nil {
+		return nil
+	}
+
+	// counts the number of evaluations done with an invalid evaluation context
+	invalidCounter := prom.NewCounterFunc(prom.CounterOpts{
+		Name:      "invalid_evaluation_contexts_total",
+		Help:      "Counter of the evaluations done with an evaluation context not following the context schema.",
+		Subsystem: GOFFSubSystem,
+	}, func() float64 { return float64(count()) })
+	return m.Registry.Register(invalidCounter)
+}
@@ -1,9 +1,12 @@
 package metric
 
 import (
+	"strings"
+	"testing"
+
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
-	"testing"
+	ffclient "github.com/thomaspoignant/go-feature-flag"
 )
 
 func TestMetrics_IncAllFlag(t *testing.T) {
@@ -95,3 +98,44 @@ func TestMetrics_IncForceRefresh(t *testing.T) {
 
 	assert.Equal(t, 3.0, testutil.ToFloat64(metricSrv.forceRefreshCounter))
 }
+
+func TestMetrics_RegisterEvaluationCache(t *testing.T) {
+	metricSrv, err := NewMetrics()
+	assert.NoError(t, err)
+
+	err = metricSrv.RegisterEvaluationCache(func() ffclient.EvaluationCacheStats {
+		return ffclient.EvaluationCacheStats{Hits: 3, Misses: 2, Size: 1}
+	})
+	assert.NoError(t, err)
+
+	expected := `
+# HELP gofeatureflag_evaluation_cache_hits_total Counter of the evaluations served from the evaluation cache.
+# TYPE gofeatureflag_evaluation_cache_hits_total counter
+gofeatureflag_evaluation_cache_hits_total 3
+# HELP gofeatureflag_evaluation_cache_misses_total Counter of the evaluations not found in the evaluation cache.
+# TYPE gofeatureflag_evaluation_cache_misses_total counter
+gofeatureflag_evaluation_cache_misses_total 2
+# HELP gofeatureflag_evaluation_cache_size Number of evaluation results in the evaluation cache.
+# TYPE gofeatureflag_evaluation_cache_size gauge
+gofeatureflag_evaluation_cache_size 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(metricSrv.Registry, strings.NewReader(expected),
+		"gofeatureflag_evaluation_cache_hits_total", "gofeatureflag_evaluation_cache_misses_total",
+		"gofeatureflag_evaluation_cache_size"))
+}
+
+func TestMetrics_RegisterInvalidEvaluationContexts(t *testing.T) {
+	metricSrv, err := NewMetrics()
+	assert.NoError(t, err)
+
+	err = metricSrv.RegisterInvalidEvaluationContexts(func() uint64 { return 4 })
+	assert.NoError(t, err)
+
+	expected := `
+# HELP gofeatureflag_invalid_evaluation_contexts_total Counter of the evaluations done with an evaluation context not following the context schema.
+# TYPE gofeatureflag_invalid_evaluation_contexts_total counter
+gofeatureflag_invalid_evaluation_contexts_total 4
+`
+	assert.NoError(t, testutil.GatherAndCompare(metricSrv.Registry, strings.NewReader(expected),
+		"gofeatureflag_invalid_evaluation_contexts_total"))
+}
@@ -6,4 +6,19 @@ import "time"
 type InfoResponse struct {
 	// This is the last time when your flag file was read and store in the internal cache.
 	LatestCacheRefresh time.Time `json:"cacheRefresh" example:"2022-06-13T11:22:55.941628+02:00"`
+
+	// MembershipLists is the state of the membership lists used by the inList operator, indexed by their names.
+	MembershipLists map[string]MembershipListInfo `json:"membershipLists,omitempty"`
+}
+
+// MembershipListInfo is the state of a membership list
+type MembershipListInfo struct {
+	// Size is the number of values in the list.
+	Size int `json:"size" example:"200000"`
+
+	// LastRefresh is the last time the list was successfully retrieved.
+	LastRefresh time.Time `json:"lastRefresh" example:"2022-06-13T11:22:55.941628+02:00"`
+
+	// Error is the error of the last retrieval of the list, the previous values are kept in that case.
+	Error string `json:"error,omitempty" example:"connection refused"`
 }
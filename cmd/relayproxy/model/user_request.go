@@ -32,4 +32,8 @@ type EvaluationContextRequest struct {
 
 	// Custom is a map containing all extra information for this user.
 	Custom map[string]interface{} `json:"custom" xml:"custom" form:"custom" query:"custom"  swaggertype:"object,string" example:"email:contact@gofeatureflag.org,firstname:John,lastname:Doe,company:GO Feature Flag"` // nolint: lll
+
+	// Kinds contains the other kinds of a multi-kind context indexed by their names, the evaluation context
+	// is the kind "user". The key of a kind is its attribute "targetingKey".
+	Kinds map[string]map[string]interface{} `json:"kinds,omitempty" xml:"kinds,omitempty" form:"kinds" query:"kinds" swaggertype:"object"` // nolint: lll
 }
@@ -21,6 +21,9 @@ type FlagState struct {
 	// VariationType is the name of the variation used to have the flag value.
 	VariationType string `json:"variationType" example:"variation-A"`
 
+	// ValueType is the declared type of the flag value (bool, string, int, float or json), empty if not declared.
+	ValueType string `json:"valueType,omitempty" example:"int"`
+
 	// TrackEvents this flag is trackable.
 	TrackEvents bool `json:"trackEvents" example:"false"`
 	Failed      bool `json:"-"`
@@ -16,6 +16,35 @@ type EvalFlagDoc struct {
 	ErrorCode string `json:"errorCode" example:""`
 	// The flag value for this user.
 	Value interface{} `json:"value"`
+	// The declared type of the flag value (bool, string, int, float or json), empty if not declared.
+	ValueType string `json:"valueType,omitempty" example:"int"`
 	// Metadata is a field containing information about your flag such as an issue tracker link, a description, etc ...
 	Metadata *map[string]interface{} `json:"metadata" yaml:"metadata,omitempty" toml:"metadata,omitempty"`
 }
+
+// ExplainFlagDoc is the documentation struct for the Swagger doc.
+type ExplainFlagDoc struct {
+	EvalFlagDoc
+	// The step-by-step trace of the evaluation.
+	Trace ExplainTraceDoc `json:"trace"`
+}
+
+// ExplainTraceDoc is the documentation struct for the Swagger doc.
+type ExplainTraceDoc struct {
+	// Name of the flag evaluated.
+	FlagKey string `json:"flagKey" example:"my-flag"`
+	// Date used to evaluate the flag.
+	EvaluationDate string `json:"evaluationDate" example:"2024-01-15T10:00:00Z"`
+	// All the checks done during the evaluation, in order.
+	Steps []ExplainTraceStepDoc `json:"steps"`
+}
+
+// ExplainTraceStepDoc is the documentation struct for the Swagger doc.
+type ExplainTraceStepDoc struct {
+	// Kind of check.
+	Type string `json:"type" example:"RULE"`
+	// Human-readable explanation of the result of the check.
+	Message string `json:"message" example:"rule #0 (beta testers) does not match the evaluation context"`
+	// Details of the evaluation of a rule (query, missing attributes, bucketing, ...).
+	Rule map[string]interface{} `json:"rule,omitempty"`
+}
@@ -8,10 +8,12 @@ import (
 	"github.com/thomaspoignant/go-feature-flag/cmd/relayproxy/metric"
 	"github.com/thomaspoignant/go-feature-flag/cmd/relayproxy/model"
 	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/contextschema"
 	"github.com/thomaspoignant/go-feature-flag/internal/flag"
 	"github.com/thomaspoignant/go-feature-flag/internal/utils"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"maps"
 	http "net/http"
 	"sort"
 )
@@ -67,7 +69,7 @@ func (h *EvaluateCtrl) Evaluate(c echo.Context) error {
 			Key:                      flagKey,
 		})
 	}
-	evalCtx, err := evaluationContextFromOFREPRequest(reqBody.Context)
+	evalCtx, err := evaluationContextFromOFREPRequest(reqBody.Context, reqBody.Kinds, h.goFF.GetContextSchema())
 	if err != nil {
 		return c.JSON(
 			http.StatusBadRequest,
@@ -115,7 +117,7 @@ func (h *EvaluateCtrl) Evaluate(c echo.Context) error {
 		Value:    flagValue.Value,
 		Reason:   flagValue.Reason,
 		Variant:  flagValue.VariationType,
-		Metadata: flagValue.Metadata,
+		Metadata: addValueTypeInMetadata(flagValue.Metadata, flagValue.ValueType),
 	})
 }
 
@@ -150,7 +152,7 @@ func (h *EvaluateCtrl) BulkEvaluate(c echo.Context) error {
 	if err := assertOFREPEvaluateRequest(request); err != nil {
 		return c.JSON(http.StatusBadRequest, err)
 	}
-	evalCtx, err := evaluationContextFromOFREPRequest(request.Context)
+	evalCtx, err := evaluationContextFromOFREPRequest(request.Context, request.Kinds, h.goFF.GetContextSchema())
 	if err != nil {
 		return c.JSON(
 			http.StatusBadRequest,
@@ -177,7 +179,7 @@ func (h *EvaluateCtrl) BulkEvaluate(c echo.Context) error {
 				Value:    value,
 				Reason:   val.Reason,
 				Variant:  val.VariationType,
-				Metadata: val.Metadata,
+				Metadata: addValueTypeInMetadata(val.Metadata, val.ValueType),
 			},
 			ErrorCode: val.ErrorCode,
 		})
@@ -203,12 +205,35 @@ func assertOFREPEvaluateRequest(ofrepEvalReq *model.OFREPEvalFlagRequest) *model
 	return nil
 }
 
-func evaluationContextFromOFREPRequest(ctx map[string]any) (ffcontext.Context, error) {
+// evaluationContextFromOFREPRequest converts the context and the other kinds of the request into an evaluation
+// context, an INVALID_CONTEXT error is return for an invalid multi-kind context or, if the context schema is in
+// strict mode, for a context not following the schema.
+func evaluationContextFromOFREPRequest(
+	ctx map[string]any, kinds map[string]map[string]any, schema *contextschema.Schema,
+) (ffcontext.Context, error) {
 	if targetingKey, ok := ctx["targetingKey"].(string); ok {
-		evalCtx := utils.ConvertEvaluationCtxFromRequest(targetingKey, ctx)
+		evalCtx, err := utils.ConvertEvaluationCtxFromRequestWithSchema(targetingKey, ctx, kinds, schema)
+		if err != nil {
+			return evalCtx, NewOFREPCommonError(flag.ErrorCodeInvalidContext,
+				fmt.Sprintf("invalid evaluation context: %s", err))
+		}
 		return evalCtx, nil
 	}
 	return ffcontext.EvaluationContext{}, NewOFREPCommonError(
 		flag.ErrorCodeTargetingKeyMissing,
 		"GO Feature Flag has received no targetingKey or a none string value that is not a string.")
 }
+
+// addValueTypeInMetadata adds the declared type of the flag in the metadata of the response,
+// so the providers know the type of the value even if it cannot be guessed from the JSON (ex: 3 for a float flag).
+func addValueTypeInMetadata(metadata map[string]any, valueType string) map[string]any {
+	if valueType == "" {
+		return metadata
+	}
+	result := maps.Clone(metadata)
+	if result == nil {
+		result = make(map[string]any)
+	}
+	result["valueType"] = valueType
+	return result
+}
@@ -212,6 +212,18 @@ func Test_Evaluate(t *testing.T) {
 				bodyFile: "../testdata/ofrep/responses/no_targeting_key_context_with_key.json",
 			},
 		},
+		{
+			name: "context rejected by a strict context schema",
+			args: args{
+				bodyFile:            "../testdata/ofrep/valid_request.json",
+				configFlagsLocation: "../testdata/controller/config_flags_context_schema.yaml",
+				flagKey:             "number-flag",
+			},
+			want: want{
+				httpCode: http.StatusBadRequest,
+				bodyFile: "../testdata/ofrep/responses/invalid_context_schema_with_key.json",
+			},
+		},
 		{
 			name: "Empty flag key",
 			args: args{
@@ -66,6 +66,16 @@ func NewGoFeatureFlagClient(
 		}
 	}
 
+	membershipLists := make(map[string]retriever.Retriever, len(proxyConf.MembershipLists))
+	for name, r := range proxyConf.MembershipLists {
+		r := r
+		listRetriever, err := initRetriever(&r)
+		if err != nil {
+			return nil, err
+		}
+		membershipLists[name] = listRetriever
+	}
+
 	var exp ffclient.DataExporter
 	if proxyConf.Exporter != nil {
 		exp, err = initDataExporter(proxyConf.Exporter)
@@ -95,6 +105,8 @@ func NewGoFeatureFlagClient(
 		StartWithRetrieverError:     proxyConf.StartWithRetrieverError,
 		EnablePollingJitter:         proxyConf.EnablePollingJitter,
 		EvaluationContextEnrichment: proxyConf.EvaluationContextEnrichment,
+		MembershipLists:             membershipLists,
+		EvaluationCacheSize:         proxyConf.EvaluationCacheSize,
 	}
 
 	return ffclient.New(f)
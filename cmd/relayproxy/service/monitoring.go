@@ -32,7 +32,18 @@ func (m *monitoringImpl) Health() model.HealthResponse {
 
 // Info returns information about the relay-proxy
 func (m *monitoringImpl) Info() model.InfoResponse {
-	return model.InfoResponse{
+	info := model.InfoResponse{
 		LatestCacheRefresh: m.goFF.GetCacheRefreshDate(),
 	}
+	for name, status := range m.goFF.GetMembershipListsStatus() {
+		if info.MembershipLists == nil {
+			info.MembershipLists = make(map[string]model.MembershipListInfo)
+		}
+		info.MembershipLists[name] = model.MembershipListInfo{
+			Size:        status.Size,
+			LastRefresh: status.LastRefresh,
+			Error:       status.Error,
+		}
+	}
+	return info
 }
@@ -8,10 +8,16 @@ import (
 	"time"
 
 	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/stickybucketing"
 
 	"github.com/thomaspoignant/go-feature-flag/notifier"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// defaultStickyBucketingTimeout is the maximum duration of the calls to the sticky bucketing store
+// during an evaluation if Config.StickyBucketingTimeout is not set.
+const defaultStickyBucketingTimeout = 100 * time.Millisecond
+
 // Config is the configuration of go-feature-flag.
 // You should also have a retriever to specify where to read the flags file.
 type Config struct {
@@ -79,6 +85,42 @@ type Config struct {
 	// Default: nil
 	EvaluationContextEnrichment map[string]interface{}
 
+	// StickyBucketingStore (optional) keeps the variation served to an evaluation context by a percentage rule.
+	// When set, an evaluation context keeps its first variation even if the percentages or the variations of
+	// the flag change, use GoFeatureFlag.ResetStickyAssignments to bucket the evaluation contexts again.
+	// Default: nil, the variation is computed at each evaluation
+	StickyBucketingStore stickybucketing.Store
+
+	// StickyBucketingTimeout (optional) is the maximum duration of the calls to the StickyBucketingStore during an
+	// evaluation, after it the variation is computed as if no store was configured.
+	// Default: 100 milliseconds
+	StickyBucketingTimeout time.Duration
+
+	// MembershipLists (optional) are large lists of values (ex: account IDs exported from another system),
+	// each one loaded by its own retriever and refreshed at the same time as the flags.
+	// The content of a list is either a JSON array or one value per line, and a rule checks if the evaluation
+	// context is part of a list with the inList("name") operator.
+	// Default: nil
+	MembershipLists map[string]retriever.Retriever
+
+	// TracerProvider (optional) is used by the variation functions accepting a context.Context
+	// (ex: BoolVariationWithContext) to create a span for each evaluation, with the key of the flag,
+	// the variant and the reason of the evaluation as attributes.
+	// Default: nil, no span is created
+	TracerProvider trace.TracerProvider
+
+	// EvaluationCacheSize (optional) is the maximum number of evaluation results kept in an LRU cache,
+	// it avoids evaluating again the rules of a flag for the same evaluation context.
+	// Only the results that can be cached are kept, and the cache is emptied each time the flags or the membership
+	// lists are updated and each time the sticky assignments are reset.
+	// Default: 0, no cache
+	EvaluationCacheSize int
+
+	// Hooks (optional) are called around each evaluation of a flag, the Before hooks in the order of the slice
+	// and the After, Error and Finally hooks in the reverse order.
+	// Default: nil
+	Hooks []Hook
+
 	// offlineMutex is a mutex to protect the Offline field.
 	offlineMutex *sync.RWMutex
 }
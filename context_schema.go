@@ -0,0 +1,118 @@
+package ffclient
+
+import (
+	"maps"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/contextschema"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/internal/flagstate"
+	"github.com/thomaspoignant/go-feature-flag/internal/utils"
+	"github.com/thomaspoignant/go-feature-flag/model"
+	"github.com/thomaspoignant/go-feature-flag/utils/fflog"
+)
+
+// invalidEvaluationContextLogInterval is the minimum interval between two warnings logged for the invalid
+// evaluation contexts in lenient mode, the total number is available with GetInvalidEvaluationContextCount.
+const invalidEvaluationContextLogInterval = time.Minute
+
+// GetInvalidEvaluationContextCount returns the number of evaluations done with an evaluation context
+// not following the context schema of the configuration.
+func GetInvalidEvaluationContextCount() uint64 {
+	return ff.GetInvalidEvaluationContextCount()
+}
+
+// GetInvalidEvaluationContextCount returns the number of evaluations done with an evaluation context
+// not following the context schema of the configuration.
+// Note: Use this function only if you are using multiple go-feature-flag instances.
+func (g *GoFeatureFlag) GetInvalidEvaluationContextCount() uint64 {
+	if g == nil {
+		return 0
+	}
+	return g.invalidEvaluationContexts.Load()
+}
+
+// GetContextSchema returns the context schema of the configuration, nil if there is none.
+// This is mostly used by software built on top of go-feature-flag such as go-feature-flag relay proxy.
+func (g *GoFeatureFlag) GetContextSchema() *contextschema.Schema {
+	if g == nil || g.config.Offline || g.cache == nil {
+		return nil
+	}
+	return g.cache.GetContextSchema()
+}
+
+// validateEvaluationContext checks the evaluation context (with the request attributes) against the context
+// schema of the configuration.
+// An invalid evaluation context is counted and logged (at most once per invalidEvaluationContextLogInterval),
+// an error is returned only if the schema is in strict mode.
+func (g *GoFeatureFlag) validateEvaluationContext(
+	evaluationCtx ffcontext.Context, requestAttributes map[string]interface{},
+) error {
+	schema := g.GetContextSchema()
+	if schema == nil || evaluationCtx == nil {
+		return nil
+	}
+	attributes := utils.ContextToMap(evaluationCtx)
+	maps.Copy(attributes, requestAttributes)
+	err := schema.Validate(attributes)
+	if err == nil {
+		return nil
+	}
+
+	count := g.invalidEvaluationContexts.Add(1)
+	if schema.IsStrict() {
+		return err
+	}
+	if g.shouldLogInvalidEvaluationContext() {
+		fflog.Printf(g.config.Logger, "warning: invalid evaluation context: %v "+
+			"(%d invalid evaluation contexts in total, this warning is logged at most once per %v)",
+			err, count, invalidEvaluationContextLogInterval)
+	}
+	return nil
+}
+
+// shouldLogInvalidEvaluationContext returns true if no warning has been logged for an invalid evaluation context
+// during the last invalidEvaluationContextLogInterval, only one of the concurrent evaluations logs the warning.
+func (g *GoFeatureFlag) shouldLogInvalidEvaluationContext() bool {
+	now := time.Now().UnixNano()
+	last := g.lastInvalidEvaluationContextLog.Load()
+	if last != 0 && now-last < int64(invalidEvaluationContextLogInterval) {
+		return false
+	}
+	return g.lastInvalidEvaluationContextLog.CompareAndSwap(last, now)
+}
+
+// invalidContextResult is the result of an evaluation with an evaluation context rejected by the context schema.
+func invalidContextResult[T model.JSONType](f flag.Flag, sdkDefaultValue T, err error) model.VariationResult[T] {
+	return model.VariationResult[T]{
+		Value:         sdkDefaultValue,
+		VariationType: flag.VariationSDKDefault,
+		Reason:        flag.ReasonError,
+		ErrorCode:     flag.ErrorCodeInvalidContext,
+		ErrorDetails:  err.Error(),
+		Failed:        true,
+		TrackEvents:   f.IsTrackEvents(),
+		Version:       f.GetVersion(),
+		Metadata:      f.GetMetadata(),
+		ValueType:     f.GetType(),
+	}
+}
+
+// invalidContextFlagsState is the state of all the flags for an evaluation context rejected by the context schema.
+func invalidContextFlagsState(flags map[string]flag.Flag) flagstate.AllFlags {
+	allFlags := flagstate.NewAllFlags()
+	for key, currentFlag := range flags {
+		allFlags.AddFlag(key, flagstate.FlagState{
+			Timestamp:     time.Now().Unix(),
+			VariationType: flag.VariationSDKDefault,
+			ValueType:     currentFlag.GetType(),
+			TrackEvents:   currentFlag.IsTrackEvents(),
+			Failed:        true,
+			ErrorCode:     flag.ErrorCodeInvalidContext,
+			Reason:        flag.ReasonError,
+			Metadata:      currentFlag.GetMetadata(),
+		})
+	}
+	return allFlags
+}
@@ -0,0 +1,151 @@
+package ffclient
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/contextschema"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+func newTenantSchema(mode contextschema.Mode) *contextschema.Schema {
+	return &contextschema.Schema{
+		Mode: testconvert.String(mode),
+		Attributes: map[string]contextschema.Attribute{
+			"tenant": {Type: testconvert.String(contextschema.TypeString), Required: testconvert.Bool(true)},
+		},
+	}
+}
+
+func TestBoolVariationDetails_ContextSchema(t *testing.T) {
+	tests := []struct {
+		name              string
+		schema            *contextschema.Schema
+		evaluationCtx     ffcontext.Context
+		want              bool
+		wantErrorCode     flag.ErrorCode
+		wantErrorDetails  string
+		wantErr           string
+		wantInvalidCount  uint64
+		wantLogContaining string
+	}{
+		{
+			name:          "no context schema",
+			evaluationCtx: ffcontext.NewEvaluationContextBuilder("user-key").AddCustom("Tenant", "acme").Build(),
+			want:          false,
+		},
+		{
+			name:          "valid evaluation context",
+			schema:        newTenantSchema(contextschema.ModeStrict),
+			evaluationCtx: ffcontext.NewEvaluationContextBuilder("user-key").AddCustom("tenant", "acme").Build(),
+			want:          true,
+		},
+		{
+			name:             "strict mode returns the default value",
+			schema:           newTenantSchema(contextschema.ModeStrict),
+			evaluationCtx:    ffcontext.NewEvaluationContextBuilder("user-key").AddCustom("Tenant", "acme").Build(),
+			want:             false,
+			wantErrorCode:    flag.ErrorCodeInvalidContext,
+			wantErrorDetails: "missing required attribute tenant",
+			wantErr:          "invalid evaluation context for the flag plan: missing required attribute tenant",
+			wantInvalidCount: 1,
+		},
+		{
+			name:              "lenient mode evaluates the flag",
+			schema:            newTenantSchema(contextschema.ModeLenient),
+			evaluationCtx:     ffcontext.NewEvaluationContextBuilder("user-key").AddCustom("tenant", 42).Build(),
+			want:              false,
+			wantInvalidCount:  1,
+			wantLogContaining: "warning: invalid evaluation context: attribute tenant should be of type string",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := &bytes.Buffer{}
+			goff := &GoFeatureFlag{
+				cache:  &cacheMock{flag: newPlanFlag(), contextSchema: tt.schema},
+				config: Config{Logger: log.New(logs, "", 0)},
+			}
+
+			got, err := goff.BoolVariationDetails("plan", tt.evaluationCtx, false)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.True(t, got.Failed)
+				assert.Equal(t, flag.VariationSDKDefault, got.VariationType)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got.Value)
+			assert.Equal(t, tt.wantErrorCode, got.ErrorCode)
+			assert.Equal(t, tt.wantErrorDetails, got.ErrorDetails)
+			assert.Equal(t, tt.wantInvalidCount, goff.GetInvalidEvaluationContextCount())
+			assert.Contains(t, logs.String(), tt.wantLogContaining)
+		})
+	}
+}
+
+func TestBoolVariationDetails_ContextSchemaLogsAtMostOncePerInterval(t *testing.T) {
+	logs := &bytes.Buffer{}
+	goff := &GoFeatureFlag{
+		cache:  &cacheMock{flag: newPlanFlag(), contextSchema: newTenantSchema(contextschema.ModeLenient)},
+		config: Config{Logger: log.New(logs, "", 0)},
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := goff.BoolVariationDetails("plan", ffcontext.NewEvaluationContext("user-key"), false)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, uint64(3), goff.GetInvalidEvaluationContextCount())
+	assert.Equal(t, 1, strings.Count(logs.String(), "warning: invalid evaluation context"))
+
+	// the next warning is logged once the interval is over
+	goff.lastInvalidEvaluationContextLog.Store(time.Now().Add(-invalidEvaluationContextLogInterval).UnixNano())
+	_, err := goff.BoolVariationDetails("plan", ffcontext.NewEvaluationContext("user-key"), false)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(logs.String(), "warning: invalid evaluation context"))
+	assert.Contains(t, logs.String(), "(4 invalid evaluation contexts in total")
+}
+
+func TestBoolVariationWithContext_ContextSchemaWithRequestAttributes(t *testing.T) {
+	goff := &GoFeatureFlag{
+		cache: &cacheMock{flag: newPlanFlag(), contextSchema: newTenantSchema(contextschema.ModeStrict)},
+	}
+	ctx := ffcontext.WithRequestAttributes(context.Background(), map[string]interface{}{"tenant": "acme"})
+
+	got, err := goff.BoolVariationWithContext(ctx, "plan", ffcontext.NewEvaluationContext("user-key"), false)
+	assert.NoError(t, err)
+	assert.True(t, got)
+	assert.Equal(t, uint64(0), goff.GetInvalidEvaluationContextCount())
+}
+
+// allFlagsCacheMock is a cacheMock returning its flag in AllFlags.
+type allFlagsCacheMock struct {
+	cacheMock
+}
+
+func (c *allFlagsCacheMock) AllFlags() (map[string]flag.Flag, error) {
+	return map[string]flag.Flag{"plan": c.flag}, nil
+}
+
+func TestAllFlagsState_ContextSchema(t *testing.T) {
+	goff := &GoFeatureFlag{
+		cache: &allFlagsCacheMock{
+			cacheMock: cacheMock{flag: newPlanFlag(), contextSchema: newTenantSchema(contextschema.ModeStrict)},
+		},
+	}
+
+	allFlags := goff.AllFlagsState(ffcontext.NewEvaluationContext("user-key"))
+	assert.Equal(t, flag.ErrorCodeInvalidContext, allFlags.GetFlags()["plan"].ErrorCode)
+	assert.True(t, allFlags.GetFlags()["plan"].Failed)
+
+	allFlags = goff.AllFlagsState(ffcontext.NewEvaluationContextBuilder("user-key").AddCustom("tenant", "acme").Build())
+	assert.Equal(t, true, allFlags.GetFlags()["plan"].Value)
+	assert.Equal(t, uint64(1), goff.GetInvalidEvaluationContextCount())
+}
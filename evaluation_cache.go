@@ -0,0 +1,118 @@
+package ffclient
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"hash/fnv"
+	"maps"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+)
+
+// EvaluationCacheStats contains the counters of the evaluation cache (see Config.EvaluationCacheSize).
+type EvaluationCacheStats struct {
+	// Hits is the number of evaluations served from the cache.
+	Hits uint64
+	// Misses is the number of evaluations not found in the cache,
+	// the evaluations of the flags that cannot be cached are also counted.
+	Misses uint64
+	// Size is the number of evaluation results currently in the cache.
+	Size int
+}
+
+// evaluationGeneration identifies the state of everything an evaluation depends on besides the flag and the
+// evaluation context, the evaluation cache is emptied as soon as one of them changes.
+type evaluationGeneration struct {
+	// flagsUpdate is the date (in nanoseconds) of the latest update of the flags.
+	flagsUpdate int64
+	// membershipLists is the version of the membership lists used by the inList operator.
+	membershipLists uint64
+	// stickyAssignmentsResets is the number of resets of the sticky bucketing store.
+	stickyAssignmentsResets uint64
+}
+
+// cachedEvaluation is the result of an evaluation kept in the evaluation cache.
+type cachedEvaluation struct {
+	value   interface{}
+	details flag.ResolutionDetails
+}
+
+// GetEvaluationCacheStats returns the counters of the evaluation cache,
+// all the counters are 0 if the cache is not enabled.
+func GetEvaluationCacheStats() EvaluationCacheStats {
+	return ff.GetEvaluationCacheStats()
+}
+
+// GetEvaluationCacheStats returns the counters of the evaluation cache,
+// all the counters are 0 if the cache is not enabled.
+// Note: Use this function only if you are using multiple go-feature-flag instances.
+func (g *GoFeatureFlag) GetEvaluationCacheStats() EvaluationCacheStats {
+	if g == nil || g.evaluationCache == nil {
+		return EvaluationCacheStats{}
+	}
+	stats := g.evaluationCache.Stats()
+	return EvaluationCacheStats{Hits: stats.Hits, Misses: stats.Misses, Size: stats.Size}
+}
+
+// evaluateFlag evaluates the flag, the result is read from the evaluation cache if it is enabled.
+// Only the cacheable results are kept, and the cache is emptied each time the flags or the membership lists
+// are updated and each time the sticky assignments are reset.
+func (g *GoFeatureFlag) evaluateFlag(
+	f flag.Flag, flagKey string, evaluationCtx ffcontext.Context, flagCtx flag.Context,
+) (interface{}, flag.ResolutionDetails) {
+	if g.evaluationCache == nil || !flagCtx.EvaluationDate.IsZero() {
+		return f.Value(flagKey, evaluationCtx, flagCtx)
+	}
+	key, err := evaluationCacheKey(flagKey, f.GetVersion(), evaluationCtx, flagCtx.EvaluationContextEnrichment)
+	if err != nil {
+		return f.Value(flagKey, evaluationCtx, flagCtx)
+	}
+
+	generation := g.evaluationGeneration()
+	if cached, ok := g.evaluationCache.Get(generation, key); ok {
+		return cached.value, cached.details
+	}
+	value, details := f.Value(flagKey, evaluationCtx, flagCtx)
+	if details.Cacheable && details.ErrorCode == "" && details.Variant != flag.VariationSDKDefault {
+		g.evaluationCache.Set(generation, key, cachedEvaluation{value: value, details: details})
+	}
+	return value, details
+}
+
+// evaluationGeneration returns the current generation of the evaluation cache.
+func (g *GoFeatureFlag) evaluationGeneration() evaluationGeneration {
+	generation := evaluationGeneration{
+		flagsUpdate:             g.cache.GetLatestUpdateDate().UnixNano(),
+		stickyAssignmentsResets: g.stickyAssignmentsResets.Load(),
+	}
+	if g.membershipLists != nil {
+		generation.membershipLists = g.membershipLists.Version()
+	}
+	return generation
+}
+
+// evaluationCacheKey is the key of an evaluation in the evaluation cache, made of the key and the version of
+// the flag and of a hash of the evaluation context with its enrichment.
+// The enrichment is applied to a copy of the attributes as during the evaluation, so the key is the same
+// if the evaluation context has already been enriched by a previous evaluation.
+func evaluationCacheKey(
+	flagKey string, version string, evaluationCtx ffcontext.Context, enrichment map[string]interface{},
+) (string, error) {
+	custom := make(map[string]interface{}, len(evaluationCtx.GetCustom())+len(enrichment))
+	maps.Copy(custom, evaluationCtx.GetCustom())
+	maps.Copy(custom, enrichment)
+	content, err := json.Marshal(struct {
+		Key    string                 `json:"key"`
+		Custom map[string]interface{} `json:"custom"`
+	}{
+		Key:    evaluationCtx.GetKey(),
+		Custom: custom,
+	})
+	if err != nil {
+		return "", err
+	}
+	hash := fnv.New128a()
+	_, _ = hash.Write(content)
+	return flagKey + "\x00" + version + "\x00" + hex.EncodeToString(hash.Sum(nil)), nil
+}
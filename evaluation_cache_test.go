@@ -0,0 +1,178 @@
+package ffclient
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/evaluationcache"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/internal/membershiplist"
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
+	"github.com/thomaspoignant/go-feature-flag/stickybucketing/inmemorystore"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+// countingFlag counts the number of evaluations of the flag.
+type countingFlag struct {
+	*flag.InternalFlag
+	evaluations int
+}
+
+func (f *countingFlag) Value(
+	flagName string, evaluationCtx ffcontext.Context, flagContext flag.Context,
+) (interface{}, flag.ResolutionDetails) {
+	f.evaluations++
+	return f.InternalFlag.Value(flagName, evaluationCtx, flagContext)
+}
+
+func TestEvaluationCache(t *testing.T) {
+	f := &countingFlag{InternalFlag: newPlanFlag()}
+	cacheMock := &fixedDateCacheMock{cacheMock: cacheMock{flag: f}, latestUpdate: time.Now()}
+	goff := &GoFeatureFlag{
+		cache:           cacheMock,
+		evaluationCache: evaluationcache.New[evaluationGeneration, cachedEvaluation](10),
+	}
+	acme := ffcontext.NewEvaluationContextBuilder("user-1").AddCustom("tenant", "acme").Build()
+	other := ffcontext.NewEvaluationContextBuilder("user-1").AddCustom("tenant", "other").Build()
+
+	for i := 0; i < 3; i++ {
+		got, err := goff.BoolVariation("my-flag", acme, false)
+		assert.NoError(t, err)
+		assert.True(t, got)
+	}
+	assert.Equal(t, 1, f.evaluations, "the result should be read from the cache")
+	assert.Equal(t, EvaluationCacheStats{Hits: 2, Misses: 1, Size: 1}, goff.GetEvaluationCacheStats())
+
+	got, err := goff.BoolVariation("my-flag", other, false)
+	assert.NoError(t, err)
+	assert.False(t, got)
+	assert.Equal(t, 2, f.evaluations, "another evaluation context is not in the cache")
+
+	// an update of the flags empties the cache
+	cacheMock.latestUpdate = cacheMock.latestUpdate.Add(time.Minute)
+	_, _ = goff.BoolVariation("my-flag", acme, false)
+	assert.Equal(t, 3, f.evaluations)
+	assert.Equal(t, 1, goff.GetEvaluationCacheStats().Size)
+}
+
+func TestEvaluationCache_NotCacheable(t *testing.T) {
+	f := &countingFlag{InternalFlag: &flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"enabled":  testconvert.Interface(true),
+			"disabled": testconvert.Interface(false),
+		},
+		DefaultRule: &flag.Rule{
+			ProgressiveRollout: &flag.ProgressiveRollout{
+				Initial: &flag.ProgressiveRolloutStep{
+					Variation: testconvert.String("disabled"),
+					Date:      testconvert.Time(time.Now().Add(-time.Hour)),
+				},
+				End: &flag.ProgressiveRolloutStep{
+					Variation: testconvert.String("enabled"),
+					Date:      testconvert.Time(time.Now().Add(time.Hour)),
+				},
+			},
+		},
+	}}
+	goff := &GoFeatureFlag{
+		cache:           &fixedDateCacheMock{cacheMock: cacheMock{flag: f}, latestUpdate: time.Now()},
+		evaluationCache: evaluationcache.New[evaluationGeneration, cachedEvaluation](10),
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := goff.BoolVariation("my-flag", ffcontext.NewEvaluationContext("user-1"), false)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 3, f.evaluations)
+	assert.Equal(t, EvaluationCacheStats{Hits: 0, Misses: 3, Size: 0}, goff.GetEvaluationCacheStats())
+}
+
+func TestEvaluationCache_Disabled(t *testing.T) {
+	assert.Equal(t, EvaluationCacheStats{}, (&GoFeatureFlag{}).GetEvaluationCacheStats())
+}
+
+func TestEvaluationCache_MembershipListsRefresh(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "paying-accounts.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("account-1\n"), 0600))
+	lists := membershiplist.NewManager(ctx, map[string]retriever.Retriever{
+		"paying-accounts": &fileretriever.Retriever{Path: path},
+	}, nil)
+	assert.NoError(t, lists.Refresh(ctx))
+
+	f := &countingFlag{InternalFlag: &flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"enabled":  testconvert.Interface(true),
+			"disabled": testconvert.Interface(false),
+		},
+		Rules: &[]flag.Rule{
+			{
+				Query:           testconvert.String(`inList("paying-accounts", "accountId")`),
+				VariationResult: testconvert.String("enabled"),
+			},
+		},
+		DefaultRule: &flag.Rule{VariationResult: testconvert.String("disabled")},
+	}}
+	goff := &GoFeatureFlag{
+		cache:           &fixedDateCacheMock{cacheMock: cacheMock{flag: f}, latestUpdate: time.Now()},
+		membershipLists: lists,
+		evaluationCache: evaluationcache.New[evaluationGeneration, cachedEvaluation](10),
+	}
+	user := ffcontext.NewEvaluationContextBuilder("user-1").AddCustom("accountId", "account-1").Build()
+
+	for i := 0; i < 2; i++ {
+		got, err := goff.BoolVariation("my-flag", user, false)
+		assert.NoError(t, err)
+		assert.True(t, got)
+	}
+	assert.Equal(t, 1, f.evaluations, "the result should be read from the cache")
+
+	// a refresh of the membership lists empties the cache, even if the flags are not updated
+	assert.NoError(t, os.WriteFile(path, []byte("account-2\n"), 0600))
+	assert.NoError(t, lists.Refresh(ctx))
+	got, err := goff.BoolVariation("my-flag", user, true)
+	assert.NoError(t, err)
+	assert.False(t, got)
+	assert.Equal(t, 2, f.evaluations)
+}
+
+func TestEvaluationCache_ResetStickyAssignments(t *testing.T) {
+	store := &inmemorystore.Store{}
+	f := &countingFlag{InternalFlag: &flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"enabled":  testconvert.Interface(true),
+			"disabled": testconvert.Interface(false),
+		},
+		DefaultRule: &flag.Rule{
+			Percentages: &map[string]float64{"enabled": 100, "disabled": 0},
+		},
+	}}
+	goff := &GoFeatureFlag{
+		cache:           &fixedDateCacheMock{cacheMock: cacheMock{flag: f}, latestUpdate: time.Now()},
+		config:          Config{StickyBucketingStore: store},
+		evaluationCache: evaluationcache.New[evaluationGeneration, cachedEvaluation](10),
+	}
+	user := ffcontext.NewEvaluationContext("user-1")
+
+	// the evaluation context was assigned to another variation before
+	assert.NoError(t, store.Set(context.Background(), "my-flag", "user-1", "disabled"))
+	for i := 0; i < 2; i++ {
+		got, err := goff.BoolVariation("my-flag", user, true)
+		assert.NoError(t, err)
+		assert.False(t, got)
+	}
+	assert.Equal(t, 1, f.evaluations, "the result should be read from the cache")
+
+	// a reset of the assignments empties the cache
+	assert.NoError(t, goff.ResetStickyAssignments("my-flag"))
+	got, err := goff.BoolVariation("my-flag", user, false)
+	assert.NoError(t, err)
+	assert.True(t, got)
+	assert.Equal(t, 2, f.evaluations)
+}
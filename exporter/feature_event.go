@@ -2,6 +2,7 @@ package exporter
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/thomaspoignant/go-feature-flag/ffcontext"
@@ -20,6 +21,9 @@ func NewFeatureEvent(
 	if ctx.IsAnonymous() {
 		contextKind = "anonymousUser"
 	}
+	if multiContext, ok := ctx.(ffcontext.MultiContext); ok {
+		contextKind = strings.Join(multiContext.GetKinds(), ",")
+	}
 
 	return FeatureEvent{
 		Kind:         "feature",
@@ -43,7 +47,8 @@ type FeatureEvent struct {
 	Kind string `json:"kind" example:"feature" parquet:"name=kind, type=BYTE_ARRAY, convertedtype=UTF8"`
 
 	// ContextKind is the kind of context which generated an event. This will only be "anonymousUser" for events generated
-	// on behalf of an anonymous user or the reserved word "user" for events generated on behalf of a non-anonymous user
+	// on behalf of an anonymous user or the reserved word "user" for events generated on behalf of a non-anonymous user.
+	// For a multi-kind context, it contains the kinds of the context separated by a comma (ex: "organization,user").
 	ContextKind string `json:"contextKind,omitempty" example:"user" parquet:"name=contextKind, type=BYTE_ARRAY, convertedtype=UTF8"`
 
 	// UserKey The key of the user object used in a feature flag evaluation. Details for the user object used in a feature
@@ -75,6 +80,20 @@ type FeatureEvent struct {
 	// Source indicates where the event was generated.
 	// This is set to SERVER when the event was evaluated in the relay-proxy and PROVIDER_CACHE when it is evaluated from the cache.
 	Source string `json:"source" example:"SERVER" parquet:"name=source, type=BYTE_ARRAY, convertedtype=UTF8"`
+
+	// BucketingKey is the attribute of the evaluation context used to select the percentage bucket.
+	// It is omitted when the targeting key is used or when the variation was not selected using a percentage.
+	BucketingKey string `json:"bucketingKey,omitempty" example:"organizationId" parquet:"name=bucketingKey, type=BYTE_ARRAY, convertedtype=UTF8"`
+
+	// BucketingValue is the value of the bucketing attribute used to select the percentage bucket.
+	BucketingValue string `json:"bucketingValue,omitempty" example:"acme-corp" parquet:"name=bucketingValue, type=BYTE_ARRAY, convertedtype=UTF8"`
+
+	// Layer is the name of the experiment layer of the flag, it is omitted if the flag is not part of a layer.
+	Layer string `json:"layer,omitempty" example:"checkout" parquet:"name=layer, type=BYTE_ARRAY, convertedtype=UTF8"`
+
+	// LayerSlice is the slice of the layer where the evaluation context is: the key of the flag allocated to this
+	// slice, "holdout" for the global holdout or empty if the evaluation context is not allocated to any flag.
+	LayerSlice string `json:"layerSlice,omitempty" example:"new-checkout-button" parquet:"name=layerSlice, type=BYTE_ARRAY, convertedtype=UTF8"`
 }
 
 // MarshalInterface marshals all interface type fields in FeatureEvent into JSON-encoded string.
@@ -20,6 +20,11 @@ func TestNewFeatureEvent(t *testing.T) {
 		version   string
 		source    string
 	}
+	multiContext, err := ffcontext.NewMultiContext(map[string]ffcontext.EvaluationContext{
+		"user":         ffcontext.NewEvaluationContext("ABCD"),
+		"organization": ffcontext.NewEvaluationContext("org-1"),
+	})
+	assert.NoError(t, err)
 	tests := []struct {
 		name string
 		args args
@@ -41,6 +46,22 @@ func TestNewFeatureEvent(t *testing.T) {
 				Variation: "Default", Value: "YO", Default: false, Source: "SERVER",
 			},
 		},
+		{
+			name: "multi-kind context",
+			args: args{
+				user:      multiContext,
+				flagKey:   "random-key",
+				value:     "YO",
+				variation: "Default",
+				failed:    false,
+				version:   "",
+				source:    "SERVER",
+			},
+			want: exporter.FeatureEvent{
+				Kind: "feature", ContextKind: "organization,user", UserKey: "ABCD", CreationDate: time.Now().Unix(),
+				Key: "random-key", Variation: "Default", Value: "YO", Default: false, Source: "SERVER",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -3,12 +3,17 @@ package ffclient
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/thomaspoignant/go-feature-flag/exporter"
 	"github.com/thomaspoignant/go-feature-flag/internal/dto"
+	"github.com/thomaspoignant/go-feature-flag/internal/evaluationcache"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/internal/membershiplist"
 	"github.com/thomaspoignant/go-feature-flag/retriever"
 	"github.com/thomaspoignant/go-feature-flag/utils/fflog"
 
@@ -47,6 +52,23 @@ type GoFeatureFlag struct {
 	bgUpdater        backgroundUpdater
 	dataExporter     *exporter.Scheduler
 	retrieverManager *retriever.Manager
+	membershipLists  *membershiplist.Manager
+
+	// decodedVariations keeps the last value decoded by Variation for each flag and type.
+	decodedVariations sync.Map
+
+	// evaluationCache keeps the cacheable evaluation results, nil if Config.EvaluationCacheSize is not set.
+	evaluationCache *evaluationcache.Cache[evaluationGeneration, cachedEvaluation]
+	// stickyAssignmentsResets counts the calls to ResetStickyAssignments, the evaluation cache is emptied
+	// after each reset.
+	stickyAssignmentsResets atomic.Uint64
+
+	// invalidEvaluationContexts counts the evaluations done with an evaluation context not following the
+	// context schema of the configuration.
+	invalidEvaluationContexts atomic.Uint64
+	// lastInvalidEvaluationContextLog is the date (in nanoseconds) of the last warning logged for an invalid
+	// evaluation context, the warning is logged at most once per invalidEvaluationContextLogInterval.
+	lastInvalidEvaluationContextLog atomic.Int64
 }
 
 // ff is the default object for go-feature-flag
@@ -70,6 +92,10 @@ func New(config Config) (*GoFeatureFlag, error) {
 		// do nothing
 	}
 
+	if config.StickyBucketingTimeout <= 0 {
+		config.StickyBucketingTimeout = defaultStickyBucketingTimeout
+	}
+
 	if config.offlineMutex == nil {
 		config.offlineMutex = &sync.RWMutex{}
 	}
@@ -77,6 +103,9 @@ func New(config Config) (*GoFeatureFlag, error) {
 	goFF := &GoFeatureFlag{
 		config: config,
 	}
+	if config.EvaluationCacheSize > 0 {
+		goFF.evaluationCache = evaluationcache.New[evaluationGeneration, cachedEvaluation](config.EvaluationCacheSize)
+	}
 
 	if !config.Offline {
 		notifiers := config.Notifiers
@@ -86,7 +115,7 @@ func New(config Config) (*GoFeatureFlag, error) {
 
 		notificationService := cache.NewNotificationService(notifiers)
 		goFF.bgUpdater = newBackgroundUpdater(config.PollingInterval, config.EnablePollingJitter)
-		goFF.cache = cache.New(notificationService, config.Logger)
+		goFF.cache = cache.New(notificationService, config.Environment, config.Logger)
 
 		retrievers, err := config.GetRetrievers()
 		if err != nil {
@@ -98,6 +127,18 @@ func New(config Config) (*GoFeatureFlag, error) {
 			return nil, fmt.Errorf("impossible to initialize the retrievers, please check your configuration: %v", err)
 		}
 
+		if len(config.MembershipLists) > 0 {
+			goFF.membershipLists = membershiplist.NewManager(config.Context, config.MembershipLists, config.Logger)
+			err = goFF.membershipLists.Init(config.Context)
+			if err != nil && !config.StartWithRetrieverError {
+				return nil, fmt.Errorf("impossible to initialize the retrievers of the membership lists: %v", err)
+			}
+			err = goFF.refreshMembershipLists()
+			if err != nil && !config.StartWithRetrieverError {
+				return nil, err
+			}
+		}
+
 		err = retrieveFlagsAndUpdateCache(goFF.config, goFF.cache, goFF.retrieverManager)
 		if err != nil && !config.StartWithRetrieverError {
 			return nil, fmt.Errorf("impossible to retrieve the flags, please check your configuration: %v", err)
@@ -135,6 +176,12 @@ func (g *GoFeatureFlag) Close() {
 		if g.retrieverManager != nil {
 			_ = g.retrieverManager.Shutdown(g.config.Context)
 		}
+		if g.membershipLists != nil {
+			_ = g.membershipLists.Shutdown(g.config.Context)
+		}
+		if closer, ok := g.config.StickyBucketingStore.(io.Closer); ok {
+			_ = closer.Close()
+		}
 	}
 }
 
@@ -144,6 +191,9 @@ func (g *GoFeatureFlag) startFlagUpdaterDaemon() {
 		select {
 		case <-g.bgUpdater.ticker.C:
 			if !g.IsOffline() {
+				if err := g.refreshMembershipLists(); err != nil {
+					fflog.Printf(g.config.Logger, "error while updating the membership lists: %v\n", err)
+				}
 				err := retrieveFlagsAndUpdateCache(g.config, g.cache, g.retrieverManager)
 				if err != nil {
 					fflog.Printf(g.config.Logger, "error while updating the cache: %v\n", err)
@@ -162,7 +212,7 @@ func retrieveFlagsAndUpdateCache(config Config, cache cache.Manager, retrieverMa
 	// all the retrievers.
 	type Results struct {
 		Error error
-		Value map[string]dto.DTO
+		Value dto.Configuration
 		Index int
 	}
 
@@ -185,13 +235,13 @@ func retrieveFlagsAndUpdateCache(config Config, cache cache.Manager, retrieverMa
 
 			// If the retriever is not ready, we ignore it
 			if rr, ok := r.(retriever.InitializableRetriever); ok && rr.Status() != retriever.RetrieverReady {
-				resultsChan <- Results{Error: nil, Value: map[string]dto.DTO{}, Index: index}
+				resultsChan <- Results{Error: nil, Value: dto.Configuration{}, Index: index}
 				return
 			}
 
 			rawValue, err := r.Retrieve(ctx)
 			if err != nil {
-				resultsChan <- Results{Error: err, Value: nil, Index: index}
+				resultsChan <- Results{Error: err, Value: dto.Configuration{}, Index: index}
 				return
 			}
 			convertedFlag, err := cache.ConvertToFlagStruct(rawValue, format)
@@ -199,7 +249,7 @@ func retrieveFlagsAndUpdateCache(config Config, cache cache.Manager, retrieverMa
 		}(r, config.FileFormat, index, config.Context)
 	}
 
-	retrieversResults := make([]map[string]dto.DTO, len(retrievers))
+	retrieversResults := make([]dto.Configuration, len(retrievers))
 	for v := range resultsChan {
 		if v.Error != nil {
 			return v.Error
@@ -207,15 +257,31 @@ func retrieveFlagsAndUpdateCache(config Config, cache cache.Manager, retrieverMa
 		retrieversResults[v.Index] = v.Value
 	}
 
-	// merge all the flags
-	newFlags := map[string]dto.DTO{}
-	for _, flags := range retrieversResults {
-		for flagName, value := range flags {
-			newFlags[flagName] = value
+	// merge all the flags, the segments, the layers, the holdout and the context schema
+	newConfig := dto.Configuration{
+		Flags:    map[string]dto.DTO{},
+		Segments: map[string]flag.Segment{},
+		Layers:   map[string]flag.Layer{},
+	}
+	for _, result := range retrieversResults {
+		for flagName, value := range result.Flags {
+			newConfig.Flags[flagName] = value
+		}
+		for segmentName, segment := range result.Segments {
+			newConfig.Segments[segmentName] = segment
+		}
+		for layerName, layer := range result.Layers {
+			newConfig.Layers[layerName] = layer
+		}
+		if result.Holdout != nil {
+			newConfig.Holdout = result.Holdout
+		}
+		if result.ContextSchema != nil {
+			newConfig.ContextSchema = result.ContextSchema
 		}
 	}
 
-	err := cache.UpdateCache(newFlags, config.Logger)
+	err := cache.UpdateCache(newConfig, config.Logger)
 	if err != nil {
 		log.Printf("error: impossible to update the cache of the flags: %v", err)
 		return err
@@ -223,6 +289,26 @@ func retrieveFlagsAndUpdateCache(config Config, cache cache.Manager, retrieverMa
 	return nil
 }
 
+// refreshMembershipLists retrieves the membership lists, a list that cannot be retrieved keeps its previous values.
+func (g *GoFeatureFlag) refreshMembershipLists() error {
+	if g.membershipLists == nil {
+		return nil
+	}
+	ctx := g.config.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return g.membershipLists.Refresh(ctx)
+}
+
+// GetMembershipListsStatus gives the size and the state of the latest refresh of each membership list.
+func (g *GoFeatureFlag) GetMembershipListsStatus() map[string]membershiplist.Status {
+	if g == nil || g.membershipLists == nil {
+		return map[string]membershiplist.Status{}
+	}
+	return g.membershipLists.GetStatus()
+}
+
 // GetCacheRefreshDate gives the date of the latest refresh of the cache
 func (g *GoFeatureFlag) GetCacheRefreshDate() time.Time {
 	if g.config.Offline {
@@ -238,6 +324,9 @@ func (g *GoFeatureFlag) ForceRefresh() bool {
 	if g.IsOffline() {
 		return false
 	}
+	if err := g.refreshMembershipLists(); err != nil {
+		fflog.Printf(g.config.Logger, "error while force updating the membership lists: %v\n", err)
+	}
 	err := retrieveFlagsAndUpdateCache(g.config, g.cache, g.retrieverManager)
 	if err != nil {
 		fflog.Printf(g.config.Logger, "error while force updating the cache: %v\n", err)
@@ -246,6 +335,24 @@ func (g *GoFeatureFlag) ForceRefresh() bool {
 	return true
 }
 
+// ResetStickyAssignments removes all the variations assigned to the evaluation contexts for a flag
+// in the sticky bucketing store, the evaluation contexts will be bucketed again at their next evaluation.
+func (g *GoFeatureFlag) ResetStickyAssignments(flagKey string) error {
+	if g == nil {
+		return fmt.Errorf("go-feature-flag is not initialised")
+	}
+	if g.config.StickyBucketingStore == nil {
+		return fmt.Errorf("no sticky bucketing store configured")
+	}
+	ctx := g.config.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// the cached results contain the variations assigned before the reset.
+	defer g.stickyAssignmentsResets.Add(1)
+	return g.config.StickyBucketingStore.Reset(ctx, flagKey)
+}
+
 // SetOffline updates the config Offline parameter
 func (g *GoFeatureFlag) SetOffline(control bool) {
 	g.config.SetOffline(control)
@@ -283,6 +390,17 @@ func ForceRefresh() bool {
 	return ff.ForceRefresh()
 }
 
+// GetMembershipListsStatus gives the size and the state of the latest refresh of each membership list.
+func GetMembershipListsStatus() map[string]membershiplist.Status {
+	return ff.GetMembershipListsStatus()
+}
+
+// ResetStickyAssignments removes all the variations assigned to the evaluation contexts for a flag
+// in the sticky bucketing store, the evaluation contexts will be bucketed again at their next evaluation.
+func ResetStickyAssignments(flagKey string) error {
+	return ff.ResetStickyAssignments(flagKey)
+}
+
 // Close the component by stopping the background refresh and clean the cache.
 func Close() {
 	onceFF = sync.Once{}
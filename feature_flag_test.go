@@ -1,9 +1,11 @@
 package ffclient_test
 
 import (
+	"context"
 	"errors"
 	"log"
 	"os"
+	"slices"
 	"testing"
 	"time"
 
@@ -15,6 +17,7 @@ import (
 	"github.com/thomaspoignant/go-feature-flag/retriever"
 	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
 	"github.com/thomaspoignant/go-feature-flag/retriever/s3retriever"
+	"github.com/thomaspoignant/go-feature-flag/stickybucketing/inmemorystore"
 	"github.com/thomaspoignant/go-feature-flag/testutils/initializableretriever"
 	"github.com/thomaspoignant/go-feature-flag/testutils/mock"
 )
@@ -551,3 +554,174 @@ func Test_ForceRefreshCache(t *testing.T) {
 	gffClient.ForceRefresh()
 	assert.Equal(t, time.Time{}, gffClient.GetCacheRefreshDate())
 }
+
+func TestGoFeatureFlag_StickyBucketing(t *testing.T) {
+	store := &inmemorystore.Store{}
+	gffClient, err := ffclient.New(ffclient.Config{
+		PollingInterval:      15 * time.Minute,
+		Retriever:            &fileretriever.Retriever{Path: "testdata/flag-config.yaml"},
+		StickyBucketingStore: store,
+	})
+	assert.NoError(t, err)
+	defer gffClient.Close()
+	user := ffcontext.NewEvaluationContext("random-key")
+
+	// the evaluation context was assigned to another variation before
+	assert.NoError(t, store.Set(context.Background(), "test-flag", "random-key", "False"))
+	got, err := gffClient.BoolVariation("test-flag", user, true)
+	assert.NoError(t, err)
+	assert.False(t, got)
+
+	assert.NoError(t, gffClient.ResetStickyAssignments("test-flag"))
+	got, err = gffClient.BoolVariation("test-flag", user, false)
+	assert.NoError(t, err)
+	assert.True(t, got)
+	variation, ok, _ := store.Get(context.Background(), "test-flag", "random-key")
+	assert.True(t, ok)
+	assert.Equal(t, "True", variation)
+}
+
+func TestGoFeatureFlag_ExplainStickyBucketing(t *testing.T) {
+	store := &inmemorystore.Store{}
+	gffClient, err := ffclient.New(ffclient.Config{
+		PollingInterval:      15 * time.Minute,
+		Retriever:            &fileretriever.Retriever{Path: "testdata/flag-config.yaml"},
+		StickyBucketingStore: store,
+	})
+	assert.NoError(t, err)
+	defer gffClient.Close()
+
+	// the hash of random-key serves True, the sticky assignment wins
+	assert.NoError(t, store.Set(context.Background(), "test-flag", "random-key", "False"))
+	got, err := gffClient.Explain("test-flag", ffcontext.NewEvaluationContext("random-key"), true)
+	assert.NoError(t, err)
+	assert.Equal(t, false, got.Value)
+	assert.Equal(t, "False", got.VariationType)
+	assert.True(t, slices.ContainsFunc(got.Trace.Steps, func(step flag.TraceStep) bool {
+		return step.Type == flag.TraceStepStickyAssignment
+	}))
+
+	// explaining the evaluation of a context without assignment does not assign it
+	got, err = gffClient.Explain("test-flag", ffcontext.NewEvaluationContext("another-key"), true)
+	assert.NoError(t, err)
+	assert.False(t, slices.ContainsFunc(got.Trace.Steps, func(step flag.TraceStep) bool {
+		return step.Type == flag.TraceStepStickyAssignment
+	}))
+	_, ok, err := store.Get(context.Background(), "test-flag", "another-key")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestGoFeatureFlag_StickyBucketingStoreCalls(t *testing.T) {
+	user := ffcontext.NewEvaluationContext("random-key")
+	tests := []struct {
+		name      string
+		evaluate  func(g *ffclient.GoFeatureFlag)
+		wantCalls int
+	}{
+		{
+			name: "variation",
+			evaluate: func(g *ffclient.GoFeatureFlag) {
+				_, _ = g.BoolVariation("test-flag", user, false)
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "variation with context",
+			evaluate: func(g *ffclient.GoFeatureFlag) {
+				_, _ = g.BoolVariationWithContext(context.Background(), "test-flag", user, false)
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "preview at a date",
+			evaluate: func(g *ffclient.GoFeatureFlag) {
+				_, _ = g.RawVariationAt("test-flag", user, false, time.Now().Add(time.Hour))
+			},
+			wantCalls: 0,
+		},
+		{
+			name: "explain",
+			evaluate: func(g *ffclient.GoFeatureFlag) {
+				_, _ = g.Explain("test-flag", user, false)
+			},
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mock.StickyBucketingStore{}
+			gffClient, err := ffclient.New(ffclient.Config{
+				PollingInterval:        15 * time.Minute,
+				Retriever:              &fileretriever.Retriever{Path: "testdata/flag-config.yaml"},
+				StickyBucketingStore:   store,
+				StickyBucketingTimeout: time.Minute,
+			})
+			assert.NoError(t, err)
+			defer gffClient.Close()
+
+			start := time.Now()
+			tt.evaluate(gffClient)
+			assert.Equal(t, tt.wantCalls, store.Calls)
+			assert.Len(t, store.Deadlines, tt.wantCalls, "the calls to the store should have a deadline")
+			for _, deadline := range store.Deadlines {
+				assert.WithinDuration(t, start.Add(time.Minute), deadline, 5*time.Second)
+			}
+		})
+	}
+}
+
+func TestGoFeatureFlag_ResetStickyAssignmentsWithoutStore(t *testing.T) {
+	gffClient, err := ffclient.New(ffclient.Config{
+		PollingInterval: 15 * time.Minute,
+		Retriever:       &fileretriever.Retriever{Path: "testdata/flag-config.yaml"},
+	})
+	assert.NoError(t, err)
+	defer gffClient.Close()
+	assert.Error(t, gffClient.ResetStickyAssignments("test-flag"))
+}
+
+func TestGoFeatureFlag_MembershipLists(t *testing.T) {
+	gffClient, err := ffclient.New(ffclient.Config{
+		PollingInterval: 15 * time.Minute,
+		Retriever:       &fileretriever.Retriever{Path: "testdata/flag-config-membership-list.yaml"},
+		MembershipLists: map[string]retriever.Retriever{
+			"paying-accounts": &fileretriever.Retriever{Path: "testdata/membership-list-paying-accounts.txt"},
+		},
+	})
+	assert.NoError(t, err)
+	defer gffClient.Close()
+
+	paying := ffcontext.NewEvaluationContextBuilder("user-1").AddCustom("accountId", "account-2").Build()
+	got, err := gffClient.BoolVariation("paying-feature", paying, false)
+	assert.NoError(t, err)
+	assert.True(t, got)
+
+	notPaying := ffcontext.NewEvaluationContextBuilder("user-2").AddCustom("accountId", "account-4").Build()
+	got, err = gffClient.BoolVariation("paying-feature", notPaying, true)
+	assert.NoError(t, err)
+	assert.False(t, got)
+
+	// the membership lists are also used when evaluating all the flags
+	allFlags := gffClient.AllFlagsState(paying)
+	assert.True(t, allFlags.IsValid())
+	assert.Equal(t, true, allFlags.GetFlags()["paying-feature"].Value)
+	assert.Equal(t, "enabled", allFlags.GetFlags()["paying-feature"].VariationType)
+	allFlags = gffClient.AllFlagsState(notPaying)
+	assert.Equal(t, false, allFlags.GetFlags()["paying-feature"].Value)
+
+	status := gffClient.GetMembershipListsStatus()
+	assert.Equal(t, 3, status["paying-accounts"].Size)
+	assert.Empty(t, status["paying-accounts"].Error)
+}
+
+func TestGoFeatureFlag_MembershipListsRetrieverError(t *testing.T) {
+	_, err := ffclient.New(ffclient.Config{
+		PollingInterval: 15 * time.Minute,
+		Retriever:       &fileretriever.Retriever{Path: "testdata/flag-config-membership-list.yaml"},
+		MembershipLists: map[string]retriever.Retriever{
+			"paying-accounts": &fileretriever.Retriever{Path: "testdata/does-not-exist.txt"},
+		},
+	})
+	assert.Error(t, err)
+}
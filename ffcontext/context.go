@@ -71,3 +71,24 @@ func (u EvaluationContext) AddCustomAttribute(name string, value interface{}) {
 		u.custom[name] = value
 	}
 }
+
+// Clone returns a copy of the context, the custom attributes added to the copy are not added to ctx.
+func Clone(ctx Context) Context {
+	switch c := ctx.(type) {
+	case EvaluationContext:
+		return EvaluationContext{key: c.key, custom: cloneValue(c.custom)}
+	case MultiContext:
+		return MultiContext{primaryKind: c.primaryKind, kinds: c.kinds, custom: cloneValue(c.custom)}
+	default:
+		return ctx
+	}
+}
+
+// cloneValue copies the custom attributes, the copy is never nil.
+func cloneValue(custom value) value {
+	clone := make(value, len(custom))
+	for k, v := range custom {
+		clone[k] = v
+	}
+	return clone
+}
@@ -42,3 +42,24 @@ func TestUser_AddCustomAttribute(t *testing.T) {
 		})
 	}
 }
+
+func TestClone(t *testing.T) {
+	user := ffcontext.NewEvaluationContextBuilder("123").AddCustom("plan", "pro").Build()
+	clone := ffcontext.Clone(user)
+	clone.AddCustomAttribute("tenant", "acme")
+	assert.Equal(t, "123", clone.GetKey())
+	assert.Equal(t, map[string]interface{}{"plan": "pro", "tenant": "acme"}, clone.GetCustom())
+	assert.Equal(t, map[string]interface{}{"plan": "pro"}, user.GetCustom())
+
+	multi, err := ffcontext.NewMultiContext(map[string]ffcontext.EvaluationContext{
+		"user":         user,
+		"organization": ffcontext.NewEvaluationContext("org-1"),
+	})
+	assert.NoError(t, err)
+	multiClone := ffcontext.Clone(multi)
+	multiClone.AddCustomAttribute("tenant", "acme")
+	assert.Equal(t, "123", multiClone.GetKey())
+	assert.Equal(t, "acme", multiClone.GetCustom()["tenant"])
+	assert.NotContains(t, multi.GetCustom(), "tenant")
+	assert.Equal(t, []string{"organization", "user"}, multiClone.(ffcontext.MultiContext).GetKinds())
+}
@@ -0,0 +1,114 @@
+package ffcontext
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultKind is the kind of the primary context of a MultiContext if it contains this kind.
+const DefaultKind = "user"
+
+// MultiContext is an evaluation context made of several kinds of contexts (ex: a user, its organization
+// and its device), each kind has its own key and its own attributes.
+//
+// In the queries, the attributes of a kind are available under the name of the kind
+// (ex: organization.plan eq "pro" or organization.key eq "org-1").
+// The primary kind gives the targeting key of the context and its attributes are also available at the root,
+// so the rules written for a single context still work.
+//
+// A kind can be used as the bucketing key of a flag or of a rule to bucket by organization, device, etc.
+type MultiContext struct {
+	primaryKind string
+	kinds       map[string]EvaluationContext
+	custom      value
+}
+
+// NewMultiContext creates a new evaluation context with several kinds, indexed by their names.
+// The primary kind is DefaultKind if it is part of the kinds, otherwise it is the first kind in alphabetical order.
+// An error is returned if the name of a kind is also an attribute of the primary kind, because both are
+// available at the root of the context.
+func NewMultiContext(kinds map[string]EvaluationContext) (MultiContext, error) {
+	names := make([]string, 0, len(kinds))
+	for name := range kinds {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	primaryKind := ""
+	if _, ok := kinds[DefaultKind]; ok {
+		primaryKind = DefaultKind
+	} else if len(names) > 0 {
+		primaryKind = names[0]
+	}
+
+	custom := value{}
+	if primary, ok := kinds[primaryKind]; ok {
+		for k, v := range primary.GetCustom() {
+			custom[k] = v
+		}
+	}
+	contexts := make(map[string]EvaluationContext, len(names))
+	for _, name := range names {
+		if _, ok := custom[name]; ok {
+			return MultiContext{}, fmt.Errorf(
+				"impossible to create the multi-kind context: the kind %s is also an attribute of the kind %s",
+				name, primaryKind)
+		}
+		kind := kinds[name]
+		contexts[name] = kind
+		attributes := make(map[string]interface{}, len(kind.GetCustom())+1)
+		for k, v := range kind.GetCustom() {
+			attributes[k] = v
+		}
+		attributes["key"] = kind.GetKey()
+		custom[name] = attributes
+	}
+
+	return MultiContext{primaryKind: primaryKind, kinds: contexts, custom: custom}, nil
+}
+
+// GetKey return the key of the primary kind.
+func (m MultiContext) GetKey() string {
+	return m.kinds[m.primaryKind].GetKey()
+}
+
+// IsAnonymous return if the primary kind is anonymous or not.
+func (m MultiContext) IsAnonymous() bool {
+	primary, ok := m.kinds[m.primaryKind]
+	return ok && primary.IsAnonymous()
+}
+
+// GetCustom return the attributes of the primary kind and the attributes of each kind under its name.
+func (m MultiContext) GetCustom() map[string]interface{} {
+	return m.custom
+}
+
+// AddCustomAttribute allows to add a custom attribute at the root of the context.
+func (m MultiContext) AddCustomAttribute(name string, value interface{}) {
+	if name != "" && m.custom != nil {
+		m.custom[name] = value
+	}
+}
+
+// GetPrimaryKind return the name of the kind used as targeting key.
+func (m MultiContext) GetPrimaryKind() string {
+	return m.primaryKind
+}
+
+// GetKinds return the names of all the kinds of the context in alphabetical order.
+func (m MultiContext) GetKinds() []string {
+	names := make([]string, 0, len(m.kinds))
+	for name := range m.kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetKind return the context of a kind, ok is false if the kind is not part of the context.
+func (m MultiContext) GetKind(name string) (EvaluationContext, bool) {
+	kind, ok := m.kinds[name]
+	return kind, ok
+}
@@ -0,0 +1,59 @@
+package ffcontext_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+)
+
+func TestNewMultiContext(t *testing.T) {
+	ctx, err := ffcontext.NewMultiContext(map[string]ffcontext.EvaluationContext{
+		"user": ffcontext.NewEvaluationContextBuilder("user-1").AddCustom("email", "john@example.com").Build(),
+		"organization": ffcontext.NewEvaluationContextBuilder("org-1").
+			AddCustom("plan", "pro").Build(),
+		"device": ffcontext.NewEvaluationContext("device-1"),
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "user", ctx.GetPrimaryKind())
+	assert.Equal(t, "user-1", ctx.GetKey())
+	assert.False(t, ctx.IsAnonymous())
+	assert.Equal(t, []string{"device", "organization", "user"}, ctx.GetKinds())
+	assert.Equal(t, map[string]interface{}{
+		"email":        "john@example.com",
+		"user":         map[string]interface{}{"key": "user-1", "email": "john@example.com"},
+		"organization": map[string]interface{}{"key": "org-1", "plan": "pro"},
+		"device":       map[string]interface{}{"key": "device-1"},
+	}, ctx.GetCustom())
+
+	organization, ok := ctx.GetKind("organization")
+	assert.True(t, ok)
+	assert.Equal(t, "org-1", organization.GetKey())
+	_, ok = ctx.GetKind("session")
+	assert.False(t, ok)
+
+	ctx.AddCustomAttribute("env", "prod")
+	assert.Equal(t, "prod", ctx.GetCustom()["env"])
+}
+
+func TestNewMultiContext_WithoutUserKind(t *testing.T) {
+	ctx, err := ffcontext.NewMultiContext(map[string]ffcontext.EvaluationContext{
+		"organization": ffcontext.NewEvaluationContextBuilder("org-1").AddCustom("anonymous", true).Build(),
+		"device":       ffcontext.NewEvaluationContext("device-1"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "device", ctx.GetPrimaryKind())
+	assert.Equal(t, "device-1", ctx.GetKey())
+	assert.False(t, ctx.IsAnonymous())
+}
+
+func TestNewMultiContext_KindCollidingWithAttribute(t *testing.T) {
+	_, err := ffcontext.NewMultiContext(map[string]ffcontext.EvaluationContext{
+		"user": ffcontext.NewEvaluationContextBuilder("user-1").
+			AddCustom("organization", "acme").Build(),
+		"organization": ffcontext.NewEvaluationContext("org-1"),
+	})
+	assert.EqualError(t, err, "impossible to create the multi-kind context: "+
+		"the kind organization is also an attribute of the kind user")
+}
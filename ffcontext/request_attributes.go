@@ -0,0 +1,32 @@
+package ffcontext
+
+import (
+	"context"
+	"maps"
+)
+
+// requestAttributesKey is the key used to store the request-scoped attributes in a context.Context.
+type requestAttributesKey struct{}
+
+// WithRequestAttributes returns a copy of ctx carrying request-scoped evaluation attributes
+// (ex: the tenant or the region of the request).
+// The attributes are added to the evaluation context by the variation functions accepting a context.Context,
+// they are merged with the attributes already registered in ctx, the new ones overriding the existing ones.
+func WithRequestAttributes(ctx context.Context, attributes map[string]interface{}) context.Context {
+	merged := maps.Clone(RequestAttributesFromContext(ctx))
+	if merged == nil {
+		merged = make(map[string]interface{}, len(attributes))
+	}
+	maps.Copy(merged, attributes)
+	return context.WithValue(ctx, requestAttributesKey{}, merged)
+}
+
+// RequestAttributesFromContext returns the request-scoped evaluation attributes registered in ctx,
+// nil if there is none.
+func RequestAttributesFromContext(ctx context.Context) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+	attributes, _ := ctx.Value(requestAttributesKey{}).(map[string]interface{})
+	return attributes
+}
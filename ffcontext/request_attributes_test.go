@@ -0,0 +1,20 @@
+package ffcontext_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+)
+
+func TestWithRequestAttributes(t *testing.T) {
+	assert.Nil(t, ffcontext.RequestAttributesFromContext(context.Background()))
+
+	ctx := ffcontext.WithRequestAttributes(context.Background(), map[string]interface{}{"tenant": "acme", "region": "eu"})
+	child := ffcontext.WithRequestAttributes(ctx, map[string]interface{}{"region": "us"})
+
+	assert.Equal(t, map[string]interface{}{"tenant": "acme", "region": "eu"}, ffcontext.RequestAttributesFromContext(ctx))
+	assert.Equal(t, map[string]interface{}{"tenant": "acme", "region": "us"},
+		ffcontext.RequestAttributesFromContext(child))
+}
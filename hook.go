@@ -0,0 +1,162 @@
+package ffclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/model"
+)
+
+// HookContext contains the information about the evaluation given to the hooks.
+type HookContext struct {
+	// FlagKey is the key of the evaluated flag.
+	FlagKey string
+	// EvaluationContext is the evaluation context used for the evaluation,
+	// it contains the changes made by the previous Before hooks.
+	EvaluationContext ffcontext.Context
+	// DefaultValue is the default value of the evaluation.
+	DefaultValue interface{}
+}
+
+// Hook is called around each evaluation of a flag, it allows to add logging, metrics, checks of the
+// evaluation context, etc. without wrapping every call of the variation functions.
+//
+// The Before hooks are called in the order of Config.Hooks, the other stages are called in the reverse order.
+// Embed UnimplementedHook in your hook to implement only the stages you need.
+type Hook interface {
+	// Before is called before the evaluation, it can return a new evaluation context used for the evaluation
+	// (nil keeps the current evaluation context).
+	// If it returns an error, the flag is not evaluated and the default value is returned.
+	Before(hookCtx HookContext) (ffcontext.Context, error)
+
+	// After is called after a successful evaluation with the result of the evaluation.
+	// If it returns an error, the default value is returned.
+	After(hookCtx HookContext, result model.VariationResult[interface{}]) error
+
+	// Error is called if the evaluation returned an error or a result with an error code,
+	// or if one of the hooks returned an error.
+	Error(hookCtx HookContext, err error)
+
+	// Finally is called at the end of every evaluation with the result returned to the caller.
+	Finally(hookCtx HookContext, result model.VariationResult[interface{}])
+}
+
+// UnimplementedHook implements all the stages of a Hook without doing anything.
+type UnimplementedHook struct{}
+
+// Before is not doing anything.
+func (UnimplementedHook) Before(_ HookContext) (ffcontext.Context, error) {
+	return nil, nil
+}
+
+// After is not doing anything.
+func (UnimplementedHook) After(_ HookContext, _ model.VariationResult[interface{}]) error {
+	return nil
+}
+
+// Error is not doing anything.
+func (UnimplementedHook) Error(_ HookContext, _ error) {}
+
+// Finally is not doing anything.
+func (UnimplementedHook) Finally(_ HookContext, _ model.VariationResult[interface{}]) {}
+
+// getVariationWithHooks evaluates the flag at the current date and calls the hooks of the configuration
+// around the evaluation.
+// It returns the evaluation context used for the evaluation, which contains the changes made by the Before hooks.
+func getVariationWithHooks[T model.JSONType](
+	ctx context.Context, g *GoFeatureFlag, flagKey string, evaluationCtx ffcontext.Context, sdkDefaultValue T,
+	expectedType string, requestAttributes map[string]interface{},
+) (model.VariationResult[T], ffcontext.Context, error) {
+	if g == nil || len(g.config.Hooks) == 0 {
+		res, err := getVariationAt(
+			ctx, g, flagKey, evaluationCtx, sdkDefaultValue, expectedType, time.Time{}, requestAttributes)
+		return res, evaluationCtx, err
+	}
+
+	hooks := g.config.Hooks
+	hookCtx := HookContext{FlagKey: flagKey, EvaluationContext: evaluationCtx, DefaultValue: sdkDefaultValue}
+	var res model.VariationResult[T]
+	var err error
+	for _, hook := range hooks {
+		newEvaluationCtx, hookErr := hook.Before(hookCtx)
+		if hookErr != nil {
+			res, err = hookErrorResult(sdkDefaultValue), fmt.Errorf("before hook of the flag %v: %w", flagKey, hookErr)
+			break
+		}
+		if newEvaluationCtx != nil {
+			hookCtx.EvaluationContext = newEvaluationCtx
+		}
+	}
+
+	// evaluationErr is the error given to the Error hooks, the evaluation can return a result with an error code
+	// (ex: a flag configuration error) without returning an error.
+	evaluationErr := err
+	if err == nil {
+		res, err = getVariationAt(ctx, g, flagKey, hookCtx.EvaluationContext, sdkDefaultValue, expectedType,
+			time.Time{}, requestAttributes)
+		evaluationErr = err
+		if err == nil && res.ErrorCode != "" {
+			evaluationErr = resultError(flagKey, toInterfaceVariationResult(res))
+		}
+		for i := len(hooks) - 1; i >= 0 && evaluationErr == nil; i-- {
+			if hookErr := hooks[i].After(hookCtx, toInterfaceVariationResult(res)); hookErr != nil {
+				res, err = hookErrorResult(sdkDefaultValue), fmt.Errorf("after hook of the flag %v: %w", flagKey, hookErr)
+				evaluationErr = err
+			}
+		}
+	}
+
+	if evaluationErr != nil {
+		for i := len(hooks) - 1; i >= 0; i-- {
+			hooks[i].Error(hookCtx, evaluationErr)
+		}
+	}
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i].Finally(hookCtx, toInterfaceVariationResult(res))
+	}
+	return res, hookCtx.EvaluationContext, err
+}
+
+// resultError is the error given to the Error hooks for a result with an error code.
+func resultError(flagKey string, res model.VariationResult[interface{}]) error {
+	if res.ErrorDetails != "" {
+		return fmt.Errorf("evaluation of the flag %v: %s: %s", flagKey, res.ErrorCode, res.ErrorDetails)
+	}
+	return fmt.Errorf("evaluation of the flag %v: %s", flagKey, res.ErrorCode)
+}
+
+// hookErrorResult is the result of an evaluation stopped by a hook.
+func hookErrorResult[T model.JSONType](sdkDefaultValue T) model.VariationResult[T] {
+	return model.VariationResult[T]{
+		Value:         sdkDefaultValue,
+		VariationType: flag.VariationSDKDefault,
+		Failed:        true,
+		Reason:        flag.ReasonError,
+		ErrorCode:     flag.ErrorCodeGeneral,
+		Cacheable:     false,
+	}
+}
+
+// toInterfaceVariationResult converts a typed result to the result given to the hooks.
+func toInterfaceVariationResult[T model.JSONType](res model.VariationResult[T]) model.VariationResult[interface{}] {
+	return model.VariationResult[interface{}]{
+		TrackEvents:    res.TrackEvents,
+		VariationType:  res.VariationType,
+		Failed:         res.Failed,
+		Version:        res.Version,
+		Reason:         res.Reason,
+		ErrorCode:      res.ErrorCode,
+		ErrorDetails:   res.ErrorDetails,
+		Value:          res.Value,
+		Cacheable:      res.Cacheable,
+		Metadata:       res.Metadata,
+		BucketingKey:   res.BucketingKey,
+		BucketingValue: res.BucketingValue,
+		Layer:          res.Layer,
+		LayerSlice:     res.LayerSlice,
+		ValueType:      res.ValueType,
+	}
+}
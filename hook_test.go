@@ -0,0 +1,183 @@
+package ffclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/exporter"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/model"
+	"github.com/thomaspoignant/go-feature-flag/testutils/mock"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+// recordingHook records the stages called and can fail a stage.
+type recordingHook struct {
+	UnimplementedHook
+	name      string
+	calls     *[]string
+	beforeCtx ffcontext.Context
+	beforeErr error
+	afterErr  error
+	results   []model.VariationResult[interface{}]
+}
+
+func (h *recordingHook) Before(_ HookContext) (ffcontext.Context, error) {
+	*h.calls = append(*h.calls, h.name+".before")
+	return h.beforeCtx, h.beforeErr
+}
+
+func (h *recordingHook) After(_ HookContext, result model.VariationResult[interface{}]) error {
+	*h.calls = append(*h.calls, h.name+".after")
+	h.results = append(h.results, result)
+	return h.afterErr
+}
+
+func (h *recordingHook) Error(_ HookContext, _ error) {
+	*h.calls = append(*h.calls, h.name+".error")
+}
+
+func (h *recordingHook) Finally(_ HookContext, _ model.VariationResult[interface{}]) {
+	*h.calls = append(*h.calls, h.name+".finally")
+}
+
+func TestHooks(t *testing.T) {
+	acmeCtx := ffcontext.NewEvaluationContextBuilder("user-key").AddCustom("tenant", "acme").Build()
+	allStages := []string{
+		"first.before", "second.before", "second.after", "first.after", "second.finally", "first.finally",
+	}
+	flagBucketingByTeam := newPlanFlag()
+	flagBucketingByTeam.BucketingKey = testconvert.String("team")
+	flagBucketingByTeam.DefaultRule = &flag.Rule{Percentages: &map[string]float64{"enabled": 50, "disabled": 50}}
+	tests := []struct {
+		name          string
+		first         recordingHook
+		second        recordingHook
+		flag          *flag.InternalFlag
+		cacheErr      error
+		want          bool
+		wantErrorCode flag.ErrorCode
+		wantErr       string
+		wantCalls     []string
+	}{
+		{
+			name:      "all the stages are called in order",
+			want:      false,
+			wantCalls: allStages,
+		},
+		{
+			name:      "before hook changing the evaluation context",
+			second:    recordingHook{beforeCtx: acmeCtx},
+			want:      true,
+			wantCalls: allStages,
+		},
+		{
+			name:          "before hook failing",
+			first:         recordingHook{beforeErr: errors.New("missing tenant")},
+			want:          false,
+			wantErrorCode: flag.ErrorCodeGeneral,
+			wantErr:       "before hook of the flag my-flag: missing tenant",
+			wantCalls:     []string{"first.before", "second.error", "first.error", "second.finally", "first.finally"},
+		},
+		{
+			name:          "after hook failing",
+			second:        recordingHook{beforeCtx: acmeCtx, afterErr: errors.New("invalid value")},
+			want:          false,
+			wantErrorCode: flag.ErrorCodeGeneral,
+			wantErr:       "after hook of the flag my-flag: invalid value",
+			wantCalls: []string{
+				"first.before", "second.before", "second.after", "second.error", "first.error", "second.finally",
+				"first.finally",
+			},
+		},
+		{
+			name:          "evaluation failing",
+			cacheErr:      errors.New("flag not found"),
+			want:          false,
+			wantErrorCode: flag.ErrorCodeFlagNotFound,
+			wantErr:       "flag my-flag is not present or disabled",
+			wantCalls: []string{
+				"first.before", "second.before", "second.error", "first.error", "second.finally", "first.finally",
+			},
+		},
+		{
+			name:          "evaluation returning an error code",
+			flag:          flagBucketingByTeam,
+			want:          false,
+			wantErrorCode: flag.ErrorBucketingKeyMissing,
+			wantCalls: []string{
+				"first.before", "second.before", "second.error", "first.error", "second.finally", "first.finally",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := []string{}
+			first, second := tt.first, tt.second
+			first.name, first.calls = "first", &calls
+			second.name, second.calls = "second", &calls
+			f := tt.flag
+			if f == nil {
+				f = newPlanFlag()
+			}
+			goff := &GoFeatureFlag{
+				cache:  NewCacheMock(f, tt.cacheErr),
+				config: Config{Hooks: []Hook{&first, &second}},
+			}
+
+			got, err := goff.BoolVariationDetails("my-flag", ffcontext.NewEvaluationContext("user-key"), false)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got.Value)
+			assert.Equal(t, tt.wantErrorCode, got.ErrorCode)
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
+
+func TestHooks_AfterReceivesTheResult(t *testing.T) {
+	calls := []string{}
+	hook := &recordingHook{name: "hook", calls: &calls}
+	goff := &GoFeatureFlag{
+		cache:  NewCacheMock(newPlanFlag(), nil),
+		config: Config{Hooks: []Hook{hook}},
+	}
+
+	_, err := goff.StringVariation("my-flag", ffcontext.NewEvaluationContext("user-key"), "default")
+	assert.Error(t, err, "the flag is a boolean flag")
+	assert.Empty(t, hook.results)
+
+	_, err = goff.BoolVariation("my-flag", ffcontext.NewEvaluationContext("user-key"), true)
+	assert.NoError(t, err)
+	assert.Len(t, hook.results, 1)
+	assert.Equal(t, false, hook.results[0].Value)
+	assert.Equal(t, "disabled", hook.results[0].VariationType)
+}
+
+func TestHooks_ExportedEventUsesTheHookContext(t *testing.T) {
+	calls := []string{}
+	hook := &recordingHook{
+		name:      "hook",
+		calls:     &calls,
+		beforeCtx: ffcontext.NewEvaluationContextBuilder("hook-key").AddCustom("tenant", "acme").Build(),
+	}
+	exp := &mock.Exporter{}
+	goff := &GoFeatureFlag{
+		cache:        NewCacheMock(newPlanFlag(), nil),
+		config:       Config{Hooks: []Hook{hook}},
+		dataExporter: exporter.NewScheduler(context.Background(), 0, 0, exp, nil),
+	}
+
+	got, err := goff.BoolVariation("my-flag", ffcontext.NewEvaluationContext("user-key"), false)
+	assert.NoError(t, err)
+	assert.True(t, got)
+	assert.Eventually(t, func() bool { return len(exp.GetExportedEvents()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "hook-key", exp.GetExportedEvents()[0].UserKey)
+}
@@ -19,6 +19,6 @@ type Cache interface {
 	// All return the complete list of the flags.
 	All() map[string]flag.Flag
 
-	// Init allow to initialize the cache with a collection of flags.
-	Init(flags map[string]dto.DTO)
+	// Init allow to initialize the cache with a collection of flags and the segments they can reference.
+	Init(config dto.Configuration)
 }
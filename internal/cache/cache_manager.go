@@ -1,28 +1,26 @@
 package cache
 
 import (
-	"encoding/json"
 	"errors"
 	"log"
-	"strings"
 	"sync"
 	"time"
 
-	"github.com/BurntSushi/toml"
-
+	"github.com/thomaspoignant/go-feature-flag/internal/contextschema"
 	"github.com/thomaspoignant/go-feature-flag/internal/dto"
 
 	"github.com/thomaspoignant/go-feature-flag/internal/flag"
-	"gopkg.in/yaml.v3"
+	"github.com/thomaspoignant/go-feature-flag/utils/fflog"
 )
 
 type Manager interface {
-	ConvertToFlagStruct(loadedFlags []byte, fileFormat string) (map[string]dto.DTO, error)
-	UpdateCache(newFlags map[string]dto.DTO, log *log.Logger) error
+	ConvertToFlagStruct(loadedFlags []byte, fileFormat string) (dto.Configuration, error)
+	UpdateCache(newConfig dto.Configuration, log *log.Logger) error
 	Close()
 	GetFlag(key string) (flag.Flag, error)
 	AllFlags() (map[string]flag.Flag, error)
 	GetLatestUpdateDate() time.Time
+	GetContextSchema() *contextschema.Schema
 }
 
 type cacheManagerImpl struct {
@@ -31,52 +29,76 @@ type cacheManagerImpl struct {
 	notificationService Service
 	latestUpdate        time.Time
 	logger              *log.Logger
+	environment         string
+	contextSchema       *contextschema.Schema
 }
 
-func New(notificationService Service, logger *log.Logger) Manager {
+// New creates a cache manager, the flags are resolved for the environment when they are loaded.
+func New(notificationService Service, environment string, logger *log.Logger) Manager {
 	return &cacheManagerImpl{
 		logger:              logger,
+		environment:         environment,
 		inMemoryCache:       NewInMemoryCache(logger),
 		mutex:               sync.RWMutex{},
 		notificationService: notificationService,
 	}
 }
 
-func (c *cacheManagerImpl) ConvertToFlagStruct(loadedFlags []byte, fileFormat string) (map[string]dto.DTO, error) {
-	var newFlags map[string]dto.DTO
-	var err error
-	switch strings.ToLower(fileFormat) {
-	case "toml":
-		err = toml.Unmarshal(loadedFlags, &newFlags)
-	case "json":
-		err = json.Unmarshal(loadedFlags, &newFlags)
-	default:
-		// default unmarshaller is YAML
-		err = yaml.Unmarshal(loadedFlags, &newFlags)
-	}
-	return newFlags, err
+func (c *cacheManagerImpl) ConvertToFlagStruct(loadedFlags []byte, fileFormat string) (dto.Configuration, error) {
+	return dto.Unmarshal(loadedFlags, fileFormat)
 }
 
-func (c *cacheManagerImpl) UpdateCache(newFlags map[string]dto.DTO, log *log.Logger) error {
+func (c *cacheManagerImpl) UpdateCache(newConfig dto.Configuration, log *log.Logger) error {
+	for _, err := range newConfig.SkippedEntries {
+		fflog.Printf(c.logger, "error: [cache] %s", err)
+	}
 	newCache := NewInMemoryCache(c.logger)
-	newCache.Init(newFlags)
+	newCache.Environment = c.environment
+	newCache.Init(newConfig)
+	for _, key := range findPrerequisiteCycles(newCache.Flags) {
+		fflog.Printf(c.logger, "error: [cache] invalid configuration for flag %s: cycle detected in the prerequisites", key)
+		delete(newCache.Flags, key)
+	}
 	newCacheFlags := newCache.All()
 	oldCacheFlags := map[string]flag.Flag{}
 
+	contextSchema := newConfig.ContextSchema
+	if contextSchema != nil {
+		if err := contextSchema.IsValid(); err != nil {
+			fflog.Printf(c.logger, "error: [cache] invalid context schema, the contexts are not validated: %s", err)
+			contextSchema = nil
+		}
+	}
+
 	c.mutex.Lock()
 	// collect flags for compare.
 	if c.inMemoryCache != nil {
 		oldCacheFlags = c.inMemoryCache.All()
 	}
+	previousUpdate := c.latestUpdate
 	c.inMemoryCache = newCache
+	c.contextSchema = contextSchema
 	c.latestUpdate = time.Now()
 	c.mutex.Unlock()
 
-	// notify the changes
-	c.notificationService.Notify(oldCacheFlags, newCacheFlags, log)
+	// notify the changes, including the ones of the scheduled steps that took effect since the previous update.
+	c.notificationService.Notify(flagsAt(oldCacheFlags, previousUpdate), flagsAt(newCacheFlags, c.latestUpdate), log)
 	return nil
 }
 
+// flagsAt returns the flags as they are at the date, with the scheduled steps planned before this date applied.
+func flagsAt(flags map[string]flag.Flag, date time.Time) map[string]flag.Flag {
+	flagsAtDate := make(map[string]flag.Flag, len(flags))
+	for key, f := range flags {
+		if internalFlag, ok := f.(*flag.InternalFlag); ok {
+			flagsAtDate[key] = internalFlag.GetFlagAt(date)
+			continue
+		}
+		flagsAtDate[key] = f
+	}
+	return flagsAtDate
+}
+
 func (c *cacheManagerImpl) Close() {
 	// Clear the cache
 	c.mutex.Lock()
@@ -110,3 +132,10 @@ func (c *cacheManagerImpl) GetLatestUpdateDate() time.Time {
 	defer c.mutex.RUnlock()
 	return c.latestUpdate
 }
+
+// GetContextSchema returns the context schema of the configuration, nil if there is no valid context schema.
+func (c *cacheManagerImpl) GetContextSchema() *contextschema.Schema {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.contextSchema
+}
@@ -1,10 +1,15 @@
 package cache_test
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/thomaspoignant/go-feature-flag/internal/flag"
 
 	"github.com/stretchr/testify/assert"
@@ -13,15 +18,18 @@ import (
 	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
 )
 
+// ignoreCompiledState ignores the queries and the templates compiled when loading the flags in the cache.
+var ignoreCompiledState = cmpopts.IgnoreUnexported(flag.InternalFlag{})
+
 func Test_FlagCacheNotInit(t *testing.T) {
-	fCache := cache.New(nil, nil)
+	fCache := cache.New(nil, "", nil)
 	fCache.Close()
 	_, err := fCache.GetFlag("test-flag")
 	assert.Error(t, err, "We should have an error if the cache is not init")
 }
 
 func Test_GetFlagNotExist(t *testing.T) {
-	fCache := cache.New(nil, nil)
+	fCache := cache.New(nil, "", nil)
 	_, err := fCache.GetFlag("not-exists-flag")
 	assert.Error(t, err, "We should have an error if the flag does not exists")
 }
@@ -120,6 +128,137 @@ trackEvents = false
 			},
 			wantErr: false,
 		},
+		{
+			name:       "Yaml with segments",
+			flagFormat: "yaml",
+			args: args{
+				loadedFlags: []byte(`
+segments:
+  beta-testers:
+    description: users enrolled in the beta program
+    query: beta eq true
+  unused:
+    query: key eq "random-key"
+test-flag:
+  variations:
+    true_var: true
+    false_var: false
+  targeting:
+    - segment: beta-testers
+      variation: true_var
+  defaultRule:
+    variation: false_var
+`),
+			},
+			expected: map[string]flag.InternalFlag{
+				"test-flag": {
+					Rules: &[]flag.Rule{
+						{
+							Segment:         testconvert.String("beta-testers"),
+							VariationResult: testconvert.String("true_var"),
+						},
+					},
+					Variations: &map[string]*interface{}{
+						"false_var": testconvert.Interface(false),
+						"true_var":  testconvert.Interface(true),
+					},
+					DefaultRule: &flag.Rule{
+						VariationResult: testconvert.String("false_var"),
+					},
+					Segments: &map[string]flag.Segment{
+						"beta-testers": {
+							Description: testconvert.String("users enrolled in the beta program"),
+							Query:       testconvert.String("beta eq true"),
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:       "Yaml with layers and holdout",
+			flagFormat: "yaml",
+			args: args{
+				loadedFlags: []byte(`
+holdout:
+  percentage: 5
+layers:
+  checkout:
+    slices:
+      - flag: test-flag
+        percentage: 40
+      - flag: other-flag
+        percentage: 40
+test-flag:
+  variations:
+    true_var: true
+    false_var: false
+  defaultRule:
+    variation: false_var
+`),
+			},
+			expected: map[string]flag.InternalFlag{
+				"test-flag": {
+					Variations: &map[string]*interface{}{
+						"false_var": testconvert.Interface(false),
+						"true_var":  testconvert.Interface(true),
+					},
+					DefaultRule: &flag.Rule{
+						VariationResult: testconvert.String("false_var"),
+					},
+					Layer: &flag.LayerAllocation{
+						Name: "checkout",
+						Layer: flag.Layer{
+							Slices: &[]flag.LayerSlice{
+								{Flag: testconvert.String("test-flag"), Percentage: testconvert.Float64(40)},
+								{Flag: testconvert.String("other-flag"), Percentage: testconvert.Float64(40)},
+							},
+						},
+						Holdout: &flag.Holdout{Percentage: testconvert.Float64(5)},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:       "Yaml with targets",
+			flagFormat: "yaml",
+			args: args{
+				loadedFlags: []byte(`
+test-flag:
+  variations:
+    true_var: true
+    false_var: false
+  targets:
+    - name: beta-testers
+      variation: true_var
+      values:
+        - user-1
+        - user-2
+  defaultRule:
+    variation: false_var
+`),
+			},
+			expected: map[string]flag.InternalFlag{
+				"test-flag": {
+					Variations: &map[string]*interface{}{
+						"false_var": testconvert.Interface(false),
+						"true_var":  testconvert.Interface(true),
+					},
+					Targets: &[]flag.Target{
+						{
+							Name:      testconvert.String("beta-testers"),
+							Variation: testconvert.String("true_var"),
+							Values:    &[]string{"user-1", "user-2"},
+						},
+					},
+					DefaultRule: &flag.Rule{
+						VariationResult: testconvert.String("false_var"),
+					},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name:       "Yaml invalid file",
 			flagFormat: "yaml",
@@ -244,7 +383,7 @@ variation = "false_var"
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			fCache := cache.New(cache.NewNotificationService([]notifier.Notifier{}), nil)
+			fCache := cache.New(cache.NewNotificationService([]notifier.Notifier{}), "", nil)
 			newFlags, err := fCache.ConvertToFlagStruct(tt.args.loadedFlags, tt.flagFormat)
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -259,7 +398,7 @@ variation = "false_var"
 			// If no error we compare with expected
 			for key, expected := range tt.expected {
 				got, _ := fCache.GetFlag(key)
-				assert.Equal(t, &expected, got) // nolint
+				assert.Empty(t, cmp.Diff(&expected, got, ignoreCompiledState)) // nolint
 			}
 			fCache.Close()
 		})
@@ -407,7 +546,7 @@ test-flag2:
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			fCache := cache.New(cache.NewNotificationService([]notifier.Notifier{}), nil)
+			fCache := cache.New(cache.NewNotificationService([]notifier.Notifier{}), "", nil)
 			newFlags, err := fCache.ConvertToFlagStruct(tt.args.loadedFlags, tt.flagFormat)
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -426,7 +565,7 @@ test-flag2:
 			// If no error we compare with expected
 			for key, expected := range tt.expected {
 				got := allFlags[key]
-				assert.Equal(t, &expected, got) //nolint: gosec
+				assert.Empty(t, cmp.Diff(&expected, got, ignoreCompiledState)) //nolint: gosec
 			}
 			fCache.Close()
 		})
@@ -448,7 +587,7 @@ func Test_cacheManagerImpl_GetLatestUpdateDate(t *testing.T) {
   trackEvents: false
 `)
 
-	fCache := cache.New(cache.NewNotificationService([]notifier.Notifier{}), nil)
+	fCache := cache.New(cache.NewNotificationService([]notifier.Notifier{}), "", nil)
 	timeBefore := fCache.GetLatestUpdateDate()
 	newFlags, _ := fCache.ConvertToFlagStruct(loadedFlags, "yaml")
 	_ = fCache.UpdateCache(newFlags, log.New(os.Stdout, "", 0))
@@ -456,3 +595,274 @@ func Test_cacheManagerImpl_GetLatestUpdateDate(t *testing.T) {
 
 	assert.True(t, timeBefore.Before(timeAfter))
 }
+
+// diffNotifier keeps the differences it is notified of.
+type diffNotifier struct {
+	mutex sync.Mutex
+	diffs []notifier.DiffCache
+}
+
+func (n *diffNotifier) Notify(diff notifier.DiffCache) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.diffs = append(n.diffs, diff)
+	return nil
+}
+
+func Test_UpdateCacheNotifiesScheduledSteps(t *testing.T) {
+	stepDate := time.Now().Add(100 * time.Millisecond)
+	loadedFlags := []byte(fmt.Sprintf(`
+test-flag:
+  variations:
+    on: true
+    off: false
+  defaultRule:
+    variation: off
+  scheduledRollout:
+    - date: %s
+      defaultRule:
+        variation: on
+`, stepDate.Format(time.RFC3339Nano)))
+
+	n := &diffNotifier{}
+	fCache := cache.New(cache.NewNotificationService([]notifier.Notifier{n}), "", nil)
+	newConfig, err := fCache.ConvertToFlagStruct(loadedFlags, "yaml")
+	assert.NoError(t, err)
+	assert.NoError(t, fCache.UpdateCache(newConfig, log.New(os.Stdout, "", 0)))
+
+	// the same configuration is loaded after the date of the step
+	time.Sleep(time.Until(stepDate.Add(10 * time.Millisecond)))
+	newConfig, err = fCache.ConvertToFlagStruct(loadedFlags, "yaml")
+	assert.NoError(t, err)
+	assert.NoError(t, fCache.UpdateCache(newConfig, log.New(os.Stdout, "", 0)))
+
+	// the flag in the cache keeps its scheduled rollout
+	f, err := fCache.GetFlag("test-flag")
+	assert.NoError(t, err)
+	assert.Equal(t, "off", f.(*flag.InternalFlag).GetDefaultRule().GetVariationResult())
+	fCache.Close()
+
+	assert.Len(t, n.diffs, 2)
+	assert.Contains(t, n.diffs[0].Added, "test-flag")
+	assert.Contains(t, n.diffs[1].Updated, "test-flag")
+	updated := n.diffs[1].Updated["test-flag"]
+	assert.Equal(t, "off", updated.Before.(*flag.InternalFlag).GetDefaultRule().GetVariationResult())
+	assert.Equal(t, "on", updated.After.(*flag.InternalFlag).GetDefaultRule().GetVariationResult())
+}
+
+func Test_UpdateCacheWithPrerequisiteCycle(t *testing.T) {
+	loadedFlags := []byte(`
+flag-a:
+  variations:
+    on: true
+    off: false
+  prerequisites:
+    - key: flag-b
+      variation: on
+  defaultRule:
+    variation: on
+flag-b:
+  variations:
+    on: true
+    off: false
+  prerequisites:
+    - key: flag-a
+      variation: on
+  defaultRule:
+    variation: on
+flag-c:
+  variations:
+    on: true
+    off: false
+  prerequisites:
+    - key: flag-a
+      variation: on
+  defaultRule:
+    variation: on
+flag-d:
+  variations:
+    on: true
+    off: false
+  defaultRule:
+    variation: on
+  scheduledRollout:
+    - date: 2024-03-01T10:00:00Z
+      prerequisites:
+        - key: flag-e
+          variation: on
+flag-e:
+  variations:
+    on: true
+    off: false
+  prerequisites:
+    - key: flag-d
+      variation: on
+  defaultRule:
+    variation: on
+`)
+
+	fCache := cache.New(cache.NewNotificationService([]notifier.Notifier{}), "", nil)
+	newConfig, err := fCache.ConvertToFlagStruct(loadedFlags, "yaml")
+	assert.NoError(t, err)
+	err = fCache.UpdateCache(newConfig, log.New(os.Stdout, "", 0))
+	assert.NoError(t, err)
+
+	allFlags, err := fCache.AllFlags()
+	assert.NoError(t, err)
+	assert.NotContains(t, allFlags, "flag-a")
+	assert.NotContains(t, allFlags, "flag-b")
+	assert.Contains(t, allFlags, "flag-c")
+	// the prerequisites of the scheduled steps are part of the cycles
+	assert.NotContains(t, allFlags, "flag-d")
+	assert.NotContains(t, allFlags, "flag-e")
+	fCache.Close()
+}
+
+func Test_UpdateCacheWithEnvironments(t *testing.T) {
+	loadedFlags := []byte(`
+new-checkout:
+  variations:
+    enabled: true
+    disabled: false
+  targeting:
+    - query: beta eq true
+      variation: enabled
+  defaultRule:
+    variation: disabled
+  environments:
+    staging:
+      defaultRule:
+        variation: enabled
+    production:
+      variations:
+        enabled: "true"
+`)
+
+	tests := []struct {
+		name        string
+		environment string
+		want        *flag.InternalFlag
+	}{
+		{
+			name:        "environment with an override",
+			environment: "staging",
+			want: &flag.InternalFlag{
+				Variations: &map[string]*interface{}{
+					"enabled":  testconvert.Interface(true),
+					"disabled": testconvert.Interface(false),
+				},
+				Rules: &[]flag.Rule{
+					{
+						Query:           testconvert.String("beta eq true"),
+						VariationResult: testconvert.String("enabled"),
+					},
+				},
+				DefaultRule: &flag.Rule{
+					VariationResult: testconvert.String("enabled"),
+				},
+			},
+		},
+		{
+			name:        "environment without override",
+			environment: "development",
+			want: &flag.InternalFlag{
+				Variations: &map[string]*interface{}{
+					"enabled":  testconvert.Interface(true),
+					"disabled": testconvert.Interface(false),
+				},
+				Rules: &[]flag.Rule{
+					{
+						Query:           testconvert.String("beta eq true"),
+						VariationResult: testconvert.String("enabled"),
+					},
+				},
+				DefaultRule: &flag.Rule{
+					VariationResult: testconvert.String("disabled"),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fCache := cache.New(cache.NewNotificationService([]notifier.Notifier{}), tt.environment, nil)
+			newConfig, err := fCache.ConvertToFlagStruct(loadedFlags, "yaml")
+			assert.NoError(t, err)
+			assert.NoError(t, fCache.UpdateCache(newConfig, log.New(os.Stdout, "", 0)))
+
+			got, err := fCache.GetFlag("new-checkout")
+			assert.NoError(t, err)
+			assert.Empty(t, cmp.Diff(tt.want, got, ignoreCompiledState))
+			fCache.Close()
+		})
+	}
+
+	t.Run("invalid override of the environment", func(t *testing.T) {
+		fCache := cache.New(cache.NewNotificationService([]notifier.Notifier{}), "production", nil)
+		newConfig, err := fCache.ConvertToFlagStruct(loadedFlags, "yaml")
+		assert.NoError(t, err)
+		assert.NoError(t, fCache.UpdateCache(newConfig, log.New(os.Stdout, "", 0)))
+		_, err = fCache.GetFlag("new-checkout")
+		assert.Error(t, err, "the variations of production do not have the same type")
+	})
+}
+
+func Test_UpdateCacheWithContextSchema(t *testing.T) {
+	tests := []struct {
+		name        string
+		loadedFlags []byte
+		wantSchema  bool
+	}{
+		{
+			name: "valid context schema",
+			loadedFlags: []byte(`
+contextSchema:
+  mode: strict
+  attributes:
+    plan:
+      type: string
+      required: true
+      values: [free, pro]
+test-flag:
+  variations:
+    enabled: true
+    disabled: false
+  defaultRule:
+    variation: disabled
+`),
+			wantSchema: true,
+		},
+		{
+			name: "invalid context schema",
+			loadedFlags: []byte(`
+contextSchema:
+  mode: fast
+test-flag:
+  variations:
+    enabled: true
+    disabled: false
+  defaultRule:
+    variation: disabled
+`),
+			wantSchema: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fCache := cache.New(cache.NewNotificationService([]notifier.Notifier{}), "", nil)
+			newConfig, err := fCache.ConvertToFlagStruct(tt.loadedFlags, "yaml")
+			assert.NoError(t, err)
+			assert.NoError(t, fCache.UpdateCache(newConfig, log.New(os.Stdout, "", 0)))
+
+			allFlags, err := fCache.AllFlags()
+			assert.NoError(t, err)
+			assert.Len(t, allFlags, 1, "the context schema should not be loaded as a flag")
+			if tt.wantSchema {
+				assert.NotNil(t, fCache.GetContextSchema())
+				assert.True(t, fCache.GetContextSchema().IsStrict())
+			} else {
+				assert.Nil(t, fCache.GetContextSchema())
+			}
+			fCache.Close()
+		})
+	}
+}
@@ -13,6 +13,9 @@ import (
 type InMemoryCache struct {
 	Flags  map[string]flag.InternalFlag
 	Logger *log.Logger
+
+	// Environment (optional) is the environment used to resolve the environment overrides of the flags.
+	Environment string
 }
 
 func NewInMemoryCache(logger *log.Logger) *InMemoryCache {
@@ -48,6 +51,7 @@ func (fc *InMemoryCache) keys() []string {
 
 func (fc *InMemoryCache) Copy() Cache {
 	inMemoryCache := NewInMemoryCache(fc.Logger)
+	inMemoryCache.Environment = fc.Environment
 	for k, v := range fc.Flags {
 		inMemoryCache.addFlag(k, v)
 	}
@@ -63,12 +67,22 @@ func (fc *InMemoryCache) All() map[string]flag.Flag {
 	return c
 }
 
-func (fc *InMemoryCache) Init(flags map[string]dto.DTO) {
+func (fc *InMemoryCache) Init(config dto.Configuration) {
 	cache := make(map[string]flag.InternalFlag, 0)
-	for key, flagDto := range flags {
+	for key, flagDto := range config.Flags {
 		flagToAdd := flagDto.Convert()
+		flagToAdd.ApplyEnvironment(fc.Environment)
+		flagToAdd.LinkSegments(config.Segments)
+		flagToAdd.CompileRules()
+		if err := flagToAdd.LinkLayer(key, config.Layers, config.Holdout); err != nil {
+			fflog.Printf(fc.Logger, "error: [cache] invalid configuration for flag %s: %s", key, err)
+			continue
+		}
 		if err := flagToAdd.IsValid(); err == nil {
-			cache[key] = flagDto.Convert()
+			flagToAdd.IndexTargets()
+			flagToAdd.ParseVariationTemplates()
+			flagToAdd.ResolveScheduledSteps()
+			cache[key] = flagToAdd
 		} else {
 			fflog.Printf(fc.Logger, "error: [cache] invalid configuration for flag %s: %s", key, err)
 		}
@@ -105,7 +105,7 @@ func TestAll(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := cache.NewInMemoryCache(nil)
-			c.Init(tt.param)
+			c.Init(dto.Configuration{Flags: tt.param})
 			assert.Equal(t, tt.want, c.All())
 		})
 	}
@@ -133,7 +133,7 @@ func TestCopy(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := cache.NewInMemoryCache(nil)
-			c.Init(tt.param)
+			c.Init(dto.Configuration{Flags: tt.param})
 			got := c.Copy()
 			assert.Equal(t, c, got)
 		})
@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/thomaspoignant/go-feature-flag/internal/flag"
 	"github.com/thomaspoignant/go-feature-flag/notifier"
 )
@@ -65,7 +66,8 @@ func (c *notificationService) getDifferences(
 			continue
 		}
 
-		if !cmp.Equal(oldCache[key], newCache[key]) {
+		// the compiled queries and templates are derived from the other fields, they are not compared.
+		if !cmp.Equal(oldCache[key], newCache[key], cmpopts.IgnoreUnexported(flag.InternalFlag{})) {
 			diff.Updated[key] = notifier.DiffUpdated{
 				Before: oldFlag,
 				After:  newFlag,
@@ -238,6 +238,18 @@ func Test_notificationService_getDifferences(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Same flag with indexed targets",
+			args: args{
+				oldCache: map[string]flag.Flag{"test-flag": newTargetedFlag(false)},
+				newCache: map[string]flag.Flag{"test-flag": newTargetedFlag(true)},
+			},
+			want: notifier.DiffCache{
+				Deleted: map[string]flag.Flag{},
+				Added:   map[string]flag.Flag{},
+				Updated: map[string]notifier.DiffUpdated{},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -250,3 +262,21 @@ func Test_notificationService_getDifferences(t *testing.T) {
 		})
 	}
 }
+
+// newTargetedFlag creates a flag with a target, indexed like when loading the flag in the cache if indexed is true.
+func newTargetedFlag(indexed bool) *flag.InternalFlag {
+	f := &flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"False": testconvert.Interface(false),
+			"True":  testconvert.Interface(true),
+		},
+		Targets: &[]flag.Target{
+			{Variation: testconvert.String("True"), Values: &[]string{"user-1", "user-2"}},
+		},
+		DefaultRule: &flag.Rule{VariationResult: testconvert.String("False")},
+	}
+	if indexed {
+		f.IndexTargets()
+	}
+	return f
+}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"sort"
+
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+)
+
+// findPrerequisiteCycles returns the keys of all the flags that are part of a cycle in their prerequisites
+// (including the prerequisites of their scheduled rollout steps).
+// Prerequisites referencing flags that are not in the collection are ignored.
+func findPrerequisiteCycles(flags map[string]flag.InternalFlag) []string {
+	const (
+		notVisited = iota
+		inProgress
+		done
+	)
+	state := make(map[string]int, len(flags))
+	inCycle := make(map[string]bool)
+	stack := make([]string, 0)
+
+	var visit func(key string)
+	visit = func(key string) {
+		state[key] = inProgress
+		stack = append(stack, key)
+		f := flags[key]
+		for _, prerequisite := range f.GetAllPrerequisites() {
+			next := prerequisite.GetKey()
+			if _, ok := flags[next]; !ok {
+				continue
+			}
+			switch state[next] {
+			case notVisited:
+				visit(next)
+			case inProgress:
+				// all the flags of the stack since next are part of the cycle
+				for i := len(stack) - 1; i >= 0; i-- {
+					inCycle[stack[i]] = true
+					if stack[i] == next {
+						break
+					}
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[key] = done
+	}
+
+	for key := range flags {
+		if state[key] == notVisited {
+			visit(key)
+		}
+	}
+
+	cycles := make([]string, 0, len(inCycle))
+	for key := range inCycle {
+		cycles = append(cycles, key)
+	}
+	sort.Strings(cycles)
+	return cycles
+}
@@ -0,0 +1,254 @@
+package contextschema
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/thomaspoignant/go-feature-flag/internal/query"
+)
+
+// Mode is the way the evaluation contexts not matching the schema are handled.
+type Mode = string
+
+const (
+	// ModeLenient evaluates the flags anyway, the invalid evaluation contexts are only logged and counted.
+	ModeLenient Mode = "lenient"
+	// ModeStrict returns the default value with the error code INVALID_CONTEXT for an invalid evaluation context.
+	ModeStrict Mode = "strict"
+)
+
+// AttributeType is the type of the value of an attribute of the evaluation context.
+type AttributeType = string
+
+const (
+	TypeString AttributeType = "string"
+	TypeNumber AttributeType = "number"
+	TypeBool   AttributeType = "bool"
+	TypeArray  AttributeType = "array"
+	TypeObject AttributeType = "object"
+)
+
+// builtinAttributes are the attributes available in all the evaluation contexts.
+var builtinAttributes = []string{"key", "targetingKey", "anonymous", "env"}
+
+// Schema describes the attributes expected in the evaluation contexts.
+type Schema struct {
+	// Mode (optional) is the way the invalid evaluation contexts are handled, lenient or strict.
+	// Default: lenient
+	Mode *Mode `json:"mode,omitempty" yaml:"mode,omitempty" toml:"mode,omitempty" jsonschema:"enum=lenient,enum=strict,title=mode,description=The way the invalid evaluation contexts are handled. In strict mode the default value is returned with the error code INVALID_CONTEXT."` // nolint: lll
+
+	// Attributes are the attributes of the evaluation contexts indexed by their names,
+	// the attributes of the nested objects are named with a dot-separated path (ex: "company.id").
+	Attributes map[string]Attribute `json:"attributes,omitempty" yaml:"attributes,omitempty" toml:"attributes,omitempty" jsonschema:"title=attributes,description=Attributes of the evaluation contexts indexed by their names."` // nolint: lll
+
+	// AdditionalAttributes (optional) is false if the evaluation contexts cannot contain other attributes
+	// than the ones of the schema.
+	// Default: true
+	AdditionalAttributes *bool `json:"additionalAttributes,omitempty" yaml:"additionalAttributes,omitempty" toml:"additionalAttributes,omitempty" jsonschema:"title=additionalAttributes,description=False if the evaluation contexts cannot contain other attributes than the ones of the schema."` // nolint: lll
+}
+
+// Attribute describes an attribute of the evaluation contexts.
+type Attribute struct {
+	// Type (optional) is the type of the value of the attribute.
+	Type *AttributeType `json:"type,omitempty" yaml:"type,omitempty" toml:"type,omitempty" jsonschema:"enum=string,enum=number,enum=bool,enum=array,enum=object,title=type,description=Type of the value of the attribute."` // nolint: lll
+
+	// Required (optional) is true if all the evaluation contexts should contain the attribute.
+	// Default: false
+	Required *bool `json:"required,omitempty" yaml:"required,omitempty" toml:"required,omitempty" jsonschema:"title=required,description=True if all the evaluation contexts should contain the attribute."` // nolint: lll
+
+	// Values (optional) are the values allowed for the attribute.
+	Values *[]interface{} `json:"values,omitempty" yaml:"values,omitempty" toml:"values,omitempty" jsonschema:"title=values,description=Values allowed for the attribute."` // nolint: lll
+}
+
+// IsValid checks that the schema is valid.
+func (s *Schema) IsValid() error {
+	if s.Mode != nil && s.GetMode() != ModeLenient && s.GetMode() != ModeStrict {
+		return fmt.Errorf("invalid mode %s, available modes are %s and %s", s.GetMode(), ModeLenient, ModeStrict)
+	}
+	for _, name := range s.attributeNames() {
+		if name == "" {
+			return fmt.Errorf("an attribute should have a name")
+		}
+		attribute := s.Attributes[name]
+		if attribute.Type != nil && !isValidType(attribute.GetType()) {
+			return fmt.Errorf("invalid attribute %s: invalid type %s, available types are %s, %s, %s, %s and %s",
+				name, attribute.GetType(), TypeString, TypeNumber, TypeBool, TypeArray, TypeObject)
+		}
+		if attribute.Values != nil && len(attribute.GetValues()) == 0 {
+			return fmt.Errorf("invalid attribute %s: the allowed values should not be empty", name)
+		}
+		for _, value := range attribute.GetValues() {
+			if attribute.Type != nil && !matchType(attribute.GetType(), value) {
+				return fmt.Errorf("invalid attribute %s: the allowed value %v is not of type %s",
+					name, value, attribute.GetType())
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks that the attributes of an evaluation context follow the schema,
+// the error describes the first attribute not following the schema.
+func (s *Schema) Validate(attributes map[string]interface{}) error {
+	for _, name := range s.attributeNames() {
+		attribute := s.Attributes[name]
+		value, ok := query.Lookup(attributes, name)
+		if !ok {
+			if attribute.IsRequired() {
+				return fmt.Errorf("missing required attribute %s", name)
+			}
+			continue
+		}
+		if attribute.Type != nil && !matchType(attribute.GetType(), value) {
+			return fmt.Errorf("attribute %s should be of type %s", name, attribute.GetType())
+		}
+		if attribute.Values != nil && !slices.ContainsFunc(attribute.GetValues(), func(allowed interface{}) bool {
+			return equalValues(allowed, value)
+		}) {
+			return fmt.Errorf("attribute %s has the value %v, the allowed values are %v",
+				name, value, attribute.GetValues())
+		}
+	}
+
+	if !s.IsAdditionalAttributesAllowed() {
+		names := make([]string, 0, len(attributes))
+		for name := range attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !s.IsKnownAttribute(name) {
+				return fmt.Errorf("unknown attribute %s", name)
+			}
+		}
+	}
+	return nil
+}
+
+// IsKnownAttribute checks if the attribute is part of the schema or available in all the evaluation contexts.
+// The attributes of an object of the schema and the objects containing an attribute of the schema are known.
+func (s *Schema) IsKnownAttribute(name string) bool {
+	root := strings.Split(name, ".")[0]
+	if slices.Contains(builtinAttributes, root) {
+		return true
+	}
+	for declared := range s.Attributes {
+		if declared == name || strings.HasPrefix(declared, name+".") || strings.HasPrefix(name, declared+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMode is the getter of the field Mode
+func (s *Schema) GetMode() Mode {
+	if s.Mode == nil {
+		return ModeLenient
+	}
+	return *s.Mode
+}
+
+// IsStrict returns true if the invalid evaluation contexts should not be evaluated.
+func (s *Schema) IsStrict() bool {
+	return s.GetMode() == ModeStrict
+}
+
+// IsAdditionalAttributesAllowed is the getter of the field AdditionalAttributes
+func (s *Schema) IsAdditionalAttributesAllowed() bool {
+	if s.AdditionalAttributes == nil {
+		return true
+	}
+	return *s.AdditionalAttributes
+}
+
+// attributeNames returns the names of the attributes of the schema in alphabetical order.
+func (s *Schema) attributeNames() []string {
+	names := make([]string, 0, len(s.Attributes))
+	for name := range s.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetType is the getter of the field Type
+func (a Attribute) GetType() AttributeType {
+	if a.Type == nil {
+		return ""
+	}
+	return *a.Type
+}
+
+// IsRequired is the getter of the field Required
+func (a Attribute) IsRequired() bool {
+	return a.Required != nil && *a.Required
+}
+
+// GetValues is the getter of the field Values
+func (a Attribute) GetValues() []interface{} {
+	if a.Values == nil {
+		return []interface{}{}
+	}
+	return *a.Values
+}
+
+// isValidType checks if the type is one of the available types of attribute.
+func isValidType(attributeType AttributeType) bool {
+	switch attributeType {
+	case TypeString, TypeNumber, TypeBool, TypeArray, TypeObject:
+		return true
+	default:
+		return false
+	}
+}
+
+// matchType checks if the value is of the type.
+func matchType(attributeType AttributeType, value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	kind := reflect.TypeOf(value).Kind()
+	switch attributeType {
+	case TypeString:
+		return kind == reflect.String
+	case TypeNumber:
+		_, ok := toFloat(value)
+		return ok
+	case TypeBool:
+		return kind == reflect.Bool
+	case TypeArray:
+		return kind == reflect.Slice || kind == reflect.Array
+	case TypeObject:
+		return kind == reflect.Map
+	default:
+		return true
+	}
+}
+
+// equalValues compares two values, the numbers are compared whatever their Go type is.
+func equalValues(a interface{}, b interface{}) bool {
+	numberA, okA := toFloat(a)
+	numberB, okB := toFloat(b)
+	if okA && okB {
+		return numberA == numberB
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// toFloat converts a number to a float64, ok is false if the value is not a number.
+func toFloat(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
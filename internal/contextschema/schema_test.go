@@ -0,0 +1,173 @@
+package contextschema_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/internal/contextschema"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+func newSchema() *contextschema.Schema {
+	return &contextschema.Schema{
+		Attributes: map[string]contextschema.Attribute{
+			"plan": {
+				Type:     testconvert.String(contextschema.TypeString),
+				Required: testconvert.Bool(true),
+				Values:   &[]interface{}{"free", "pro"},
+			},
+			"age":        {Type: testconvert.String(contextschema.TypeNumber)},
+			"company.id": {Type: testconvert.String(contextschema.TypeString)},
+			"level":      {Values: &[]interface{}{1, 2}},
+		},
+	}
+}
+
+func TestSchema_IsValid(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  *contextschema.Schema
+		wantErr string
+	}{
+		{
+			name:   "valid schema",
+			schema: newSchema(),
+		},
+		{
+			name:   "empty schema",
+			schema: &contextschema.Schema{},
+		},
+		{
+			name:    "invalid mode",
+			schema:  &contextschema.Schema{Mode: testconvert.String("fast")},
+			wantErr: "invalid mode fast, available modes are lenient and strict",
+		},
+		{
+			name: "attribute without name",
+			schema: &contextschema.Schema{Attributes: map[string]contextschema.Attribute{
+				"": {Type: testconvert.String(contextschema.TypeString)},
+			}},
+			wantErr: "an attribute should have a name",
+		},
+		{
+			name: "invalid type",
+			schema: &contextschema.Schema{Attributes: map[string]contextschema.Attribute{
+				"plan": {Type: testconvert.String("text")},
+			}},
+			wantErr: "invalid attribute plan: invalid type text, available types are string, number, bool, array and object",
+		},
+		{
+			name: "empty allowed values",
+			schema: &contextschema.Schema{Attributes: map[string]contextschema.Attribute{
+				"plan": {Values: &[]interface{}{}},
+			}},
+			wantErr: "invalid attribute plan: the allowed values should not be empty",
+		},
+		{
+			name: "allowed value of the wrong type",
+			schema: &contextschema.Schema{Attributes: map[string]contextschema.Attribute{
+				"plan": {Type: testconvert.String(contextschema.TypeString), Values: &[]interface{}{"free", 1}},
+			}},
+			wantErr: "invalid attribute plan: the allowed value 1 is not of type string",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schema.IsValid()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSchema_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		schema     *contextschema.Schema
+		attributes map[string]interface{}
+		wantErr    string
+	}{
+		{
+			name:   "valid context",
+			schema: newSchema(),
+			attributes: map[string]interface{}{
+				"key":     "user-key",
+				"plan":    "pro",
+				"age":     42,
+				"company": map[string]interface{}{"id": "acme"},
+				"level":   float64(2),
+				"other":   true,
+			},
+		},
+		{
+			name:       "missing required attribute",
+			schema:     newSchema(),
+			attributes: map[string]interface{}{"key": "user-key", "Plan": "pro"},
+			wantErr:    "missing required attribute plan",
+		},
+		{
+			name:       "attribute of the wrong type",
+			schema:     newSchema(),
+			attributes: map[string]interface{}{"plan": "pro", "age": "42"},
+			wantErr:    "attribute age should be of type number",
+		},
+		{
+			name:       "nested attribute of the wrong type",
+			schema:     newSchema(),
+			attributes: map[string]interface{}{"plan": "pro", "company": map[string]interface{}{"id": 1}},
+			wantErr:    "attribute company.id should be of type string",
+		},
+		{
+			name:       "value not allowed",
+			schema:     newSchema(),
+			attributes: map[string]interface{}{"plan": "enterprise"},
+			wantErr:    "attribute plan has the value enterprise, the allowed values are [free pro]",
+		},
+		{
+			name: "unknown attribute",
+			schema: &contextschema.Schema{
+				AdditionalAttributes: testconvert.Bool(false),
+				Attributes: map[string]contextschema.Attribute{
+					"plan":       {Type: testconvert.String(contextschema.TypeString)},
+					"company.id": {Type: testconvert.String(contextschema.TypeString)},
+				},
+			},
+			attributes: map[string]interface{}{
+				"key":       "user-key",
+				"anonymous": false,
+				"company":   map[string]interface{}{"id": "acme"},
+				"Plan":      "pro",
+			},
+			wantErr: "unknown attribute Plan",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schema.Validate(tt.attributes)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSchema_IsKnownAttribute(t *testing.T) {
+	schema := newSchema()
+	assert.True(t, schema.IsKnownAttribute("plan"))
+	assert.True(t, schema.IsKnownAttribute("company"))
+	assert.True(t, schema.IsKnownAttribute("company.id"))
+	assert.True(t, schema.IsKnownAttribute("targetingKey"))
+	assert.True(t, schema.IsKnownAttribute("env"))
+	assert.False(t, schema.IsKnownAttribute("Plan"))
+	assert.False(t, schema.IsKnownAttribute("company.name"))
+}
+
+func TestSchema_Mode(t *testing.T) {
+	assert.False(t, (&contextschema.Schema{}).IsStrict())
+	assert.True(t, (&contextschema.Schema{Mode: testconvert.String(contextschema.ModeStrict)}).IsStrict())
+}
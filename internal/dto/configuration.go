@@ -0,0 +1,134 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/thomaspoignant/go-feature-flag/internal/contextschema"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"gopkg.in/yaml.v3"
+)
+
+// SegmentsKey is the reserved top-level key of a configuration file used to declare the segments.
+// It means that no flag can be named "segments", "layers", "holdout" or "contextSchema".
+const SegmentsKey = "segments"
+
+// LayersKey is the reserved top-level key of a configuration file used to declare the experiment layers.
+const LayersKey = "layers"
+
+// HoldoutKey is the reserved top-level key of a configuration file used to declare the global holdout.
+const HoldoutKey = "holdout"
+
+// ContextSchemaKey is the reserved top-level key of a configuration file used to declare the context schema.
+const ContextSchemaKey = "contextSchema"
+
+// Configuration is the content of a configuration file.
+// It contains the flags and the shared objects that those flags can reference.
+// Use Unmarshal to parse a configuration file.
+type Configuration struct {
+	// Flags are all the flags of the configuration file indexed by their keys.
+	Flags map[string]DTO `json:"-" yaml:"-" toml:"-"`
+
+	// SkippedEntries are the errors of the top-level entries ignored when parsing the file,
+	// the rest of the file is still loaded.
+	SkippedEntries []error `json:"-" yaml:"-" toml:"-"`
+
+	// Segments are named audiences that can be referenced by the targeting rules of all the flags.
+	Segments map[string]flag.Segment `json:"segments,omitempty" yaml:"segments,omitempty" toml:"segments,omitempty" jsonschema:"title=segments,description=Named audiences that can be referenced by the targeting rules of all the flags."` // nolint: lll
+
+	// Layers are groups of mutually exclusive experiments, an evaluation context sees at most one flag of a layer.
+	Layers map[string]flag.Layer `json:"layers,omitempty" yaml:"layers,omitempty" toml:"layers,omitempty" jsonschema:"title=layers,description=Groups of mutually exclusive experiments. An evaluation context sees at most one flag of a layer."` // nolint: lll
+
+	// Holdout is the part of the traffic that never sees any flag of the layers, the flags outside of the layers
+	// are not affected by the holdout.
+	Holdout *flag.Holdout `json:"holdout,omitempty" yaml:"holdout,omitempty" toml:"holdout,omitempty" jsonschema:"title=holdout,description=Part of the traffic that never sees any flag of the layers."` // nolint: lll
+
+	// ContextSchema describes the attributes expected in the evaluation contexts, it is used to validate them.
+	ContextSchema *contextschema.Schema `json:"contextSchema,omitempty" yaml:"contextSchema,omitempty" toml:"contextSchema,omitempty" jsonschema:"title=contextSchema,description=Attributes expected in the evaluation contexts. It is used to validate the evaluation contexts and the queries of the rules."` // nolint: lll
+}
+
+// Unmarshal parses a configuration file in the format json, toml or yaml (default).
+// The file is decoded once: the reserved top-level keys are decoded into the shared objects
+// and all the other keys into the flags.
+// A reserved key containing a flag is skipped and reported in SkippedEntries, because a flag cannot use
+// a reserved name.
+func Unmarshal(data []byte, format string) (Configuration, error) {
+	var config Configuration
+	var err error
+	switch strings.ToLower(format) {
+	case "toml":
+		var entries map[string]toml.Primitive
+		var metadata toml.MetaData
+		if metadata, err = toml.Decode(string(data), &entries); err == nil {
+			err = setEntries(&config, entries, func(entry toml.Primitive, v interface{}) error {
+				return metadata.PrimitiveDecode(entry, v)
+			})
+		}
+	case "json":
+		var entries map[string]json.RawMessage
+		if err = json.Unmarshal(data, &entries); err == nil {
+			err = setEntries(&config, entries, func(entry json.RawMessage, v interface{}) error {
+				return json.Unmarshal(entry, v)
+			})
+		}
+	default:
+		var entries map[string]yaml.Node
+		if err = yaml.Unmarshal(data, &entries); err == nil {
+			err = setEntries(&config, entries, func(entry yaml.Node, v interface{}) error {
+				return entry.Decode(v)
+			})
+		}
+	}
+	return config, err
+}
+
+// setEntries decodes the top-level entries of a configuration file into the configuration.
+func setEntries[T any](c *Configuration, entries map[string]T, decode func(entry T, v interface{}) error) error {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	c.Flags = make(map[string]DTO, len(entries))
+	for _, key := range keys {
+		entry := entries[key]
+		if err := c.setEntry(key, func(v interface{}) error { return decode(entry, v) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setEntry decodes the top-level entry key of a configuration file into the shared object or the flag it contains.
+func (c *Configuration) setEntry(key string, decode func(v interface{}) error) error {
+	var sharedObject interface{}
+	switch key {
+	case SegmentsKey:
+		sharedObject = &c.Segments
+	case LayersKey:
+		sharedObject = &c.Layers
+	case HoldoutKey:
+		sharedObject = &c.Holdout
+	case ContextSchemaKey:
+		sharedObject = &c.ContextSchema
+	default:
+		var flagDto DTO
+		if err := decode(&flagDto); err != nil {
+			return err
+		}
+		c.Flags[key] = flagDto
+		return nil
+	}
+
+	var flagDto DTO
+	if decode(&flagDto) == nil && flagDto.isFlag() {
+		c.SkippedEntries = append(c.SkippedEntries,
+			fmt.Errorf("invalid flag %s: %s is a reserved key, it cannot be used as the name of a flag", key, key))
+		return nil
+	}
+	return decode(sharedObject)
+}
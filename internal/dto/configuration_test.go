@@ -0,0 +1,117 @@
+package dto_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/internal/dto"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+func TestUnmarshal(t *testing.T) {
+	wantSegments := map[string]flag.Segment{
+		"beta-testers": {Query: testconvert.String("beta eq true")},
+	}
+	tests := []struct {
+		name         string
+		format       string
+		data         string
+		wantFlags    []string
+		wantSegments map[string]flag.Segment
+		wantSkipped  []string
+		wantErr      string
+	}{
+		{
+			name:   "yaml",
+			format: "yaml",
+			data: `
+segments:
+  beta-testers:
+    query: beta eq true
+test-flag:
+  variations:
+    A: true
+    B: false
+  defaultRule:
+    variation: A
+`,
+			wantFlags:    []string{"test-flag"},
+			wantSegments: wantSegments,
+		},
+		{
+			name:   "json",
+			format: "json",
+			data: `{
+  "segments": {"beta-testers": {"query": "beta eq true"}},
+  "test-flag": {"variations": {"A": true, "B": false}, "defaultRule": {"variation": "A"}}
+}`,
+			wantFlags:    []string{"test-flag"},
+			wantSegments: wantSegments,
+		},
+		{
+			name:   "toml",
+			format: "toml",
+			data: `
+[segments.beta-testers]
+query = "beta eq true"
+
+[test-flag]
+defaultRule = { variation = "A" }
+[test-flag.variations]
+A = true
+B = false
+`,
+			wantFlags:    []string{"test-flag"},
+			wantSegments: wantSegments,
+		},
+		{
+			name:   "flag using a reserved name",
+			format: "yaml",
+			data: `
+holdout:
+  variations:
+    A: true
+    B: false
+  defaultRule:
+    variation: A
+test-flag:
+  variations:
+    A: true
+    B: false
+  defaultRule:
+    variation: A
+`,
+			wantFlags:   []string{"test-flag"},
+			wantSkipped: []string{"invalid flag holdout: holdout is a reserved key, it cannot be used as the name of a flag"},
+		},
+		{
+			name:    "invalid file",
+			format:  "json",
+			data:    `{"test-flag": `,
+			wantErr: "unexpected end of JSON input",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dto.Unmarshal([]byte(tt.data), tt.format)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantSegments, got.Segments)
+			flags := make([]string, 0, len(got.Flags))
+			for key := range got.Flags {
+				flags = append(flags, key)
+			}
+			assert.ElementsMatch(t, tt.wantFlags, flags)
+			skipped := make([]string, 0, len(got.SkippedEntries))
+			for _, err := range got.SkippedEntries {
+				skipped = append(skipped, err.Error())
+			}
+			assert.ElementsMatch(t, tt.wantSkipped, skipped)
+			assert.Nil(t, got.Holdout)
+		})
+	}
+}
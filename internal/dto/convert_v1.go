@@ -13,14 +13,25 @@ func ConvertV1DtoToInternalFlag(dto DTO) flag.InternalFlag {
 	}
 
 	return flag.InternalFlag{
-		Variations:      dto.Variations,
-		Rules:           dto.Rules,
-		DefaultRule:     dto.DefaultRule,
-		TrackEvents:     dto.TrackEvents,
-		Disable:         dto.Disable,
-		Version:         dto.Version,
-		Scheduled:       dto.Scheduled,
-		Experimentation: experimentation,
-		Metadata:        dto.Metadata,
+		Variations:          dto.Variations,
+		Type:                dto.Type,
+		Schema:              dto.Schema,
+		Rules:               dto.Rules,
+		Targets:             dto.Targets,
+		DefaultRule:         dto.DefaultRule,
+		TrackEvents:         dto.TrackEvents,
+		Disable:             dto.Disable,
+		Version:             dto.Version,
+		Scheduled:           dto.Scheduled,
+		Environments:        dto.Environments,
+		Experimentation:     experimentation,
+		TimeWindows:         dto.TimeWindows,
+		Metadata:            dto.Metadata,
+		BucketingKey:        dto.BucketingKey,
+		Seed:                dto.Seed,
+		HashAlgorithm:       dto.HashAlgorithm,
+		Prerequisites:       dto.Prerequisites,
+		FallbackVariation:   dto.FallbackVariation,
+		TemplatedVariations: dto.TemplatedVariations,
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 
 	"github.com/thomaspoignant/go-feature-flag/testutils/flagv1"
 
@@ -332,6 +333,74 @@ func TestConvertV0DtoToInternalFlag(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "[v0] Flag without query + progressive rollout with steps",
+			d: dto.DTO{
+				DTOv0: dto.DTOv0{
+					True:    testconvert.Interface("true"),
+					False:   testconvert.Interface("false"),
+					Default: testconvert.Interface("default"),
+					Rollout: &dto.Rollout{
+						CommonRollout: dto.CommonRollout{
+							Progressive: &dto.ProgressiveV0{
+								Percentage: dto.ProgressivePercentageV0{
+									Initial: 1,
+									End:     100,
+								},
+								ReleaseRamp: dto.ProgressiveReleaseRampV0{
+									Start: testconvert.Time(time.Date(2021, time.February, 1, 10, 10, 10, 10, time.UTC)),
+									End:   testconvert.Time(time.Date(2021, time.February, 4, 10, 10, 10, 10, time.UTC)),
+								},
+								Steps: []dto.ProgressiveStepV0{
+									{
+										Date:       testconvert.Time(time.Date(2021, time.February, 2, 10, 10, 10, 10, time.UTC)),
+										Percentage: testconvert.Float64(5),
+										Hold:       testconvert.Bool(true),
+									},
+									{
+										Date:       testconvert.Time(time.Date(2021, time.February, 3, 10, 10, 10, 10, time.UTC)),
+										Percentage: testconvert.Float64(25),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: flag.InternalFlag{
+				Variations: &map[string]*interface{}{
+					"Default": testconvert.Interface("default"),
+					"False":   testconvert.Interface("false"),
+					"True":    testconvert.Interface("true"),
+				},
+				DefaultRule: &flag.Rule{
+					Name: testconvert.String("legacyDefaultRule"),
+					ProgressiveRollout: &flag.ProgressiveRollout{
+						Initial: &flag.ProgressiveRolloutStep{
+							Variation:  testconvert.String("False"),
+							Percentage: testconvert.Float64(1),
+							Date:       testconvert.Time(time.Date(2021, time.February, 1, 10, 10, 10, 10, time.UTC)),
+						},
+						Steps: &[]flag.ProgressiveRolloutRampStep{
+							{
+								Date:       testconvert.Time(time.Date(2021, time.February, 2, 10, 10, 10, 10, time.UTC)),
+								Percentage: testconvert.Float64(5),
+								Hold:       testconvert.Bool(true),
+							},
+							{
+								Date:       testconvert.Time(time.Date(2021, time.February, 3, 10, 10, 10, 10, time.UTC)),
+								Percentage: testconvert.Float64(25),
+							},
+						},
+						End: &flag.ProgressiveRolloutStep{
+							Variation:  testconvert.String("True"),
+							Percentage: testconvert.Float64(100),
+							Date:       testconvert.Time(time.Date(2021, time.February, 4, 10, 10, 10, 10, time.UTC)),
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "[v1] Complete and complex flag v1",
 			d: dto.DTO{
@@ -440,7 +509,9 @@ func TestConvertV0DtoToInternalFlag(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.Equal(t, tt.want, tt.d.Convert(), cmp.Diff(tt.want, tt.d.Convert()))
+			got := tt.d.Convert()
+			assert.Equal(t, tt.want, got,
+				cmp.Diff(tt.want, got, cmpopts.IgnoreUnexported(flag.InternalFlag{})))
 		})
 	}
 }
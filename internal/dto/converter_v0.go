@@ -67,19 +67,8 @@ func createDefaultLegacyRuleV0(d DTO, hasTargetRule bool) *flag.Rule {
 
 	if hasProgressiveRollout && !hasTargetRule {
 		return &flag.Rule{
-			Name: &defaultRuleName,
-			ProgressiveRollout: &flag.ProgressiveRollout{
-				Initial: &flag.ProgressiveRolloutStep{
-					Variation:  &falseVariation,
-					Percentage: &d.Rollout.Progressive.Percentage.Initial,
-					Date:       d.Rollout.Progressive.ReleaseRamp.Start,
-				},
-				End: &flag.ProgressiveRolloutStep{
-					Variation:  &trueVariation,
-					Percentage: &d.Rollout.Progressive.Percentage.End,
-					Date:       d.Rollout.Progressive.ReleaseRamp.End,
-				},
-			},
+			Name:               &defaultRuleName,
+			ProgressiveRollout: convertProgressiveV0(d.Rollout.Progressive),
 		}
 	}
 
@@ -111,18 +100,7 @@ func createLegacyRuleV0(d DTO) flag.Rule {
 		d.Rollout.Progressive != nil &&
 		d.Rollout.Progressive.ReleaseRamp.Start != nil &&
 		d.Rollout.Progressive.ReleaseRamp.End != nil {
-		progressiveRollout = &flag.ProgressiveRollout{
-			Initial: &flag.ProgressiveRolloutStep{
-				Variation:  &falseVariation,
-				Percentage: &d.Rollout.Progressive.Percentage.Initial,
-				Date:       d.Rollout.Progressive.ReleaseRamp.Start,
-			},
-			End: &flag.ProgressiveRolloutStep{
-				Variation:  &trueVariation,
-				Percentage: &d.Rollout.Progressive.Percentage.End,
-				Date:       d.Rollout.Progressive.ReleaseRamp.End,
-			},
-		}
+		progressiveRollout = convertProgressiveV0(d.Rollout.Progressive)
 	}
 
 	var percentages *map[string]float64
@@ -315,18 +293,36 @@ func convertScheduledStepProgressiveRollout(dto ScheduledStepV0) *flag.Progressi
 
 	var progressive *flag.ProgressiveRollout
 	if hasProgressiveRollout {
-		progressive = &flag.ProgressiveRollout{
-			Initial: &flag.ProgressiveRolloutStep{
-				Variation:  &falseVariation,
-				Percentage: &dto.Rollout.Progressive.Percentage.Initial,
-				Date:       dto.Rollout.Progressive.ReleaseRamp.Start,
-			},
-			End: &flag.ProgressiveRolloutStep{
-				Variation:  &trueVariation,
-				Percentage: &dto.Rollout.Progressive.Percentage.End,
-				Date:       dto.Rollout.Progressive.ReleaseRamp.End,
-			},
-		}
+		progressive = convertProgressiveV0(dto.Rollout.Progressive)
 	}
 	return progressive
 }
+
+// convertProgressiveV0 convert the legacy progressive rollout, including its intermediate steps, to the new format.
+func convertProgressiveV0(progressive *ProgressiveV0) *flag.ProgressiveRollout {
+	rollout := &flag.ProgressiveRollout{
+		Initial: &flag.ProgressiveRolloutStep{
+			Variation:  &falseVariation,
+			Percentage: &progressive.Percentage.Initial,
+			Date:       progressive.ReleaseRamp.Start,
+		},
+		End: &flag.ProgressiveRolloutStep{
+			Variation:  &trueVariation,
+			Percentage: &progressive.Percentage.End,
+			Date:       progressive.ReleaseRamp.End,
+		},
+	}
+
+	if len(progressive.Steps) > 0 {
+		steps := make([]flag.ProgressiveRolloutRampStep, 0, len(progressive.Steps))
+		for _, step := range progressive.Steps {
+			steps = append(steps, flag.ProgressiveRolloutRampStep{
+				Percentage: step.Percentage,
+				Date:       step.Date,
+				Hold:       step.Hold,
+			})
+		}
+		rollout.Steps = &steps
+	}
+	return rollout
+}
@@ -33,14 +33,28 @@ type DTOv1 struct {
 	// limit except if the variationValue is a bool, the max is 2.
 	Variations *map[string]*interface{} `json:"variations,omitempty" yaml:"variations,omitempty" toml:"variations,omitempty"  jsonschema:"required,title=variations,description=All the variations available for this flag. You need at least 2 variations and it is a key value pair. All the variations should have the same type."` // nolint:lll
 
+	// Type (optional) is the declared type of the values of the flag, all the variations should be of this type.
+	Type *string `json:"type,omitempty" yaml:"type,omitempty" toml:"type,omitempty" jsonschema:"enum=bool,enum=string,enum=int,enum=float,enum=json,title=type,description=Declared type of the values of the flag. All the variations should be of this type."` // nolint: lll
+
+	// Schema (optional) is a JSON schema that all the variations of the flag should follow.
+	Schema *map[string]interface{} `json:"schema,omitempty" yaml:"schema,omitempty" toml:"schema,omitempty" jsonschema:"title=schema,description=JSON schema that all the variations of the flag should follow."` // nolint: lll
+
 	// Rules is the list of Rule for this flag.
 	// This an optional field.
 	Rules *[]flag.Rule `json:"targeting,omitempty" yaml:"targeting,omitempty" toml:"targeting,omitempty" jsonschema:"title=targeting,description=List of rule to target a subset of the users based on the evaluation context."` // nolint: lll
 
+	// Targets is the list of individual targeting lists for this flag, they are checked before the Rules.
+	// This an optional field.
+	Targets *[]flag.Target `json:"targets,omitempty" yaml:"targets,omitempty" toml:"targets,omitempty" jsonschema:"title=targets,description=Lists of values of an attribute of the evaluation context that always receive a specific variation. They are checked before the targeting rules."` // nolint: lll
+
 	// DefaultRule is the rule applied after checking that any other rules
 	// matched the user.
 	DefaultRule *flag.Rule `json:"defaultRule,omitempty" yaml:"defaultRule,omitempty" toml:"defaultRule,omitempty" jsonschema:"required,title=defaultRule,description=How do we evaluate the flag if the user is not part of any of the targeting rule."` // nolint: lll
 
+	// Environments (optional) contains the overrides of the flag (variations, targeting, default rule and disable)
+	// for each environment, the override of the configured environment is applied when loading the flag.
+	Environments *map[string]flag.EnvironmentOverride `json:"environments,omitempty" yaml:"environments,omitempty" toml:"environments,omitempty" jsonschema:"title=environments,description=Overrides of the flag for each environment. The override of the configured environment is applied when loading the flag."` // nolint: lll
+
 	// Scheduled is your struct to configure an update on some fields of your flag over time.
 	// You can add several steps that updates the flag, this is typically used if you want to gradually add more user
 	// in your flag.
@@ -51,8 +65,31 @@ type DTOv1 struct {
 	// When the experimentation is not running, the flag will serve the default value.
 	Experimentation *ExperimentationDto `json:"experimentation,omitempty" yaml:"experimentation,omitempty" toml:"experimentation,omitempty" jsonschema:"title=experimentation,description=Configure an experimentation. It will allow you to configure a start date and an end date for your flag."` // nolint: lll
 
+	// TimeWindows is a list of recurring periods of time where the flag is active.
+	TimeWindows *[]flag.TimeWindow `json:"timeWindows,omitempty" yaml:"timeWindows,omitempty" toml:"timeWindows,omitempty" jsonschema:"title=timeWindows,description=Recurring periods of time where the flag is active. Outside of these windows the flag serves the default value."` // nolint: lll
+
 	// Metadata is a field containing information about your flag such as an issue tracker link, a description, etc ...
 	Metadata *map[string]interface{} `json:"metadata,omitempty" yaml:"metadata,omitempty" toml:"metadata,omitempty" jsonschema:"title=metadata,description=A field containing information about your flag such as an issue tracker link a description etc..."` // nolint: lll
+
+	// BucketingKey is the attribute of the evaluation context used to affect a user to a percentage bucket.
+	BucketingKey *string `json:"bucketingKey,omitempty" yaml:"bucketingKey,omitempty" toml:"bucketingKey,omitempty" jsonschema:"title=bucketingKey,description=Attribute of the evaluation context used to affect a user to a percentage bucket (ex: organizationId). Default is the targeting key."` // nolint: lll
+
+	// Seed is the salt added to the bucketing value before hashing it to select the percentage bucket.
+	Seed *string `json:"seed,omitempty" yaml:"seed,omitempty" toml:"seed,omitempty" jsonschema:"title=seed,description=Salt added to the bucketing value before hashing it to select the percentage bucket. Change it to reshuffle the users of an experiment. Default is the flag name."` // nolint: lll
+
+	// HashAlgorithm is the algorithm used to hash the bucketing value.
+	HashAlgorithm *string `json:"hashAlgorithm,omitempty" yaml:"hashAlgorithm,omitempty" toml:"hashAlgorithm,omitempty" jsonschema:"title=hashAlgorithm,enum=fnv,enum=murmur3,enum=sha1,description=Algorithm used to hash the bucketing value to select the percentage bucket. Default is fnv."` // nolint: lll
+
+	// Prerequisites is the list of flags that should resolve to a specific variation for the same evaluation
+	// context before evaluating the targeting of this flag.
+	Prerequisites *[]flag.Prerequisite `json:"prerequisites,omitempty" yaml:"prerequisites,omitempty" toml:"prerequisites,omitempty" jsonschema:"title=prerequisites,description=List of flags that should resolve to a specific variation for the same evaluation context before evaluating the targeting of this flag."` // nolint: lll
+
+	// FallbackVariation is the variation served when one of the prerequisites is not fulfilled.
+	FallbackVariation *string `json:"fallbackVariation,omitempty" yaml:"fallbackVariation,omitempty" toml:"fallbackVariation,omitempty" jsonschema:"title=fallbackVariation,description=Variation served when one of the prerequisites is not fulfilled. If not set the SDK default value is served."` // nolint: lll
+
+	// TemplatedVariations is true if the string values of the variations are templates rendered with the
+	// attributes of the evaluation context.
+	TemplatedVariations *bool `json:"templatedVariations,omitempty" yaml:"templatedVariations,omitempty" toml:"templatedVariations,omitempty" jsonschema:"title=templatedVariations,description=Render the string values of the variations as templates with the attributes of the evaluation context (ex: Hello {{ .name }}). Default is false."` // nolint: lll
 }
 
 // DTOv0 describe the fields of a flag.
@@ -91,3 +128,8 @@ func (d *DTO) Convert() flag.InternalFlag {
 	}
 	return ConvertV1DtoToInternalFlag(*d)
 }
+
+// isFlag checks if the DTO contains the fields of a flag, in one of the flag formats.
+func (d *DTO) isFlag() bool {
+	return d.Variations != nil || d.DefaultRule != nil || d.True != nil || d.False != nil || d.Default != nil
+}
@@ -62,6 +62,23 @@ type ProgressiveV0 struct {
 	// This field is mandatory if you want to use a progressive rollout.
 	// If any field missing we ignore the progressive rollout.
 	ReleaseRamp ProgressiveReleaseRampV0 `json:"releaseRamp,omitempty" yaml:"releaseRamp,omitempty" toml:"releaseRamp,omitempty"` // nolint: lll
+
+	// Steps is an ordered list of intermediate steps between the start and the end of the release ramp.
+	// This field is optional
+	Steps []ProgressiveStepV0 `json:"steps,omitempty" yaml:"steps,omitempty" toml:"steps,omitempty"`
+}
+
+type ProgressiveStepV0 struct {
+	// Date is the time when the percentage of the step is reached.
+	Date *time.Time `json:"date,omitempty" yaml:"date,omitempty" toml:"date,omitempty"`
+
+	// Percentage is the percentage of the rollout reached at the date of the step.
+	Percentage *float64 `json:"percentage,omitempty" yaml:"percentage,omitempty" toml:"percentage,omitempty"`
+
+	// Hold keeps the percentage of the step until the date of the next step.
+	// This field is optional
+	// Default: false
+	Hold *bool `json:"hold,omitempty" yaml:"hold,omitempty" toml:"hold,omitempty"`
 }
 
 type ProgressivePercentageV0 struct {
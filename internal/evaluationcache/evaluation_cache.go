@@ -0,0 +1,106 @@
+package evaluationcache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Stats contains the counters of a Cache.
+type Stats struct {
+	// Hits is the number of lookups that found a value in the cache.
+	Hits uint64
+	// Misses is the number of lookups that did not find a value in the cache.
+	Misses uint64
+	// Size is the number of values currently in the cache.
+	Size int
+}
+
+// Cache is a bounded LRU cache, the least recently used value is removed when the cache is full.
+//
+// Each value is stored for a generation (ex: the date of the latest update of the flags),
+// the cache is emptied as soon as it is used with another generation.
+type Cache[G comparable, V any] struct {
+	mutex      sync.Mutex
+	maxSize    int
+	generation G
+	entries    map[string]*list.Element
+	order      *list.List
+	hits       uint64
+	misses     uint64
+}
+
+// entry is an element of the LRU list.
+type entry[V any] struct {
+	key   string
+	value V
+}
+
+// New creates a cache keeping at most maxSize values.
+func New[G comparable, V any](maxSize int) *Cache[G, V] {
+	return &Cache[G, V]{
+		maxSize: maxSize,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns the value of the key for this generation.
+func (c *Cache[G, V]) Get(generation G, key string) (V, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.checkGeneration(generation)
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		var empty V
+		return empty, false
+	}
+	c.hits++
+	c.order.MoveToFront(element)
+	return element.Value.(*entry[V]).value, true
+}
+
+// Set stores the value of the key for this generation.
+func (c *Cache[G, V]) Set(generation G, key string, value V) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.checkGeneration(generation)
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*entry[V]).value = value
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&entry[V]{key: key, value: value})
+	if c.order.Len() > c.maxSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry[V]).key)
+	}
+}
+
+// Purge removes all the values of the cache.
+func (c *Cache[G, V]) Purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+// Stats returns the counters of the cache.
+func (c *Cache[G, V]) Stats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return Stats{Hits: c.hits, Misses: c.misses, Size: c.order.Len()}
+}
+
+// checkGeneration empties the cache if the generation has changed, the lock should be held.
+func (c *Cache[G, V]) checkGeneration(generation G) {
+	if c.generation == generation {
+		return
+	}
+	c.generation = generation
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
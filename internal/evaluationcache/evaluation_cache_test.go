@@ -0,0 +1,40 @@
+package evaluationcache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/internal/evaluationcache"
+)
+
+func TestCache(t *testing.T) {
+	generation := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := evaluationcache.New[time.Time, string](2)
+
+	_, ok := c.Get(generation, "a")
+	assert.False(t, ok)
+
+	c.Set(generation, "a", "value-a")
+	c.Set(generation, "b", "value-b")
+	got, ok := c.Get(generation, "a")
+	assert.True(t, ok)
+	assert.Equal(t, "value-a", got)
+
+	// b is the least recently used value, it is removed
+	c.Set(generation, "c", "value-c")
+	_, ok = c.Get(generation, "b")
+	assert.False(t, ok)
+	_, ok = c.Get(generation, "c")
+	assert.True(t, ok)
+	assert.Equal(t, evaluationcache.Stats{Hits: 2, Misses: 2, Size: 2}, c.Stats())
+
+	// a new generation empties the cache
+	_, ok = c.Get(generation.Add(time.Minute), "a")
+	assert.False(t, ok)
+	assert.Equal(t, evaluationcache.Stats{Hits: 2, Misses: 3, Size: 0}, c.Stats())
+
+	c.Set(generation, "a", "value-a")
+	c.Purge()
+	assert.Equal(t, 0, c.Stats().Size)
+}
@@ -0,0 +1,46 @@
+package flag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/internalerror"
+	"github.com/thomaspoignant/go-feature-flag/internal/utils"
+)
+
+// getBucketingValue returns the value used to bucket the evaluation context.
+// If no bucketingKey is provided, the targeting key of the context is used, otherwise we look for
+// the attribute (nested attributes are separated by a dot, ex: "company.id") in the evaluation context.
+// For a multi-kind context, the bucketing key can be the name of a kind (ex: "organization") to use the key of
+// this kind.
+func getBucketingValue(ctx ffcontext.Context, bucketingKey string) (string, error) {
+	if bucketingKey == "" {
+		return ctx.GetKey(), nil
+	}
+	if multiContext, ok := ctx.(ffcontext.MultiContext); ok {
+		if kind, ok := multiContext.GetKind(bucketingKey); ok {
+			if kind.GetKey() == "" {
+				return "", &internalerror.BucketingKeyMissing{BucketingKey: bucketingKey}
+			}
+			return kind.GetKey(), nil
+		}
+	}
+
+	var current interface{} = utils.ContextToMap(ctx)
+	for _, attribute := range strings.Split(bucketingKey, ".") {
+		attributes, ok := current.(map[string]interface{})
+		if !ok {
+			return "", &internalerror.BucketingKeyMissing{BucketingKey: bucketingKey}
+		}
+		if current, ok = attributes[attribute]; !ok || current == nil {
+			return "", &internalerror.BucketingKeyMissing{BucketingKey: bucketingKey}
+		}
+	}
+
+	value := fmt.Sprintf("%v", current)
+	if value == "" {
+		return "", &internalerror.BucketingKeyMissing{BucketingKey: bucketingKey}
+	}
+	return value, nil
+}
@@ -0,0 +1,107 @@
+package flag_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+func TestInternalFlag_BucketingKey(t *testing.T) {
+	percentageFlag := flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"A": testconvert.Interface("A"),
+			"B": testconvert.Interface("B"),
+		},
+		Rules: &[]flag.Rule{
+			{
+				Name:         testconvert.String("device rollout"),
+				Query:        testconvert.String(`platform eq "mobile"`),
+				BucketingKey: testconvert.String("device.id"),
+				Percentages:  &map[string]float64{"A": 50, "B": 50},
+			},
+		},
+		DefaultRule: &flag.Rule{
+			Percentages: &map[string]float64{"A": 50, "B": 50},
+		},
+		BucketingKey: testconvert.String("organizationId"),
+	}
+	staticFlag := flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"A": testconvert.Interface("A"),
+			"B": testconvert.Interface("B"),
+		},
+		DefaultRule:  &flag.Rule{VariationResult: testconvert.String("B")},
+		BucketingKey: testconvert.String("organizationId"),
+	}
+	orgCtx := func(key string) ffcontext.Context {
+		return ffcontext.NewEvaluationContextBuilder(key).AddCustom("organizationId", "acme-corp").Build()
+	}
+
+	tests := []struct {
+		name               string
+		flag               flag.InternalFlag
+		ctx                ffcontext.Context
+		sameVariantAs      []ffcontext.Context
+		want               interface{}
+		wantReason         flag.ResolutionReason
+		wantErrorCode      flag.ErrorCode
+		wantBucketingKey   string
+		wantBucketingValue string
+	}{
+		{
+			name:               "all the users of an organization should have the same variation",
+			flag:               percentageFlag,
+			ctx:                orgCtx("user-0"),
+			sameVariantAs:      []ffcontext.Context{orgCtx("user-1"), orgCtx("user-2"), orgCtx("user-3")},
+			wantReason:         flag.ReasonSplit,
+			wantBucketingKey:   "organizationId",
+			wantBucketingValue: "acme-corp",
+		},
+		{
+			name: "rule bucketing key should override the flag bucketing key",
+			flag: percentageFlag,
+			ctx: ffcontext.NewEvaluationContextBuilder("user-1").
+				AddCustom("platform", "mobile").
+				AddCustom("device", map[string]interface{}{"id": 1234}).
+				Build(),
+			wantReason:         flag.ReasonTargetingMatchSplit,
+			wantBucketingKey:   "device.id",
+			wantBucketingValue: "1234",
+		},
+		{
+			name:          "missing bucketing attribute should return an error",
+			flag:          percentageFlag,
+			ctx:           ffcontext.NewEvaluationContext("user-1"),
+			want:          "default-sdk",
+			wantReason:    flag.ReasonError,
+			wantErrorCode: flag.ErrorBucketingKeyMissing,
+		},
+		{
+			name:       "missing bucketing attribute is ignored when no percentage is used",
+			flag:       staticFlag,
+			ctx:        ffcontext.NewEvaluationContext("user-1"),
+			want:       "B",
+			wantReason: flag.ReasonStatic,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flagCtx := flag.Context{DefaultSdkValue: "default-sdk"}
+			got, details := tt.flag.Value("my-flag", tt.ctx, flagCtx)
+			if tt.want != nil {
+				assert.Equal(t, tt.want, got)
+			}
+			assert.Equal(t, tt.wantReason, details.Reason)
+			assert.Equal(t, tt.wantErrorCode, details.ErrorCode)
+			assert.Equal(t, tt.wantBucketingKey, details.BucketingKey)
+			assert.Equal(t, tt.wantBucketingValue, details.BucketingValue)
+			for _, ctx := range tt.sameVariantAs {
+				_, other := tt.flag.Value("my-flag", ctx, flagCtx)
+				assert.Equal(t, details.Variant, other.Variant)
+			}
+		})
+	}
+}
@@ -1,5 +1,12 @@
 package flag
 
+import (
+	"context"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/stickybucketing"
+)
+
 type Context struct {
 	// EvaluationContextEnrichment will be merged with the evaluation context sent during the evaluation.
 	// It is useful to add common attributes to all the evaluation, such as a server version, environment, ...
@@ -11,6 +18,45 @@ type Context struct {
 
 	// DefaultSdkValue is the default value of the SDK when calling the variation.
 	DefaultSdkValue interface{}
+
+	// FlagGetter (optional) is used to retrieve the other flags of the configuration during the evaluation.
+	// It is needed to evaluate the prerequisites of a flag, without it the prerequisites are never fulfilled.
+	FlagGetter func(flagKey string) (Flag, error)
+
+	// EvaluationDate (optional) is the date used to evaluate the flag, it allows to know what an evaluation context
+	// will receive at a specific date (scheduled rollout, progressive rollout, experimentation, time windows ...).
+	// Default: the current date
+	EvaluationDate time.Time
+
+	// StickyBucketingStore (optional) keeps the variation served to an evaluation context by a percentage rule,
+	// so the evaluation context keeps it when the percentages of the flag change.
+	// Default: nil, the variation is computed at each evaluation
+	StickyBucketingStore stickybucketing.Store
+
+	// Context (optional) is used to call the StickyBucketingStore, the calls stop when it is canceled
+	// or when its deadline is exceeded.
+	// Default: context.Background()
+	Context context.Context
+
+	// MembershipLists (optional) are the lists loaded from their own retrievers, used by the inList operator.
+	// Without it, the inList operator never matches.
+	MembershipLists MembershipLists
+}
+
+// GetEvaluationDate returns the date used to evaluate the flag, the current date if not set.
+func (s *Context) GetEvaluationDate() time.Time {
+	if s.EvaluationDate.IsZero() {
+		return time.Now()
+	}
+	return s.EvaluationDate
+}
+
+// GetContext returns the context used to call the StickyBucketingStore, context.Background() if not set.
+func (s *Context) GetContext() context.Context {
+	if s.Context == nil {
+		return context.Background()
+	}
+	return s.Context
 }
 
 func (s *Context) AddIntoEvaluationContextEnrichment(key string, value interface{}) {
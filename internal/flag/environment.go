@@ -0,0 +1,81 @@
+package flag
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+)
+
+// EnvironmentOverride contains the fields of a flag that are different in an environment.
+// The fields not set keep the value of the flag.
+type EnvironmentOverride struct {
+	// Variations (optional) are added to the variations of the flag, a variation with the same name is replaced.
+	Variations *map[string]*interface{} `json:"variations,omitempty" yaml:"variations,omitempty" toml:"variations,omitempty" jsonschema:"title=variations,description=Variations added to the variations of the flag in this environment. A variation with the same name is replaced."` // nolint: lll
+
+	// Rules (optional) replaces all the targeting rules of the flag.
+	Rules *[]Rule `json:"targeting,omitempty" yaml:"targeting,omitempty" toml:"targeting,omitempty" jsonschema:"title=targeting,description=Targeting rules of the flag in this environment. They replace all the rules of the flag."` // nolint: lll
+
+	// DefaultRule (optional) replaces the default rule of the flag.
+	DefaultRule *Rule `json:"defaultRule,omitempty" yaml:"defaultRule,omitempty" toml:"defaultRule,omitempty" jsonschema:"title=defaultRule,description=Default rule of the flag in this environment."` // nolint: lll
+
+	// Disable (optional) replaces the field disable of the flag.
+	Disable *bool `json:"disable,omitempty" yaml:"disable,omitempty" toml:"disable,omitempty" jsonschema:"title=disable,description=True if the flag is disabled in this environment."` // nolint: lll
+}
+
+// ApplyEnvironment resolves the flag for the environment, the override of the environment (if any) is merged into
+// the flag and the overrides of all the environments are removed.
+// It is done once when loading the flag, so the evaluations do not depend on the environment.
+func (f *InternalFlag) ApplyEnvironment(environment string) {
+	if override, ok := f.GetEnvironments()[environment]; ok && environment != "" {
+		f.applyEnvironmentOverride(override)
+	}
+	f.Environments = nil
+}
+
+// applyEnvironmentOverride merges the override of an environment into the flag.
+func (f *InternalFlag) applyEnvironmentOverride(override EnvironmentOverride) {
+	if override.Variations != nil {
+		variations := make(map[string]*interface{}, len(f.GetVariations())+len(*override.Variations))
+		maps.Copy(variations, f.GetVariations())
+		maps.Copy(variations, *override.Variations)
+		f.Variations = &variations
+	}
+	if override.Rules != nil {
+		f.Rules = override.Rules
+	}
+	if override.DefaultRule != nil {
+		f.DefaultRule = override.DefaultRule
+	}
+	if override.Disable != nil {
+		f.Disable = override.Disable
+	}
+}
+
+// isValidEnvironments checks that the flag is valid in all its environments.
+func (f *InternalFlag) isValidEnvironments() error {
+	names := make([]string, 0, len(f.GetEnvironments()))
+	for name := range f.GetEnvironments() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == "" {
+			return fmt.Errorf("an environment should have a name")
+		}
+		flagInEnvironment := *f
+		flagInEnvironment.ApplyEnvironment(name)
+		if err := flagInEnvironment.IsValid(); err != nil {
+			return fmt.Errorf("invalid environment %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// GetEnvironments is the getter of the field Environments
+func (f *InternalFlag) GetEnvironments() map[string]EnvironmentOverride {
+	if f.Environments == nil {
+		return map[string]EnvironmentOverride{}
+	}
+	return *f.Environments
+}
@@ -0,0 +1,147 @@
+package flag_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+func TestInternalFlag_Environments(t *testing.T) {
+	// cachedFlag is the flag as loaded from the configuration, before resolving its environment.
+	cachedFlag := func() flag.InternalFlag {
+		return flag.InternalFlag{
+			Variations: &map[string]*interface{}{
+				"enabled":  testconvert.Interface(true),
+				"disabled": testconvert.Interface(false),
+			},
+			Rules: &[]flag.Rule{
+				{
+					Query:           testconvert.String(`beta eq true`),
+					VariationResult: testconvert.String("enabled"),
+				},
+			},
+			DefaultRule: &flag.Rule{VariationResult: testconvert.String("disabled")},
+			Environments: &map[string]flag.EnvironmentOverride{
+				"staging": {
+					Rules:       &[]flag.Rule{},
+					DefaultRule: &flag.Rule{VariationResult: testconvert.String("enabled")},
+				},
+				"production": {
+					Disable: testconvert.Bool(true),
+				},
+			},
+		}
+	}
+	tests := []struct {
+		name        string
+		environment string
+		want        flag.ResolutionDetails
+	}{
+		{
+			name:        "apply the override of the environment",
+			environment: "staging",
+			want:        flag.ResolutionDetails{Variant: "enabled", Reason: flag.ReasonStatic, Cacheable: true},
+		},
+		{
+			name:        "disable the flag in an environment",
+			environment: "production",
+			want: flag.ResolutionDetails{
+				Variant:   flag.VariationSDKDefault,
+				Reason:    flag.ReasonDisabled,
+				Cacheable: true,
+			},
+		},
+		{
+			name:        "environment without override",
+			environment: "development",
+			want:        flag.ResolutionDetails{Variant: "disabled", Reason: flag.ReasonDefault, Cacheable: true},
+		},
+		{
+			name:        "no environment",
+			environment: "",
+			want:        flag.ResolutionDetails{Variant: "disabled", Reason: flag.ReasonDefault, Cacheable: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cached := cachedFlag()
+			flagInEnvironment := cached
+			flagInEnvironment.ApplyEnvironment(tt.environment)
+			assert.Nil(t, flagInEnvironment.Environments)
+
+			evaluationCtx := ffcontext.NewEvaluationContext("user-1")
+			_, got := flagInEnvironment.Value("my-flag", evaluationCtx, flag.Context{DefaultSdkValue: false})
+			assert.Equal(t, tt.want, got)
+
+			// the flag in the cache is not modified by the evaluation in the environment
+			ignoreUnexported := cmpopts.IgnoreUnexported(flag.InternalFlag{})
+			assert.Empty(t, cmp.Diff(cachedFlag(), cached, ignoreUnexported))
+			_, got = cached.Value("my-flag", evaluationCtx, flag.Context{DefaultSdkValue: false})
+			assert.Equal(t, "disabled", got.Variant)
+		})
+	}
+}
+
+func TestInternalFlag_IsValidEnvironments(t *testing.T) {
+	tests := []struct {
+		name         string
+		environments map[string]flag.EnvironmentOverride
+		environment  string
+		wantErr      string
+	}{
+		{
+			name: "valid environments",
+			environments: map[string]flag.EnvironmentOverride{
+				"staging": {DefaultRule: &flag.Rule{VariationResult: testconvert.String("enabled")}},
+			},
+		},
+		{
+			name: "all the environments are validated",
+			environments: map[string]flag.EnvironmentOverride{
+				"staging":    {DefaultRule: &flag.Rule{VariationResult: testconvert.String("enabled")}},
+				"production": {DefaultRule: &flag.Rule{}},
+			},
+			wantErr: "invalid environment production: impossible to return value",
+		},
+		{
+			name: "an environment should have a name",
+			environments: map[string]flag.EnvironmentOverride{
+				"": {Disable: testconvert.Bool(true)},
+			},
+			wantErr: "an environment should have a name",
+		},
+		{
+			name: "the other environments are not validated once the environment is resolved",
+			environments: map[string]flag.EnvironmentOverride{
+				"staging":    {DefaultRule: &flag.Rule{VariationResult: testconvert.String("enabled")}},
+				"production": {DefaultRule: &flag.Rule{}},
+			},
+			environment: "staging",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := flag.InternalFlag{
+				Variations: &map[string]*interface{}{
+					"enabled":  testconvert.Interface(true),
+					"disabled": testconvert.Interface(false),
+				},
+				DefaultRule:  &flag.Rule{VariationResult: testconvert.String("disabled")},
+				Environments: &tt.environments,
+			}
+			if tt.environment != "" {
+				f.ApplyEnvironment(tt.environment)
+			}
+			if tt.wantErr != "" {
+				assert.EqualError(t, f.IsValid(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, f.IsValid())
+		})
+	}
+}
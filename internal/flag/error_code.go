@@ -17,4 +17,11 @@ const (
 
 	// 	ErrorFlagConfiguration is returned when we were not able to use the flag because of a misconfiguration
 	ErrorFlagConfiguration ErrorCode = "FLAG_CONFIG"
+
+	// ErrorBucketingKeyMissing is returned when the attribute used to bucket the evaluation context is missing
+	ErrorBucketingKeyMissing ErrorCode = "BUCKETING_KEY_MISSING"
+
+	// ErrorTemplateRendering is returned when the template of the variation cannot be rendered
+	// with the attributes of the evaluation context
+	ErrorTemplateRendering ErrorCode = "TEMPLATE_RENDERING"
 )
@@ -0,0 +1,306 @@
+package flag
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/internalerror"
+	"github.com/thomaspoignant/go-feature-flag/internal/query"
+	"github.com/thomaspoignant/go-feature-flag/internal/utils"
+)
+
+// TraceStepType is the kind of check done during the evaluation of a flag.
+type TraceStepType = string
+
+const (
+	// TraceStepScheduledStep is a step of the scheduled rollout of the flag.
+	TraceStepScheduledStep TraceStepType = "SCHEDULED_STEP"
+	// TraceStepDisabled is the check of the disable field of the flag.
+	TraceStepDisabled TraceStepType = "DISABLED"
+	// TraceStepExperimentation is the check of the experimentation dates of the flag.
+	TraceStepExperimentation TraceStepType = "EXPERIMENTATION"
+	// TraceStepTimeWindows is the check of the time windows of the flag.
+	TraceStepTimeWindows TraceStepType = "TIME_WINDOWS"
+	// TraceStepPrerequisites is the check of the prerequisites of the flag.
+	TraceStepPrerequisites TraceStepType = "PREREQUISITES"
+	// TraceStepLayer is the allocation of the evaluation context in the experiment layer of the flag.
+	TraceStepLayer TraceStepType = "LAYER"
+	// TraceStepTargets is the check of the individual targeting lists of the flag.
+	TraceStepTargets TraceStepType = "TARGETS"
+	// TraceStepRule is the evaluation of a rule of the targeting.
+	TraceStepRule TraceStepType = "RULE"
+	// TraceStepRuleOutsideTimeWindow is a rule skipped because the evaluation date is outside its time windows.
+	TraceStepRuleOutsideTimeWindow TraceStepType = "RULE_OUTSIDE_TIME_WINDOW"
+	// TraceStepDefaultRule is the evaluation of the default rule.
+	TraceStepDefaultRule TraceStepType = "DEFAULT_RULE"
+	// TraceStepStickyAssignment is the use of a variation assigned during a previous evaluation.
+	TraceStepStickyAssignment TraceStepType = "STICKY_ASSIGNMENT"
+)
+
+// Explainer is implemented by the flags able to explain how they are evaluated.
+type Explainer interface {
+	// Explain evaluates the flag and returns a step-by-step trace of the evaluation.
+	Explain(flagName string, evaluationCtx ffcontext.Context, flagContext Context,
+	) (interface{}, ResolutionDetails, EvaluationTrace)
+}
+
+// EvaluationTrace is the step-by-step explanation of the evaluation of a flag for an evaluation context.
+type EvaluationTrace struct {
+	// FlagKey is the name of the flag evaluated.
+	FlagKey string `json:"flagKey"`
+
+	// EvaluationDate is the date used to evaluate the flag.
+	EvaluationDate time.Time `json:"evaluationDate"`
+
+	// Steps are all the checks done during the evaluation, in order.
+	Steps []TraceStep `json:"steps"`
+}
+
+// TraceStep is one of the checks done during the evaluation of a flag.
+type TraceStep struct {
+	// Type is the kind of check.
+	Type TraceStepType `json:"type"`
+
+	// Message is a human-readable explanation of the result of the check.
+	Message string `json:"message"`
+
+	// Rule contains the details of the evaluation of a rule, only for the RULE, RULE_OUTSIDE_TIME_WINDOW and DEFAULT_RULE
+	// steps.
+	Rule *RuleTrace `json:"rule,omitempty"`
+}
+
+// RuleTrace contains the details of the evaluation of a rule.
+type RuleTrace struct {
+	// Index is the position of the rule in the targeting, nil for the default rule.
+	Index *int `json:"index,omitempty"`
+
+	// Name is the name of the rule.
+	Name string `json:"name,omitempty"`
+
+	// Query is the query of the rule.
+	Query string `json:"query,omitempty"`
+
+	// QueryFormat is the language of the query.
+	QueryFormat QueryFormat `json:"queryFormat,omitempty"`
+
+	// Segment is the segment the evaluation context should be part of.
+	Segment string `json:"segment,omitempty"`
+
+	// Disabled is true if the rule is disabled.
+	Disabled bool `json:"disabled"`
+
+	// InTimeWindows is false if the evaluation date is outside the time windows of the rule.
+	InTimeWindows bool `json:"inTimeWindows"`
+
+	// Matched is true if the rule applies to the evaluation context.
+	Matched bool `json:"matched"`
+
+	// MissingAttributes are the attributes used by the query (or the segment) that are not
+	// in the evaluation context.
+	MissingAttributes []string `json:"missingAttributes,omitempty"`
+
+	// Variation is the variation served by the rule.
+	Variation string `json:"variation,omitempty"`
+
+	// Bucketing contains the details of the percentage bucketing, only for the rules serving percentages.
+	Bucketing *BucketingTrace `json:"bucketing,omitempty"`
+
+	// Error is the error returned while evaluating the rule.
+	Error string `json:"error,omitempty"`
+}
+
+// BucketingTrace contains the details of how the evaluation context is affected to a percentage bucket.
+// The hash and the boundaries of the buckets are between 0 and MaxPercentage.
+type BucketingTrace struct {
+	// BucketingKey is the attribute used to bucket the evaluation context, empty if the targeting key is used.
+	BucketingKey string `json:"bucketingKey,omitempty"`
+
+	// BucketingValue is the value of the bucketing attribute for the evaluation context.
+	BucketingValue string `json:"bucketingValue,omitempty"`
+
+	// Hash is the bucket value of the evaluation context.
+	Hash uint32 `json:"hash"`
+
+	// Buckets are the boundaries of the bucket of each variation, the start is included and the end excluded.
+	Buckets map[string]BucketBoundaries `json:"buckets,omitempty"`
+}
+
+// BucketBoundaries are the limits of the bucket of a variation.
+type BucketBoundaries struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// Explain evaluates the flag like Value and returns a trace of all the checks done during the evaluation.
+// The trace is recorded by the evaluation itself, so it always explains the value returned.
+// The evaluation has no side effect: the evaluation context is not enriched and the sticky assignments
+// are read but never stored.
+// It is more expensive than Value and should only be used to understand the result of an evaluation.
+func (f *InternalFlag) Explain(
+	flagName string,
+	evaluationCtx ffcontext.Context,
+	flagContext Context,
+) (interface{}, ResolutionDetails, EvaluationTrace) {
+	evaluationDate := flagContext.GetEvaluationDate()
+	flagContext.EvaluationDate = evaluationDate
+	trace := EvaluationTrace{FlagKey: flagName, EvaluationDate: evaluationDate, Steps: make([]TraceStep, 0)}
+
+	for _, step := range f.GetScheduled() {
+		if step.Date == nil {
+			continue
+		}
+		message := fmt.Sprintf("scheduled step of %s is not applied yet", step.Date.Format(time.RFC3339))
+		if step.Date.Before(evaluationDate) {
+			message = fmt.Sprintf("scheduled step of %s is applied", step.Date.Format(time.RFC3339))
+		}
+		trace.add(TraceStepScheduledStep, message, nil)
+	}
+	value, resolutionDetails := f.GetFlagAt(evaluationDate).evaluate(
+		flagName, ffcontext.Clone(evaluationCtx), flagContext, evaluationDate, &trace)
+	return value, resolutionDetails, trace
+}
+
+// message returns a human-readable explanation of the allocation of the evaluation context in the layer.
+func (l layerSelection) message() string {
+	switch {
+	case l.allocated:
+		return fmt.Sprintf("the evaluation context is allocated to this flag in the layer %s", l.layer)
+	case l.slice == HoldoutSlice:
+		return "the evaluation context is part of the global holdout, serving the SDK default value"
+	case l.slice == "":
+		return fmt.Sprintf("the evaluation context is not allocated to any flag of the layer %s, "+
+			"serving the SDK default value", l.layer)
+	default:
+		return fmt.Sprintf("the evaluation context is allocated to the flag %s in the layer %s, "+
+			"serving the SDK default value", l.slice, l.layer)
+	}
+}
+
+// add appends a step to the trace, nothing is done if the evaluation is not explained (nil trace).
+func (t *EvaluationTrace) add(stepType TraceStepType, message string, rule *RuleTrace) {
+	if t == nil {
+		return
+	}
+	t.Steps = append(t.Steps, TraceStep{Type: stepType, Message: message, Rule: rule})
+}
+
+// addRule appends the result of the evaluation of a rule to the trace, ruleIndex is nil for the default rule.
+// The details are built from the result of Rule.Evaluate, the queries are not evaluated again.
+func (t *EvaluationTrace) addRule(
+	f *InternalFlag,
+	rule *Rule,
+	ruleIndex *int,
+	ctx ffcontext.Context,
+	bucket bucket,
+	bucketErr error,
+	variation string,
+	err error,
+	evaluationDate time.Time,
+) {
+	if t == nil {
+		return
+	}
+	_, notApply := err.(*internalerror.RuleNotApply)
+	_, outsideTimeWindow := err.(*internalerror.RuleOutsideTimeWindow)
+	notApply = notApply || outsideTimeWindow
+	ruleTrace := RuleTrace{Index: ruleIndex, Name: rule.GetName(), InTimeWindows: true, Matched: !notApply}
+	if ruleIndex != nil {
+		ruleTrace.Query = rule.GetQuery()
+		ruleTrace.QueryFormat = rule.GetQueryFormat()
+		ruleTrace.Segment = rule.GetSegment()
+		ruleTrace.Disabled = rule.IsDisable()
+		ruleTrace.InTimeWindows = isInTimeWindows(rule.GetTimeWindows(), evaluationDate)
+		ruleTrace.MissingAttributes = query.MissingAttributes(f.compiledRule(rule).attributes, utils.ContextToMap(ctx))
+	}
+
+	stepType := TraceStepRule
+	switch {
+	case ruleIndex == nil:
+		stepType = TraceStepDefaultRule
+	case outsideTimeWindow:
+		stepType = TraceStepRuleOutsideTimeWindow
+	}
+	if !ruleTrace.Matched {
+		t.add(stepType, ruleTrace.message(), &ruleTrace)
+		return
+	}
+
+	if rule.ProgressiveRollout != nil || len(rule.GetPercentages()) > 0 {
+		ruleTrace.Bucketing = rule.explainBucketing(bucket, evaluationDate)
+	}
+	switch {
+	case err != nil:
+		ruleTrace.Error = err.Error()
+	case bucketErr != nil && rule.IsDynamic():
+		ruleTrace.Error = bucketErr.Error()
+	default:
+		ruleTrace.Variation = variation
+	}
+	t.add(stepType, ruleTrace.message(), &ruleTrace)
+}
+
+// message returns a human-readable explanation of the evaluation of the rule.
+func (r *RuleTrace) message() string {
+	name := "the default rule"
+	if r.Index != nil {
+		name = fmt.Sprintf("rule #%d", *r.Index)
+		if r.Name != "" {
+			name = fmt.Sprintf("rule #%d (%s)", *r.Index, r.Name)
+		}
+	}
+
+	switch {
+	case r.Disabled:
+		return fmt.Sprintf("%s is disabled", name)
+	case !r.InTimeWindows:
+		return fmt.Sprintf("%s does not apply, the evaluation date is outside its time windows", name)
+	case !r.Matched && len(r.MissingAttributes) > 0:
+		return fmt.Sprintf("%s does not match the evaluation context, missing attributes: %v", name, r.MissingAttributes)
+	case !r.Matched:
+		return fmt.Sprintf("%s does not match the evaluation context", name)
+	case r.Error != "":
+		return fmt.Sprintf("%s matches but cannot be evaluated: %s", name, r.Error)
+	case r.Bucketing != nil:
+		return fmt.Sprintf("%s matches, the bucket value %d serves the variation %s",
+			name, r.Bucketing.Hash, r.Variation)
+	default:
+		return fmt.Sprintf("%s matches, serving the variation %s", name, r.Variation)
+	}
+}
+
+// explainBucketing returns the details of how the evaluation context is affected to a percentage bucket.
+func (r *Rule) explainBucketing(b bucket, evaluationDate time.Time) *BucketingTrace {
+	bucketingTrace := &BucketingTrace{
+		BucketingKey:   b.key,
+		BucketingValue: b.value,
+		Hash:           b.hashID,
+		Buckets:        make(map[string]BucketBoundaries),
+	}
+
+	if r.ProgressiveRollout != nil {
+		rollout := r.GetProgressiveRollout()
+		if rollout.Initial == nil || rollout.End == nil {
+			return bucketingTrace
+		}
+		current := float64(0)
+		if rollout.Initial.Date == nil || !evaluationDate.Before(*rollout.Initial.Date) {
+			current = rollout.getPercentageAt(evaluationDate) * PercentageMultiplier
+		}
+		bucketingTrace.Buckets[rollout.Initial.getVariation()] = BucketBoundaries{
+			Start: current,
+			End:   float64(MaxPercentage),
+		}
+		bucketingTrace.Buckets[rollout.End.getVariation()] = BucketBoundaries{Start: 0, End: current}
+		return bucketingTrace
+	}
+
+	buckets, err := r.getPercentageBuckets()
+	if err != nil {
+		return bucketingTrace
+	}
+	for variation, percentageBucket := range buckets {
+		bucketingTrace.Buckets[variation] = BucketBoundaries{Start: percentageBucket.start, End: percentageBucket.end}
+	}
+	return bucketingTrace
+}
@@ -0,0 +1,121 @@
+package flag_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/stickybucketing/inmemorystore"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+func TestInternalFlag_Explain(t *testing.T) {
+	f := flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"A": testconvert.Interface("A"),
+			"B": testconvert.Interface("B"),
+			"C": testconvert.Interface("C"),
+		},
+		Rules: &[]flag.Rule{
+			{
+				Name:            testconvert.String("disabled rule"),
+				Query:           testconvert.String(`key eq "user-1"`),
+				VariationResult: testconvert.String("C"),
+				Disable:         testconvert.Bool(true),
+			},
+			{
+				Name:            testconvert.String("enterprise"),
+				Query:           testconvert.String(`company.plan eq "enterprise" and country eq "FR"`),
+				VariationResult: testconvert.String("C"),
+			},
+			{
+				Name:        testconvert.String("beta"),
+				Query:       testconvert.String(`beta eq true`),
+				Percentages: &map[string]float64{"A": 40, "B": 60},
+			},
+		},
+		DefaultRule: &flag.Rule{VariationResult: testconvert.String("C")},
+		Scheduled: &[]flag.ScheduledStep{
+			{
+				InternalFlag: flag.InternalFlag{Version: testconvert.String("2")},
+				Date:         testconvert.Time(time.Now().Add(-1 * time.Hour)),
+			},
+		},
+	}
+
+	ctx := ffcontext.NewEvaluationContextBuilder("user-1").
+		AddCustom("company", map[string]interface{}{"plan": "enterprise"}).
+		AddCustom("beta", true).
+		Build()
+	value, resolutionDetails, trace := f.Explain("my-flag", ctx, flag.Context{DefaultSdkValue: "default"})
+
+	assert.Equal(t, "my-flag", trace.FlagKey)
+	assert.Equal(t, resolutionDetails.Variant, value)
+	assert.Equal(t, flag.ReasonTargetingMatchSplit, resolutionDetails.Reason)
+
+	stepTypes := make([]string, 0, len(trace.Steps))
+	for _, step := range trace.Steps {
+		stepTypes = append(stepTypes, step.Type)
+	}
+	assert.Equal(t, []string{
+		flag.TraceStepScheduledStep,
+		flag.TraceStepDisabled,
+		flag.TraceStepRule,
+		flag.TraceStepRule,
+		flag.TraceStepRule,
+	}, stepTypes)
+
+	disabledRule := trace.Steps[2].Rule
+	assert.True(t, disabledRule.Disabled)
+	assert.False(t, disabledRule.Matched)
+
+	enterpriseRule := trace.Steps[3].Rule
+	assert.False(t, enterpriseRule.Matched)
+	assert.Equal(t, []string{"country"}, enterpriseRule.MissingAttributes)
+
+	betaRule := trace.Steps[4].Rule
+	assert.True(t, betaRule.Matched)
+	assert.Equal(t, resolutionDetails.Variant, betaRule.Variation)
+	assert.Equal(t, map[string]flag.BucketBoundaries{
+		"B": {Start: 0, End: 60000},
+		"A": {Start: 60000, End: 100000},
+	}, betaRule.Bucketing.Buckets)
+	bucket := betaRule.Bucketing.Buckets[betaRule.Variation]
+	assert.True(t, float64(betaRule.Bucketing.Hash) >= bucket.Start && float64(betaRule.Bucketing.Hash) < bucket.End)
+}
+
+func TestInternalFlag_ExplainWithoutSideEffect(t *testing.T) {
+	f := flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"A": testconvert.Interface("A"),
+			"B": testconvert.Interface("B"),
+		},
+		DefaultRule: &flag.Rule{Percentages: &map[string]float64{"A": 50, "B": 50}},
+	}
+	store := &inmemorystore.Store{}
+	ctx := ffcontext.NewEvaluationContext("user-1")
+	flagContext := flag.Context{
+		DefaultSdkValue:             "default",
+		StickyBucketingStore:        store,
+		EvaluationContextEnrichment: map[string]interface{}{"env": "prod"},
+	}
+
+	value, resolutionDetails, trace := f.Explain("my-flag", ctx, flagContext)
+	assert.Equal(t, resolutionDetails.Variant, value)
+	lastStep := trace.Steps[len(trace.Steps)-1]
+	assert.Equal(t, flag.TraceStepDefaultRule, lastStep.Type)
+	assert.Equal(t, resolutionDetails.Variant, lastStep.Rule.Variation, "the trace should explain the value served")
+	assert.NotContains(t, ctx.GetCustom(), "env", "the evaluation context should not be enriched")
+	_, assigned, _ := store.Get(context.Background(), "my-flag", "user-1")
+	assert.False(t, assigned, "the variation should not be stored in the sticky bucketing store")
+
+	// an existing assignment is explained
+	assert.NoError(t, store.Set(context.Background(), "my-flag", "user-1", "B"))
+	value, resolutionDetails, trace = f.Explain("my-flag", ctx, flagContext)
+	assert.Equal(t, "B", value)
+	assert.True(t, resolutionDetails.StickyAssignment)
+	assert.Equal(t, flag.TraceStepStickyAssignment, trace.Steps[len(trace.Steps)-1].Type)
+}
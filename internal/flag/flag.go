@@ -23,6 +23,9 @@ type Flag interface {
 	// GetVariationValue return the value of variation from his name
 	GetVariationValue(name string) interface{}
 
+	// GetType return the declared type of the values of the flag, empty if the type is not declared
+	GetType() string
+
 	// GetMetadata return the metadata associated to the flag
 	GetMetadata() map[string]interface{}
 }
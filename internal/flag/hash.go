@@ -0,0 +1,72 @@
+package flag
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/thomaspoignant/go-feature-flag/internal/utils"
+)
+
+// HashAlgorithm is the algorithm used to hash the bucketing value of an evaluation context.
+type HashAlgorithm = string
+
+const (
+	// HashAlgorithmFNV is the default algorithm, a 32 bits FNV-1a hash.
+	HashAlgorithmFNV HashAlgorithm = "fnv"
+	// HashAlgorithmMurmur3 is the 32 bits MurmurHash3, used by flagd and most of the feature flag vendors.
+	HashAlgorithmMurmur3 HashAlgorithm = "murmur3"
+	// HashAlgorithmSHA1 uses the first 4 bytes of the SHA-1 of the bucketing value.
+	HashAlgorithmSHA1 HashAlgorithm = "sha1"
+)
+
+// bucketFunctions contains the function returning the bucket of a string, between 0 and MaxPercentage,
+// for each supported algorithm.
+var bucketFunctions = map[HashAlgorithm]func(s string) uint32{
+	HashAlgorithmFNV:     moduloBucket(utils.Hash),
+	HashAlgorithmMurmur3: murmur3Bucket,
+	HashAlgorithmSHA1:    moduloBucket(utils.SHA1Hash),
+}
+
+// moduloBucket returns a function computing the bucket as the hash of the string modulo MaxPercentage.
+func moduloBucket(hash func(s string) uint32) func(s string) uint32 {
+	return func(s string) uint32 {
+		return hash(s) % MaxPercentage
+	}
+}
+
+// murmur3Bucket computes the bucket like the flagd fractional operator: the absolute value of the hash read as
+// a signed 32 bits integer, divided by math.MaxInt32, is the ratio of MaxPercentage of the bucket.
+// An evaluation context is in the same percentage bucket as with flagd for the same salted bucketing value.
+func murmur3Bucket(s string) uint32 {
+	ratio := math.Abs(float64(int32(utils.Murmur3Hash(s)))) / math.MaxInt32
+	// math.MinInt32 gives a ratio slightly above 1, it is in the last bucket.
+	return min(uint32(ratio*float64(MaxPercentage)), MaxPercentage-1)
+}
+
+// isValidHashAlgorithm checks if the hash algorithm is supported.
+func isValidHashAlgorithm(algorithm HashAlgorithm) error {
+	if _, ok := bucketFunctions[algorithm]; !ok {
+		return fmt.Errorf("unknown hash algorithm: %s", algorithm)
+	}
+	return nil
+}
+
+// hash returns the bucket of the bucketing value for this flag, between 0 and MaxPercentage.
+// The bucketing value is salted with the seed of the flag, or with the flag name if no seed is configured.
+func (f *InternalFlag) hash(flagName string, bucketingValue string) uint32 {
+	salt := flagName
+	if f.Seed != nil {
+		salt = f.GetSeed()
+	}
+	return hashBucket(f.GetHashAlgorithm(), salt, bucketingValue)
+}
+
+// hashBucket returns the bucket of the salted bucketing value with the hash algorithm, between 0 and MaxPercentage.
+// An unknown algorithm falls back to HashAlgorithmFNV.
+func hashBucket(algorithm HashAlgorithm, salt string, bucketingValue string) uint32 {
+	bucketFunction, ok := bucketFunctions[algorithm]
+	if !ok {
+		bucketFunction = bucketFunctions[HashAlgorithmFNV]
+	}
+	return bucketFunction(salt + bucketingValue)
+}
@@ -0,0 +1,155 @@
+package flag_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/internal/utils"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+func TestInternalFlag_HashSeedAndAlgorithm(t *testing.T) {
+	tests := []struct {
+		name          string
+		seed          *string
+		hashAlgorithm *string
+		want          uint32
+	}{
+		{
+			name: "default hash uses the flag name",
+			want: utils.Hash("my-flag"+"user-1") % flag.MaxPercentage,
+		},
+		{
+			name: "seed replaces the flag name",
+			seed: testconvert.String("experiment-2024"),
+			want: utils.Hash("experiment-2024"+"user-1") % flag.MaxPercentage,
+		},
+		{
+			// murmur3("my-flaguser-1") is 0x442a3cef, 1143618799 / math.MaxInt32 * flag.MaxPercentage
+			name:          "murmur3 algorithm uses the flagd ratio",
+			hashAlgorithm: testconvert.String(flag.HashAlgorithmMurmur3),
+			want:          53253,
+		},
+		{
+			// murmur3("experiment-2024user-1") is 0xff2352a7, read as the signed integer -14462297
+			name:          "murmur3 algorithm with a negative hash",
+			seed:          testconvert.String("experiment-2024"),
+			hashAlgorithm: testconvert.String(flag.HashAlgorithmMurmur3),
+			want:          673,
+		},
+		{
+			name:          "sha1 algorithm with a seed",
+			seed:          testconvert.String("experiment-2024"),
+			hashAlgorithm: testconvert.String(flag.HashAlgorithmSHA1),
+			want:          utils.SHA1Hash("experiment-2024"+"user-1") % flag.MaxPercentage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := flag.InternalFlag{
+				Variations: &map[string]*interface{}{
+					"A": testconvert.Interface("A"),
+					"B": testconvert.Interface("B"),
+				},
+				DefaultRule:   &flag.Rule{Percentages: &map[string]float64{"A": 50, "B": 50}},
+				Seed:          tt.seed,
+				HashAlgorithm: tt.hashAlgorithm,
+			}
+			assert.NoError(t, f.IsValid())
+			hash := func(flagName string) uint32 {
+				_, _, trace := f.Explain(
+					flagName, ffcontext.NewEvaluationContext("user-1"), flag.Context{DefaultSdkValue: "default"})
+				lastStep := trace.Steps[len(trace.Steps)-1]
+				assert.Equal(t, flag.TraceStepDefaultRule, lastStep.Type)
+				return lastStep.Rule.Bucketing.Hash
+			}
+			assert.Equal(t, tt.want, hash("my-flag"))
+			if tt.seed != nil {
+				// flags sharing the same seed put an evaluation context in the same bucket
+				assert.Equal(t, tt.want, hash("other-flag"))
+			}
+		})
+	}
+
+}
+
+// TestInternalFlag_Murmur3FlagdParity checks that the murmur3 algorithm puts the evaluation contexts in the same
+// buckets as the flagd fractional operator (the expected buckets come from the flagd test suite).
+func TestInternalFlag_Murmur3FlagdParity(t *testing.T) {
+	// flagd buckets: ["red", 25], ["blue", 25], ["green", 25], ["yellow", 25]
+	flagdBuckets := map[string][2]uint32{
+		"red":    {0, 25000},
+		"blue":   {25000, 50000},
+		"green":  {50000, 75000},
+		"yellow": {75000, flag.MaxPercentage},
+	}
+	tests := []struct {
+		email      string
+		wantBucket string
+	}{
+		{email: "rachel@faas.com", wantBucket: "yellow"},
+		{email: "monica@faas.com", wantBucket: "blue"},
+		{email: "joey@faas.com", wantBucket: "red"},
+		{email: "ross@faas.com", wantBucket: "green"},
+	}
+	f := flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"A": testconvert.Interface("A"),
+			"B": testconvert.Interface("B"),
+		},
+		DefaultRule:   &flag.Rule{Percentages: &map[string]float64{"A": 50, "B": 50}},
+		BucketingKey:  testconvert.String("email"),
+		HashAlgorithm: testconvert.String(flag.HashAlgorithmMurmur3),
+	}
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			ctx := ffcontext.NewEvaluationContextBuilder("user-1").AddCustom("email", tt.email).Build()
+			_, _, trace := f.Explain("headerColor", ctx, flag.Context{DefaultSdkValue: "default"})
+			lastStep := trace.Steps[len(trace.Steps)-1]
+			assert.Equal(t, flag.TraceStepDefaultRule, lastStep.Type)
+			hash := lastStep.Rule.Bucketing.Hash
+			assert.GreaterOrEqual(t, hash, flagdBuckets[tt.wantBucket][0])
+			assert.Less(t, hash, flagdBuckets[tt.wantBucket][1])
+		})
+	}
+}
+
+func TestInternalFlag_IsValidHashAlgorithm(t *testing.T) {
+	tests := []struct {
+		name          string
+		hashAlgorithm *string
+		wantErr       string
+	}{
+		{
+			name: "default hash algorithm",
+		},
+		{
+			name:          "murmur3 algorithm",
+			hashAlgorithm: testconvert.String(flag.HashAlgorithmMurmur3),
+		},
+		{
+			name:          "unknown hash algorithm",
+			hashAlgorithm: testconvert.String("md5"),
+			wantErr:       "unknown hash algorithm: md5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := flag.InternalFlag{
+				Variations: &map[string]*interface{}{
+					"A": testconvert.Interface("A"),
+					"B": testconvert.Interface("B"),
+				},
+				DefaultRule:   &flag.Rule{VariationResult: testconvert.String("A")},
+				HashAlgorithm: tt.hashAlgorithm,
+			}
+			if tt.wantErr != "" {
+				assert.EqualError(t, f.IsValid(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, f.IsValid())
+		})
+	}
+}
@@ -4,9 +4,14 @@ import (
 	"fmt"
 	"github.com/thomaspoignant/go-feature-flag/ffcontext"
 	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/thomaspoignant/go-feature-flag/internal/internalerror"
+	"github.com/thomaspoignant/go-feature-flag/internal/schema"
 	"github.com/thomaspoignant/go-feature-flag/internal/utils"
 )
 
@@ -22,10 +27,21 @@ type InternalFlag struct {
 	// Variations are all the variations available for this flag. You can have as many variation as needed.
 	Variations *map[string]*interface{} `json:"variations,omitempty" yaml:"variations,omitempty" toml:"variations,omitempty"` // nolint:lll
 
+	// Type (optional) is the declared type of the values of the flag (bool, string, int, float or json).
+	// All the variations are checked against this type when loading the flag.
+	Type *string `json:"type,omitempty" yaml:"type,omitempty" toml:"type,omitempty" render:"omitnil"`
+
+	// Schema (optional) is a JSON schema that all the variations of the flag should follow.
+	Schema *map[string]interface{} `json:"schema,omitempty" yaml:"schema,omitempty" toml:"schema,omitempty" render:"omitnil"` // nolint: lll
+
 	// Rules is the list of Rule for this flag.
 	// This an optional field.
 	Rules *[]Rule `json:"targeting,omitempty" yaml:"targeting,omitempty" toml:"targeting,omitempty"`
 
+	// Targets (optional) are lists of values of an attribute of the evaluation context that always receive
+	// a specific variation. They are checked before the Rules.
+	Targets *[]Target `json:"targets,omitempty" yaml:"targets,omitempty" toml:"targets,omitempty" render:"omitnil"`
+
 	// DefaultRule is the originalRule applied after checking that any other rules
 	// matched the user.
 	DefaultRule *Rule `json:"defaultRule,omitempty" yaml:"defaultRule,omitempty" toml:"defaultRule,omitempty"`
@@ -35,6 +51,14 @@ type InternalFlag struct {
 	// When the experimentation is not running, the flag will serve the default value.
 	Experimentation *ExperimentationRollout `json:"experimentation,omitempty" yaml:"experimentation,omitempty" toml:"experimentation,omitempty"` // nolint: lll
 
+	// TimeWindows (optional) is a list of recurring periods of time where the flag is active.
+	// Outside of these windows, the flag will serve the default value.
+	TimeWindows *[]TimeWindow `json:"timeWindows,omitempty" yaml:"timeWindows,omitempty" toml:"timeWindows,omitempty" render:"omitnil"` // nolint: lll
+
+	// Environments (optional) contains the overrides of the flag for each environment, indexed by the name of the
+	// environment. The override of the configured environment is applied when loading the flag.
+	Environments *map[string]EnvironmentOverride `json:"environments,omitempty" yaml:"environments,omitempty" toml:"environments,omitempty" render:"omitnil"` // nolint: lll
+
 	// Scheduled is your struct to configure an update on some fields of your flag over time.
 	// You can add several steps that updates the flag, this is typically used if you want to gradually add more user
 	// in your flag.
@@ -54,6 +78,58 @@ type InternalFlag struct {
 
 	// Metadata is a field containing information about your flag such as an issue tracker link, a description, etc ...
 	Metadata *map[string]interface{} `json:"metadata,omitempty" yaml:"metadata,omitempty" toml:"metadata,omitempty"`
+
+	// BucketingKey (optional) is the attribute of the evaluation context used to affect a user to a percentage
+	// bucket (ex: "organizationId" to serve the same variation to all the users of an organization).
+	// It can be overridden in each rule.
+	// Default: the targeting key of the evaluation context.
+	BucketingKey *string `json:"bucketingKey,omitempty" yaml:"bucketingKey,omitempty" toml:"bucketingKey,omitempty" render:"omitnil"` // nolint: lll
+
+	// Seed (optional) is the salt added to the bucketing value before hashing it.
+	// Changing it reshuffles the evaluation contexts in the percentage buckets,
+	// and flags sharing the same seed put an evaluation context in the same bucket.
+	// Default: the name of the flag.
+	Seed *string `json:"seed,omitempty" yaml:"seed,omitempty" toml:"seed,omitempty" render:"omitnil"`
+
+	// HashAlgorithm (optional) is the algorithm used to hash the bucketing value (fnv, murmur3 or sha1).
+	// Default: fnv
+	HashAlgorithm *HashAlgorithm `json:"hashAlgorithm,omitempty" yaml:"hashAlgorithm,omitempty" toml:"hashAlgorithm,omitempty" render:"omitnil"` // nolint: lll
+
+	// Prerequisites (optional) is the list of flags that should resolve to a specific variation for the same
+	// evaluation context before evaluating the targeting of this flag.
+	Prerequisites *[]Prerequisite `json:"prerequisites,omitempty" yaml:"prerequisites,omitempty" toml:"prerequisites,omitempty" render:"omitnil"` // nolint: lll
+
+	// FallbackVariation (optional) is the variation served when one of the prerequisites is not fulfilled.
+	// If not set, the SDK default value is served.
+	FallbackVariation *string `json:"fallbackVariation,omitempty" yaml:"fallbackVariation,omitempty" toml:"fallbackVariation,omitempty" render:"omitnil"` // nolint: lll
+
+	// TemplatedVariations (optional) is true if the string values of the variations are templates rendered
+	// with the attributes of the evaluation context (ex: "Hello {{ .name }}").
+	// Default: false, the values are served as they are.
+	TemplatedVariations *bool `json:"templatedVariations,omitempty" yaml:"templatedVariations,omitempty" toml:"templatedVariations,omitempty" render:"omitnil"` // nolint: lll
+
+	// Layer (optional) is the experiment layer of this flag and the global holdout.
+	// They are defined at the top level of the configuration file and linked to the flag when loading it.
+	Layer *LayerAllocation `json:"layer,omitempty" yaml:"layer,omitempty" toml:"layer,omitempty" render:"omitnil"`
+
+	// Segments contains the segments referenced by the rules of this flag.
+	// They are defined at the top level of the configuration file and linked to the flag when loading it.
+	Segments *map[string]Segment `json:"segments,omitempty" yaml:"segments,omitempty" toml:"segments,omitempty" render:"omitnil"` // nolint: lll
+
+	// templates contains the parsed templates of the variations indexed by their source,
+	// they are parsed when loading the flag.
+	templates map[string]*template.Template `diff:"-" render:"omitnil"`
+
+	// compiled contains the queries of the rules ready to be evaluated, they are compiled when loading the flag.
+	compiled *compiledRules `diff:"-" render:"omitnil"`
+
+	// targetIndex contains the values of the targets indexed for a constant time lookup, they are indexed when
+	// loading the flag.
+	targetIndex targetIndex `diff:"-" render:"omitnil"`
+
+	// snapshots contains the flag as it is after each date of the scheduled rollout sorted by date,
+	// they are resolved when loading the flag.
+	snapshots []scheduledSnapshot `diff:"-" render:"omitnil"`
 }
 
 // Value is returning the Value associate to the flag
@@ -62,13 +138,25 @@ func (f *InternalFlag) Value(
 	evaluationCtx ffcontext.Context,
 	flagContext Context,
 ) (interface{}, ResolutionDetails) {
-	f.applyScheduledRolloutSteps()
+	evaluationDate := flagContext.GetEvaluationDate()
+	return f.GetFlagAt(evaluationDate).evaluate(flagName, evaluationCtx, flagContext, evaluationDate, nil)
+}
 
+// evaluate is returning the value of the flag at the evaluation date,
+// the scheduled steps should already be applied to the flag.
+// If trace is not nil, the checks done are recorded in it and the sticky assignments are not modified.
+func (f *InternalFlag) evaluate(
+	flagName string,
+	evaluationCtx ffcontext.Context,
+	flagContext Context,
+	evaluationDate time.Time,
+	trace *EvaluationTrace,
+) (interface{}, ResolutionDetails) {
 	if flagContext.EvaluationContextEnrichment != nil {
 		maps.Copy(evaluationCtx.GetCustom(), flagContext.EvaluationContextEnrichment)
 	}
 
-	if f.IsDisable() || f.isExperimentationOver() {
+	if !f.isAvailable(evaluationDate, trace) {
 		return flagContext.DefaultSdkValue, ResolutionDetails{
 			Variant:   VariationSDKDefault,
 			Reason:    ReasonDisabled,
@@ -77,27 +165,195 @@ func (f *InternalFlag) Value(
 		}
 	}
 
-	variationSelection, err := f.selectVariation(flagName, evaluationCtx)
+	if len(f.GetTimeWindows()) > 0 {
+		if !isInTimeWindows(f.GetTimeWindows(), evaluationDate) {
+			trace.add(TraceStepTimeWindows,
+				"the evaluation date is outside the time windows of the flag, serving the SDK default value", nil)
+			return flagContext.DefaultSdkValue, ResolutionDetails{
+				Variant:   VariationSDKDefault,
+				Reason:    ReasonOutsideTimeWindow,
+				Cacheable: false,
+				Metadata:  f.GetMetadata(),
+			}
+		}
+		trace.add(TraceStepTimeWindows, "the evaluation date is inside the time windows of the flag", nil)
+	}
+
+	prerequisitesMet, prerequisitesCacheable := f.checkPrerequisites(flagName, evaluationCtx, flagContext, trace)
+	if !prerequisitesMet {
+		return f.prerequisiteFailedValue(evaluationCtx, flagContext, trace)
+	}
+
+	layer := layerSelection{allocated: true}
+	if f.Layer != nil {
+		layer = f.Layer.selectSlice(flagName, evaluationCtx)
+		trace.add(TraceStepLayer, layer.message(), nil)
+		if !layer.allocated {
+			return flagContext.DefaultSdkValue, ResolutionDetails{
+				Variant:    VariationSDKDefault,
+				Reason:     ReasonNotAllocated,
+				Cacheable:  f.isCacheable(),
+				Metadata:   f.GetMetadata(),
+				Layer:      layer.layer,
+				LayerSlice: layer.slice,
+			}
+		}
+	}
+
+	variationSelection, err := f.selectVariation(flagName, evaluationCtx, evaluationDate, flagContext, trace)
 	if err != nil {
+		errorCode := ErrorFlagConfiguration
+		if _, ok := err.(*internalerror.BucketingKeyMissing); ok {
+			errorCode = ErrorBucketingKeyMissing
+		}
 		return flagContext.DefaultSdkValue,
 			ResolutionDetails{
 				Variant:   VariationSDKDefault,
 				Reason:    ReasonError,
-				ErrorCode: ErrorFlagConfiguration,
+				ErrorCode: errorCode,
 				Metadata:  f.GetMetadata(),
 			}
 	}
 
-	return f.GetVariationValue(variationSelection.name), ResolutionDetails{
-		Variant:   variationSelection.name,
-		Reason:    variationSelection.reason,
-		RuleIndex: variationSelection.ruleIndex,
-		RuleName:  variationSelection.ruleName,
-		Cacheable: variationSelection.cacheable,
+	value, templated, err := f.renderVariationValue(variationSelection.name, evaluationCtx)
+	if err != nil {
+		return flagContext.DefaultSdkValue, f.templateRenderingError()
+	}
+
+	return value, ResolutionDetails{
+		Variant:          variationSelection.name,
+		Reason:           variationSelection.reason,
+		RuleIndex:        variationSelection.ruleIndex,
+		RuleName:         variationSelection.ruleName,
+		TargetName:       variationSelection.targetName,
+		Cacheable:        variationSelection.cacheable && prerequisitesCacheable && !templated,
+		Metadata:         f.GetMetadata(),
+		BucketingKey:     variationSelection.bucketingKey,
+		BucketingValue:   variationSelection.bucketingValue,
+		StickyAssignment: variationSelection.sticky,
+		Layer:            layer.layer,
+		LayerSlice:       layer.slice,
+	}
+}
+
+// isAvailable checks that the flag is enabled and that its experimentation is running at the evaluation date.
+func (f *InternalFlag) isAvailable(evaluationDate time.Time, trace *EvaluationTrace) bool {
+	if f.IsDisable() {
+		trace.add(TraceStepDisabled, "the flag is disabled, serving the SDK default value", nil)
+		return false
+	}
+	trace.add(TraceStepDisabled, "the flag is enabled", nil)
+
+	if f.Experimentation == nil {
+		return true
+	}
+	if f.isExperimentationOver(evaluationDate) {
+		trace.add(TraceStepExperimentation, "the experimentation is not running, serving the SDK default value", nil)
+		return false
+	}
+	trace.add(TraceStepExperimentation, "the experimentation is running", nil)
+	return true
+}
+
+// prerequisiteFailedValue is the result of an evaluation when one of the prerequisites is not fulfilled,
+// the fallback variation is served if the flag has one.
+func (f *InternalFlag) prerequisiteFailedValue(
+	evaluationCtx ffcontext.Context,
+	flagContext Context,
+	trace *EvaluationTrace,
+) (interface{}, ResolutionDetails) {
+	if f.FallbackVariation == nil {
+		trace.add(TraceStepPrerequisites,
+			fmt.Sprintf("at least one prerequisite is not fulfilled, serving the variation %s", VariationSDKDefault), nil)
+		return flagContext.DefaultSdkValue, ResolutionDetails{
+			Variant:   VariationSDKDefault,
+			Reason:    ReasonPrerequisiteFailed,
+			Cacheable: false,
+			Metadata:  f.GetMetadata(),
+		}
+	}
+	trace.add(TraceStepPrerequisites,
+		fmt.Sprintf("at least one prerequisite is not fulfilled, serving the variation %s", f.GetFallbackVariation()),
+		nil)
+	value, _, err := f.renderVariationValue(f.GetFallbackVariation(), evaluationCtx)
+	if err != nil {
+		return flagContext.DefaultSdkValue, f.templateRenderingError()
+	}
+	return value, ResolutionDetails{
+		Variant:   f.GetFallbackVariation(),
+		Reason:    ReasonPrerequisiteFailed,
+		Cacheable: false,
 		Metadata:  f.GetMetadata(),
 	}
 }
 
+// templateRenderingError is the result of an evaluation when the template of the variation cannot be rendered.
+func (f *InternalFlag) templateRenderingError() ResolutionDetails {
+	return ResolutionDetails{
+		Variant:   VariationSDKDefault,
+		Reason:    ReasonError,
+		ErrorCode: ErrorTemplateRendering,
+		Cacheable: false,
+		Metadata:  f.GetMetadata(),
+	}
+}
+
+// checkPrerequisites is evaluating the prerequisite flags for the evaluation context.
+// It returns if all the prerequisites are fulfilled and if their results can be cached.
+// A prerequisite flag that does not exist or that cannot be evaluated is considered as not fulfilled.
+func (f *InternalFlag) checkPrerequisites(
+	flagName string,
+	evaluationCtx ffcontext.Context,
+	flagContext Context,
+	trace *EvaluationTrace,
+) (bool, bool) {
+	met, cacheable := f.evaluatePrerequisites(flagName, evaluationCtx, flagContext, trace != nil)
+	if met && len(f.GetPrerequisites()) > 0 {
+		trace.add(TraceStepPrerequisites, "all the prerequisites are fulfilled", nil)
+	}
+	return met, cacheable
+}
+
+// evaluatePrerequisites evaluates the prerequisite flags, they are explained (without modifying the sticky
+// assignments) if the evaluation of the flag is explained.
+func (f *InternalFlag) evaluatePrerequisites(
+	flagName string,
+	evaluationCtx ffcontext.Context,
+	flagContext Context,
+	explain bool,
+) (bool, bool) {
+	if len(f.GetPrerequisites()) == 0 {
+		return true, true
+	}
+	if flagContext.FlagGetter == nil {
+		return false, false
+	}
+
+	cacheable := true
+	prerequisiteCtx := flagContext
+	prerequisiteCtx.DefaultSdkValue = nil
+	for _, prerequisite := range f.GetPrerequisites() {
+		if prerequisite.GetKey() == flagName {
+			return false, false
+		}
+		prerequisiteFlag, err := flagContext.FlagGetter(prerequisite.GetKey())
+		if err != nil || prerequisiteFlag == nil {
+			return false, false
+		}
+		var resolutionDetails ResolutionDetails
+		if explainer, ok := prerequisiteFlag.(Explainer); ok && explain {
+			_, resolutionDetails, _ = explainer.Explain(prerequisite.GetKey(), evaluationCtx, prerequisiteCtx)
+		} else {
+			_, resolutionDetails = prerequisiteFlag.Value(prerequisite.GetKey(), evaluationCtx, prerequisiteCtx)
+		}
+		if resolutionDetails.ErrorCode != "" || resolutionDetails.Variant != prerequisite.GetVariation() {
+			return false, false
+		}
+		cacheable = cacheable && resolutionDetails.Cacheable
+	}
+	return true, cacheable
+}
+
 // selectEvaluationReason is choosing which reason has been chosen for the evaluation.
 func selectEvaluationReason(hasRule bool, targetingMatch bool, isDynamic bool, isDefaultRule bool) ResolutionReason {
 	if hasRule && targetingMatch {
@@ -120,104 +376,325 @@ func selectEvaluationReason(hasRule bool, targetingMatch bool, isDynamic bool, i
 }
 
 func (f *InternalFlag) isCacheable() bool {
-	isDynamic := (f.Scheduled != nil && len(*f.Scheduled) > 0) || f.Experimentation != nil
+	isDynamic := (f.Scheduled != nil && len(*f.Scheduled) > 0) || f.Experimentation != nil || f.hasTimeWindows()
 	return !isDynamic
 }
 
+// hasTimeWindows checks if the flag or one of its rules is restricted to time windows.
+func (f *InternalFlag) hasTimeWindows() bool {
+	if len(f.GetTimeWindows()) > 0 {
+		return true
+	}
+	for _, rule := range f.GetRules() {
+		if len(rule.GetTimeWindows()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // selectVariation is doing the magic to select the variation that should be used for this specific user
-// to always affect the user to the same segment we are using a hash of the flag name + key
-func (f *InternalFlag) selectVariation(flagName string, ctx ffcontext.Context) (*variationSelection, error) {
-	hashID := utils.Hash(flagName+ctx.GetKey()) % MaxPercentage
+// to always affect the user to the same segment we are using a hash of the flag name + bucketing value
+// (by default the targeting key of the evaluation context).
+func (f *InternalFlag) selectVariation(
+	flagName string,
+	ctx ffcontext.Context,
+	evaluationDate time.Time,
+	flagContext Context,
+	trace *EvaluationTrace,
+) (*variationSelection, error) {
+	lists := flagContext.MembershipLists
+	if selection, ok := f.selectTarget(ctx, trace); ok {
+		return selection, nil
+	}
+
 	hasRule := len(f.GetRules()) != 0
+	outsideRuleTimeWindow := false
 	// Check all targeting in order, the first to match will be the one used.
-	if hasRule {
-		for ruleIndex, target := range f.GetRules() {
-			variationName, err := target.Evaluate(ctx, hashID, false)
-			if err != nil {
-				// the targeting does not apply
-				if _, ok := err.(*internalerror.RuleNotApply); ok {
-					continue
-				}
-				return nil, err
+	for ruleIndex, target := range f.GetRules() {
+		bucket, bucketErr := f.computeBucket(flagName, ctx, &target)
+		variationName, err := target.evaluate(
+			flagName, ctx, bucket.hashID, false, f.compiledRule(&target), lists, evaluationDate)
+		trace.addRule(f, &target, &ruleIndex, ctx, bucket, bucketErr, variationName, err, evaluationDate)
+		if err != nil {
+			// the targeting does not apply
+			switch err.(type) {
+			case *internalerror.RuleNotApply:
+				continue
+			case *internalerror.RuleOutsideTimeWindow:
+				outsideRuleTimeWindow = true
+				continue
 			}
-			reason := selectEvaluationReason(hasRule, true, target.IsDynamic(), false)
-			return &variationSelection{
-				name:      variationName,
-				reason:    reason,
-				ruleIndex: &ruleIndex,
-				ruleName:  f.GetRules()[ruleIndex].Name,
-				cacheable: f.isCacheable() && target.ProgressiveRollout == nil,
-			}, err
+			return nil, err
+		}
+		if bucketErr != nil && target.IsDynamic() {
+			return nil, bucketErr
 		}
+		reason := selectEvaluationReason(hasRule, true, target.IsDynamic(), false)
+		selection := &variationSelection{
+			name:      variationName,
+			reason:    reason,
+			ruleIndex: &ruleIndex,
+			ruleName:  f.GetRules()[ruleIndex].Name,
+			cacheable: f.isCacheable() && target.ProgressiveRollout == nil,
+		}
+		f.applyBucket(selection, flagContext, flagName, &target, bucket, bucketErr, trace)
+		return selection, nil
 	}
 
 	if f.DefaultRule == nil {
+		trace.add(TraceStepDefaultRule, "no rule matched and the flag has no default rule", nil)
 		return nil, fmt.Errorf("no default targeting for the flag")
 	}
 
-	variationName, err := f.GetDefaultRule().Evaluate(ctx, hashID, true)
+	bucket, bucketErr := f.computeBucket(flagName, ctx, f.GetDefaultRule())
+	// the query of the default rule is ignored, so it is not compiled.
+	variationName, err := f.GetDefaultRule().evaluate(flagName, ctx, bucket.hashID, true, nil, lists, evaluationDate)
+	trace.addRule(f, f.GetDefaultRule(), nil, ctx, bucket, bucketErr, variationName, err, evaluationDate)
 	if err != nil {
 		return nil, err
 	}
+	if bucketErr != nil && f.GetDefaultRule().IsDynamic() {
+		return nil, bucketErr
+	}
 
 	reason := selectEvaluationReason(hasRule, false, f.GetDefaultRule().IsDynamic(), true)
-	return &variationSelection{
+	if outsideRuleTimeWindow {
+		reason = ReasonOutsideRuleTimeWindow
+	}
+	selection := &variationSelection{
 		name:      variationName,
 		reason:    reason,
 		cacheable: f.isCacheable() && f.GetDefaultRule().ProgressiveRollout == nil,
+	}
+	f.applyBucket(selection, flagContext, flagName, f.GetDefaultRule(), bucket, bucketErr, trace)
+	return selection, nil
+}
+
+// selectTarget returns the variation of the first target list containing the evaluation context.
+func (f *InternalFlag) selectTarget(ctx ffcontext.Context, trace *EvaluationTrace) (*variationSelection, bool) {
+	if len(f.GetTargets()) == 0 {
+		return nil, false
+	}
+	target, ok := f.matchTarget(ctx)
+	if !ok {
+		trace.add(TraceStepTargets, "the evaluation context is not part of any target list", nil)
+		return nil, false
+	}
+	name := target.GetName()
+	if name == "" {
+		name = "a target list"
+	}
+	trace.add(TraceStepTargets, fmt.Sprintf("the evaluation context is part of %s, serving the variation %s",
+		name, target.GetVariation()), nil)
+	return &variationSelection{
+		name:       target.GetVariation(),
+		reason:     ReasonTargetingMatch,
+		targetName: target.Name,
+		cacheable:  f.isCacheable(),
+	}, true
+}
+
+// applyBucket adds the bucket of the evaluation context to the selection of a rule, and replaces the variation
+// by the one assigned during a previous evaluation if a sticky bucketing store is configured.
+// The assignments are only read if the evaluation is explained.
+func (f *InternalFlag) applyBucket(
+	selection *variationSelection,
+	flagContext Context,
+	flagName string,
+	rule *Rule,
+	bucket bucket,
+	bucketErr error,
+	trace *EvaluationTrace,
+) {
+	if rule.IsDynamic() && bucket.key != "" {
+		selection.bucketingKey = bucket.key
+		selection.bucketingValue = bucket.value
+	}
+	if flagContext.StickyBucketingStore == nil || len(rule.GetPercentages()) == 0 || bucketErr != nil {
+		return
+	}
+	selection.name, selection.sticky = f.stickyVariation(
+		flagContext, flagName, bucket.value, selection.name, trace == nil)
+	if selection.sticky {
+		trace.add(TraceStepStickyAssignment, fmt.Sprintf(
+			"the evaluation context was assigned to the variation %s during a previous evaluation, serving it",
+			selection.name), nil)
+	}
+}
+
+// computeBucket is computing the hash used to affect the evaluation context to a percentage bucket.
+// The bucketing key of the rule is used first, then the one of the flag and if none is configured
+// we use the targeting key.
+func (f *InternalFlag) computeBucket(flagName string, ctx ffcontext.Context, rule *Rule) (bucket, error) {
+	bucketingKey := rule.GetBucketingKey()
+	if bucketingKey == "" {
+		bucketingKey = f.GetBucketingKey()
+	}
+
+	value, err := getBucketingValue(ctx, bucketingKey)
+	if err != nil {
+		return bucket{key: bucketingKey}, err
+	}
+	return bucket{
+		key:    bucketingKey,
+		value:  value,
+		hashID: f.hash(flagName, value),
 	}, nil
 }
 
-// nolint: gocognit
-// applyScheduledRolloutSteps is checking if the flag has a scheduled rollout configured.
-// If yes we merge the changes to the current flag.
-func (f *InternalFlag) applyScheduledRolloutSteps() {
-	evaluationDate := time.Now()
-	if f.Scheduled != nil {
-		for _, steps := range *f.Scheduled {
-			if steps.Date != nil && steps.Date.Before(evaluationDate) {
-				f.Rules = MergeSetOfRules(f.GetRules(), steps.GetRules())
-				if steps.Disable != nil {
-					f.Disable = steps.Disable
-				}
+// GetFlagAt returns the flag as it is at the evaluation date, with the changes of all the scheduled steps
+// planned before this date applied.
+// The flag itself is never modified, the flags resolved when loading the flag (see ResolveScheduledSteps) are
+// returned so the evaluations do not merge the steps. The result does not depend on the previous evaluations
+// and it is safe to use concurrently.
+func (f *InternalFlag) GetFlagAt(evaluationDate time.Time) *InternalFlag {
+	if f.snapshots == nil {
+		return f.applyScheduledSteps(func(date time.Time) bool { return date.Before(evaluationDate) })
+	}
+	// index is the first snapshot that is not before the evaluation date, the previous one applies.
+	index := sort.Search(len(f.snapshots), func(i int) bool {
+		return !f.snapshots[i].date.Before(evaluationDate)
+	})
+	if index == 0 {
+		return f
+	}
+	return f.snapshots[index-1].flag
+}
 
-				if steps.TrackEvents != nil {
-					f.TrackEvents = steps.TrackEvents
-				}
+// ResolveScheduledSteps resolves the flag as it is after each date of its scheduled rollout.
+// It is done once when loading the flag in the cache, after all the other fields of the flag are set, so the
+// resolved flags share the compiled queries, the parsed templates and the indexed targets of the flag.
+func (f *InternalFlag) ResolveScheduledSteps() {
+	f.snapshots = nil
+	if len(f.GetScheduled()) == 0 {
+		return
+	}
+	dates := make([]time.Time, 0, len(f.GetScheduled()))
+	for _, step := range f.GetScheduled() {
+		if step.Date != nil {
+			dates = append(dates, *step.Date)
+		}
+	}
+	slices.SortFunc(dates, time.Time.Compare)
+	dates = slices.CompactFunc(dates, time.Time.Equal)
 
-				if steps.DefaultRule != nil {
-					f.DefaultRule.MergeRules(*steps.DefaultRule)
-				}
+	snapshots := make([]scheduledSnapshot, 0, len(dates))
+	for _, snapshotDate := range dates {
+		snapshots = append(snapshots, scheduledSnapshot{
+			date: snapshotDate,
+			flag: f.applyScheduledSteps(func(date time.Time) bool { return !date.After(snapshotDate) }),
+		})
+	}
+	f.snapshots = snapshots
+}
 
-				if steps.Variations != nil {
-					for key, value := range steps.GetVariations() {
-						f.GetVariations()[key] = value
-					}
-				}
+// applyScheduledSteps returns the flag with the changes of the scheduled steps for which isApplied(date) is true.
+// A new flag is returned if at least one step applies.
+func (f *InternalFlag) applyScheduledSteps(isApplied func(date time.Time) bool) *InternalFlag {
+	flagAtDate := f
+	for _, step := range f.GetScheduled() {
+		if step.Date == nil || !isApplied(*step.Date) {
+			continue
+		}
+		if flagAtDate == f {
+			flagCopy := *f
+			flagAtDate = &flagCopy
+		}
+		flagAtDate.applyScheduledStep(step)
+	}
+	return flagAtDate
+}
 
-				if steps.Version != nil {
-					f.Version = steps.Version
-				}
+// applyScheduledStep merges the changes of a scheduled step into the flag.
+// The fields shared with the flag in the cache are replaced and never modified in place.
+func (f *InternalFlag) applyScheduledStep(step ScheduledStep) {
+	f.Rules = MergeSetOfRules(f.GetRules(), step.GetRules())
+	if step.Disable != nil {
+		f.Disable = step.Disable
+	}
 
-				if steps.Experimentation != nil {
-					if f.Experimentation == nil {
-						f.Experimentation = &ExperimentationRollout{}
-					}
-					if steps.Experimentation.Start != nil {
-						f.Experimentation.End = steps.Experimentation.End
-					}
-					if steps.Experimentation.End != nil {
-						f.Experimentation.End = steps.Experimentation.End
-					}
-				}
-			}
+	if step.TrackEvents != nil {
+		f.TrackEvents = step.TrackEvents
+	}
+
+	if step.Type != nil {
+		f.Type = step.Type
+	}
+
+	if step.Schema != nil {
+		f.Schema = step.Schema
+	}
+
+	if step.Prerequisites != nil {
+		f.Prerequisites = step.Prerequisites
+	}
+
+	if step.FallbackVariation != nil {
+		f.FallbackVariation = step.FallbackVariation
+	}
+
+	if step.DefaultRule != nil {
+		defaultRule := Rule{}
+		if f.DefaultRule != nil {
+			defaultRule = *f.DefaultRule
+		}
+		defaultRule.MergeRules(*step.DefaultRule)
+		f.DefaultRule = &defaultRule
+	}
+
+	if step.Variations != nil {
+		variations := make(map[string]*interface{}, len(f.GetVariations())+len(step.GetVariations()))
+		maps.Copy(variations, f.GetVariations())
+		maps.Copy(variations, step.GetVariations())
+		f.Variations = &variations
+	}
+
+	if step.Version != nil {
+		f.Version = step.Version
+	}
+
+	if step.BucketingKey != nil {
+		f.BucketingKey = step.BucketingKey
+	}
+
+	if step.TimeWindows != nil {
+		f.TimeWindows = step.TimeWindows
+	}
+
+	if step.Targets != nil {
+		f.Targets = step.Targets
+	}
+
+	if step.Seed != nil {
+		f.Seed = step.Seed
+	}
+
+	if step.HashAlgorithm != nil {
+		f.HashAlgorithm = step.HashAlgorithm
+	}
+
+	if step.TemplatedVariations != nil {
+		f.TemplatedVariations = step.TemplatedVariations
+	}
+
+	if step.Experimentation != nil {
+		experimentation := ExperimentationRollout{}
+		if f.Experimentation != nil {
+			experimentation = *f.Experimentation
+		}
+		if step.Experimentation.Start != nil {
+			experimentation.Start = step.Experimentation.Start
 		}
+		if step.Experimentation.End != nil {
+			experimentation.End = step.Experimentation.End
+		}
+		f.Experimentation = &experimentation
 	}
 }
 
-// isExperimentationOver checks if we are in an experimentation or not
-func (f *InternalFlag) isExperimentationOver() bool {
-	now := time.Now()
+// isExperimentationOver checks if we are in an experimentation or not at the evaluation date
+func (f *InternalFlag) isExperimentationOver(now time.Time) bool {
 	return f.Experimentation != nil &&
 		((f.Experimentation.Start != nil && now.Before(*f.Experimentation.Start)) ||
 			(f.Experimentation.End != nil && now.After(*f.Experimentation.End)))
@@ -249,6 +726,48 @@ func (f *InternalFlag) IsValid() error {
 		}
 	}
 
+	if f.Type != nil {
+		if err := isValidValueType(f.GetType()); err != nil {
+			return err
+		}
+	}
+	if f.Schema != nil {
+		if err := schema.Check(f.GetSchema()); err != nil {
+			return fmt.Errorf("invalid schema: %w", err)
+		}
+	}
+
+	for name, value := range f.GetVariations() {
+		if value == nil {
+			continue
+		}
+		if f.IsTemplatedVariations() {
+			if f.IsTemplatedVariations() {
+				if err := validateVariationTemplate(*value); err != nil {
+					return fmt.Errorf("invalid variation %s: %w", name, err)
+				}
+			}
+		}
+		if err := f.isValidVariationValue(name, *value); err != nil {
+			return err
+		}
+	}
+	if err := f.isValidScheduledSteps(); err != nil {
+		return err
+	}
+
+	if f.Layer != nil {
+		if err := f.Layer.IsValid(); err != nil {
+			return err
+		}
+	}
+
+	if f.HashAlgorithm != nil {
+		if err := isValidHashAlgorithm(f.GetHashAlgorithm()); err != nil {
+			return err
+		}
+	}
+
 	// Validate that we have a default Rule
 	if f.GetDefaultRule() == nil {
 		return fmt.Errorf("missing default rule")
@@ -274,9 +793,211 @@ func (f *InternalFlag) IsValid() error {
 		}
 	}
 
+	for _, target := range f.GetTargets() {
+		if err := target.IsValid(f.GetVariations()); err != nil {
+			return err
+		}
+	}
+
+	for _, window := range f.GetTimeWindows() {
+		if err := window.IsValid(); err != nil {
+			return err
+		}
+	}
+
+	if f.BucketingKey != nil && strings.TrimSpace(f.GetBucketingKey()) == "" {
+		return fmt.Errorf("bucketing key cannot be empty")
+	}
+
+	// Validate the prerequisites
+	for _, prerequisite := range f.GetPrerequisites() {
+		if err := prerequisite.IsValid(); err != nil {
+			return err
+		}
+	}
+	if f.FallbackVariation != nil {
+		if _, ok := f.GetVariations()[f.GetFallbackVariation()]; !ok {
+			return fmt.Errorf("fallback variation %s does not exist", f.GetFallbackVariation())
+		}
+	}
+
+	// Validate the segments used by the rules
+	for _, segmentName := range f.getSegmentReferences() {
+		segment, ok := f.GetSegments()[segmentName]
+		if !ok {
+			return fmt.Errorf("unknown segment: %s", segmentName)
+		}
+		if err := segment.IsValid(); err != nil {
+			return fmt.Errorf("invalid segment %s: %w", segmentName, err)
+		}
+	}
+
+	if err := f.isValidEnvironments(); err != nil {
+		return err
+	}
+
+	// Parse the queries of the rules, an invalid query makes the flag invalid.
+	for _, rule := range f.getAllRules() {
+		if rule.IsDisable() {
+			continue
+		}
+		if err := f.compiledRule(&rule).validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isValidScheduledSteps checks the flag as it is after each scheduled step, a step can change the variations,
+// the declared type, the prerequisites or the fallback variation of the flag.
+func (f *InternalFlag) isValidScheduledSteps() error {
+	flagAtStep := *f
+	for _, step := range f.GetScheduled() {
+		flagAtStep.applyScheduledStep(step)
+		for name, value := range step.GetVariations() {
+			if value == nil {
+				continue
+			}
+			if err := validateVariationTemplate(*value); err != nil {
+				return fmt.Errorf("invalid variation %s: %w", name, err)
+			}
+		}
+
+		if step.Type != nil {
+			if err := isValidValueType(step.GetType()); err != nil {
+				return err
+			}
+		}
+		if step.Schema != nil {
+			if err := schema.Check(step.GetSchema()); err != nil {
+				return fmt.Errorf("invalid schema: %w", err)
+			}
+		}
+		// a new type or schema applies to all the variations of the flag.
+		variations := step.GetVariations()
+		if step.Type != nil || step.Schema != nil {
+			variations = flagAtStep.GetVariations()
+		}
+		for name, value := range variations {
+			if value == nil {
+				continue
+			}
+			if err := flagAtStep.isValidVariationValue(name, *value); err != nil {
+				return err
+			}
+		}
+
+		for _, prerequisite := range step.GetPrerequisites() {
+			if err := prerequisite.IsValid(); err != nil {
+				return err
+			}
+		}
+		if flagAtStep.FallbackVariation != nil {
+			if _, ok := flagAtStep.GetVariations()[flagAtStep.GetFallbackVariation()]; !ok {
+				return fmt.Errorf("fallback variation %s does not exist", flagAtStep.GetFallbackVariation())
+			}
+		}
+	}
 	return nil
 }
 
+// LinkSegments attaches to the flag the segments referenced by its rules (including the ones
+// from the scheduled rollout steps).
+// The segments not used by the flag are ignored, so an update of an unrelated segment does not
+// change the flag.
+func (f *InternalFlag) LinkSegments(segments map[string]Segment) {
+	linked := make(map[string]Segment)
+	for _, segmentName := range f.getSegmentReferences() {
+		if segment, ok := segments[segmentName]; ok {
+			linked[segmentName] = segment
+		}
+	}
+
+	if len(linked) == 0 {
+		f.Segments = nil
+		return
+	}
+	f.Segments = &linked
+}
+
+// CompileRules expands and parses the queries of the rules of the flag (including the ones from the scheduled
+// rollout steps and the rules updated by those steps) and keeps them in the flag, so the evaluations do not
+// parse the queries.
+// It is done once when loading the flag in the cache, after linking the segments.
+func (f *InternalFlag) CompileRules() {
+	if len(f.getAllRules()) == 0 {
+		return
+	}
+	f.compiled = &compiledRules{}
+	for _, rule := range f.getAllRules() {
+		f.compiledRule(&rule)
+	}
+
+	flagAtStep := *f
+	for _, step := range f.GetScheduled() {
+		flagAtStep.applyScheduledStep(step)
+		for _, rule := range flagAtStep.GetRules() {
+			f.compiledRule(&rule)
+		}
+	}
+}
+
+// compiledRule returns the queries of the rule ready to be evaluated.
+// A rule of a flag loaded in the cache is compiled once and kept in the flag (see CompileRules),
+// a rule of a flag that has not been loaded by the cache is compiled at each call.
+func (f *InternalFlag) compiledRule(rule *Rule) *compiledRule {
+	if f.compiled == nil {
+		return rule.compile(f.GetSegments())
+	}
+	return f.compiled.get(rule, f.GetSegments())
+}
+
+// getSegmentReferences returns the names of all the segments used by the rules of the flag.
+func (f *InternalFlag) getSegmentReferences() []string {
+	references := make([]string, 0)
+	for _, rule := range f.getAllRules() {
+		for _, segmentName := range rule.GetSegmentReferences() {
+			if !utils.Contains(references, segmentName) {
+				references = append(references, segmentName)
+			}
+		}
+	}
+	return references
+}
+
+// QueryAttributes returns the attributes of the evaluation context used by the queries of the rules of the flag
+// and of the segments they reference, in alphabetical order.
+func (f *InternalFlag) QueryAttributes() []string {
+	attributes := make([]string, 0)
+	for _, rule := range f.getAllRules() {
+		for _, attribute := range f.compiledRule(&rule).attributes {
+			if !utils.Contains(attributes, attribute) {
+				attributes = append(attributes, attribute)
+			}
+		}
+	}
+	sort.Strings(attributes)
+	return attributes
+}
+
+// getAllRules returns the targeting rules of the flag, including the ones from the scheduled rollout steps
+// and from the environment overrides.
+func (f *InternalFlag) getAllRules() []Rule {
+	rules := append([]Rule{}, f.GetRules()...)
+	if f.Scheduled != nil {
+		for _, step := range *f.Scheduled {
+			rules = append(rules, step.GetRules()...)
+		}
+	}
+	for _, override := range f.GetEnvironments() {
+		if override.Rules != nil {
+			rules = append(rules, *override.Rules...)
+		}
+	}
+	return rules
+}
+
 // GetVariations is the getter of the field Variations
 func (f *InternalFlag) GetVariations() map[string]*interface{} {
 	if f.Variations == nil {
@@ -292,6 +1013,14 @@ func (f *InternalFlag) GetRules() []Rule {
 	return *f.Rules
 }
 
+// GetTargets is the getter of the field Targets
+func (f *InternalFlag) GetTargets() []Target {
+	if f.Targets == nil {
+		return []Target{}
+	}
+	return *f.Targets
+}
+
 func (f *InternalFlag) GetRuleIndexByName(name string) *int {
 	for index, rule := range f.GetRules() {
 		if rule.GetName() == name {
@@ -340,6 +1069,103 @@ func (f *InternalFlag) GetVariationValue(name string) interface{} {
 	return nil
 }
 
+// GetType is the getter of the field Type
+func (f *InternalFlag) GetType() string {
+	if f.Type == nil {
+		return ""
+	}
+	return *f.Type
+}
+
+// GetSchema is the getter of the field Schema
+func (f *InternalFlag) GetSchema() map[string]interface{} {
+	if f.Schema == nil {
+		return nil
+	}
+	return *f.Schema
+}
+
+// GetBucketingKey is the getter of the field BucketingKey
+func (f *InternalFlag) GetBucketingKey() string {
+	if f.BucketingKey == nil {
+		return ""
+	}
+	return *f.BucketingKey
+}
+
+// GetSeed is the getter of the field Seed
+func (f *InternalFlag) GetSeed() string {
+	if f.Seed == nil {
+		return ""
+	}
+	return *f.Seed
+}
+
+// GetHashAlgorithm is the getter of the field HashAlgorithm
+func (f *InternalFlag) GetHashAlgorithm() HashAlgorithm {
+	if f.HashAlgorithm == nil {
+		return HashAlgorithmFNV
+	}
+	return *f.HashAlgorithm
+}
+
+// GetTimeWindows is the getter of the field TimeWindows
+func (f *InternalFlag) GetTimeWindows() []TimeWindow {
+	if f.TimeWindows == nil {
+		return []TimeWindow{}
+	}
+	return *f.TimeWindows
+}
+
+// GetScheduled is the getter of the field Scheduled
+func (f *InternalFlag) GetScheduled() []ScheduledStep {
+	if f.Scheduled == nil {
+		return []ScheduledStep{}
+	}
+	return *f.Scheduled
+}
+
+// GetPrerequisites is the getter of the field Prerequisites
+func (f *InternalFlag) GetPrerequisites() []Prerequisite {
+	if f.Prerequisites == nil {
+		return []Prerequisite{}
+	}
+	return *f.Prerequisites
+}
+
+// GetAllPrerequisites returns the prerequisites of the flag, including the ones from the scheduled rollout steps.
+func (f *InternalFlag) GetAllPrerequisites() []Prerequisite {
+	prerequisites := append([]Prerequisite{}, f.GetPrerequisites()...)
+	for _, step := range f.GetScheduled() {
+		prerequisites = append(prerequisites, step.GetPrerequisites()...)
+	}
+	return prerequisites
+}
+
+// GetFallbackVariation is the getter of the field FallbackVariation
+func (f *InternalFlag) GetFallbackVariation() string {
+	if f.FallbackVariation == nil {
+		return ""
+	}
+	return *f.FallbackVariation
+}
+
+// IsTemplatedVariations is the getter of the field TemplatedVariations
+func (f *InternalFlag) IsTemplatedVariations() bool {
+	if f.TemplatedVariations == nil {
+		return false
+	}
+	return *f.TemplatedVariations
+}
+
+// GetSegments is the getter of the field Segments
+func (f *InternalFlag) GetSegments() map[string]Segment {
+	if f.Segments == nil {
+		return map[string]Segment{}
+	}
+	return *f.Segments
+}
+
 // GetMetadata return the metadata associated to the flag
 func (f *InternalFlag) GetMetadata() map[string]interface{} {
 	if f.Metadata == nil {
@@ -3,10 +3,12 @@ package flag_test
 import (
 	"fmt"
 	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/stretchr/testify/assert"
 	"github.com/thomaspoignant/go-feature-flag/internal/flag"
 	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
@@ -1537,8 +1539,9 @@ func TestInternalFlag_Value(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got, got1 := tt.flag.Value(tt.args.flagName, tt.args.user, tt.args.flagContext)
-			assert.Equalf(t, tt.want, got, "not expected value: %s", cmp.Diff(tt.want, got))
-			assert.Equalf(t, tt.want1, got1, "not expected value: %s", cmp.Diff(tt.want1, got1))
+			ignoreUnexported := cmpopts.IgnoreUnexported(flag.InternalFlag{})
+			assert.Equalf(t, tt.want, got, "not expected value: %s", cmp.Diff(tt.want, got, ignoreUnexported))
+			assert.Equalf(t, tt.want1, got1, "not expected value: %s", cmp.Diff(tt.want1, got1, ignoreUnexported))
 		})
 	}
 }
@@ -1726,6 +1729,7 @@ func TestInternalFlag_IsValid(t *testing.T) {
 		Experimentation *flag.ExperimentationRollout
 		Scheduled       *[]flag.ScheduledStep
 		Metadata        *map[string]interface{}
+		Segments        *map[string]flag.Segment
 	}
 	tests := []struct {
 		name     string
@@ -1733,6 +1737,71 @@ func TestInternalFlag_IsValid(t *testing.T) {
 		wantErr  assert.ErrorAssertionFunc
 		errorMsg string
 	}{
+		{
+			name: "rule referencing an unknown segment",
+			fields: fields{
+				Variations: &map[string]*interface{}{
+					"A": testconvert.Interface("A"),
+					"B": testconvert.Interface("B"),
+				},
+				Rules: &[]flag.Rule{
+					{
+						Segment:         testconvert.String("beta-testers"),
+						VariationResult: testconvert.String("B"),
+					},
+				},
+				DefaultRule: &flag.Rule{
+					VariationResult: testconvert.String("A"),
+				},
+			},
+			errorMsg: "unknown segment: beta-testers",
+			wantErr:  assert.Error,
+		},
+		{
+			name: "query referencing an invalid segment",
+			fields: fields{
+				Variations: &map[string]*interface{}{
+					"A": testconvert.Interface("A"),
+					"B": testconvert.Interface("B"),
+				},
+				Rules: &[]flag.Rule{
+					{
+						Query:           testconvert.String(`inSegment("beta-testers")`),
+						VariationResult: testconvert.String("B"),
+					},
+				},
+				DefaultRule: &flag.Rule{
+					VariationResult: testconvert.String("A"),
+				},
+				Segments: &map[string]flag.Segment{
+					"beta-testers": {},
+				},
+			},
+			errorMsg: "invalid segment beta-testers: a segment should have a query",
+			wantErr:  assert.Error,
+		},
+		{
+			name: "rule referencing a valid segment",
+			fields: fields{
+				Variations: &map[string]*interface{}{
+					"A": testconvert.Interface("A"),
+					"B": testconvert.Interface("B"),
+				},
+				Rules: &[]flag.Rule{
+					{
+						Segment:         testconvert.String("beta-testers"),
+						VariationResult: testconvert.String("B"),
+					},
+				},
+				DefaultRule: &flag.Rule{
+					VariationResult: testconvert.String("A"),
+				},
+				Segments: &map[string]flag.Segment{
+					"beta-testers": {Query: testconvert.String("beta eq true")},
+				},
+			},
+			wantErr: assert.NoError,
+		},
 		{
 			name: "no variation",
 			fields: fields{
@@ -1975,6 +2044,107 @@ func TestInternalFlag_IsValid(t *testing.T) {
 			errorMsg: "invalid progressive rollout, initial percentage should be lower than end percentage: 30/20",
 			wantErr:  assert.Error,
 		},
+		{
+			name: "progressive rollout steps not ordered",
+			fields: fields{
+				Variations: &map[string]*interface{}{
+					"A": testconvert.Interface("A"),
+					"B": testconvert.Interface("B"),
+				},
+				DefaultRule: &flag.Rule{
+					Name: testconvert.String("default"),
+					ProgressiveRollout: &flag.ProgressiveRollout{
+						Initial: &flag.ProgressiveRolloutStep{
+							Variation:  testconvert.String("A"),
+							Percentage: testconvert.Float64(0),
+							Date:       testconvert.Time(time.Date(2021, time.February, 1, 10, 10, 10, 10, time.UTC)),
+						},
+						Steps: &[]flag.ProgressiveRolloutRampStep{
+							{
+								Percentage: testconvert.Float64(25),
+								Date:       testconvert.Time(time.Date(2021, time.February, 3, 10, 10, 10, 10, time.UTC)),
+							},
+							{
+								Percentage: testconvert.Float64(50),
+								Date:       testconvert.Time(time.Date(2021, time.February, 2, 10, 10, 10, 10, time.UTC)),
+							},
+						},
+						End: &flag.ProgressiveRolloutStep{
+							Variation:  testconvert.String("B"),
+							Percentage: testconvert.Float64(100),
+							Date:       testconvert.Time(time.Date(2021, time.February, 5, 10, 10, 10, 10, time.UTC)),
+						},
+					},
+				},
+			},
+			errorMsg: "invalid progressive rollout, step 1 date should be after the previous one",
+			wantErr:  assert.Error,
+		},
+		{
+			name: "progressive rollout steps percentage decreasing",
+			fields: fields{
+				Variations: &map[string]*interface{}{
+					"A": testconvert.Interface("A"),
+					"B": testconvert.Interface("B"),
+				},
+				DefaultRule: &flag.Rule{
+					Name: testconvert.String("default"),
+					ProgressiveRollout: &flag.ProgressiveRollout{
+						Initial: &flag.ProgressiveRolloutStep{
+							Variation:  testconvert.String("A"),
+							Percentage: testconvert.Float64(0),
+							Date:       testconvert.Time(time.Date(2021, time.February, 1, 10, 10, 10, 10, time.UTC)),
+						},
+						Steps: &[]flag.ProgressiveRolloutRampStep{
+							{
+								Percentage: testconvert.Float64(25),
+								Date:       testconvert.Time(time.Date(2021, time.February, 2, 10, 10, 10, 10, time.UTC)),
+							},
+							{
+								Percentage: testconvert.Float64(5),
+								Date:       testconvert.Time(time.Date(2021, time.February, 3, 10, 10, 10, 10, time.UTC)),
+							},
+						},
+						End: &flag.ProgressiveRolloutStep{
+							Variation:  testconvert.String("B"),
+							Percentage: testconvert.Float64(100),
+							Date:       testconvert.Time(time.Date(2021, time.February, 5, 10, 10, 10, 10, time.UTC)),
+						},
+					},
+				},
+			},
+			errorMsg: "invalid progressive rollout, step 1 percentage should not be lower than the previous one: 25/5",
+			wantErr:  assert.Error,
+		},
+		{
+			name: "progressive rollout step without date",
+			fields: fields{
+				Variations: &map[string]*interface{}{
+					"A": testconvert.Interface("A"),
+					"B": testconvert.Interface("B"),
+				},
+				DefaultRule: &flag.Rule{
+					Name: testconvert.String("default"),
+					ProgressiveRollout: &flag.ProgressiveRollout{
+						Initial: &flag.ProgressiveRolloutStep{
+							Variation:  testconvert.String("A"),
+							Percentage: testconvert.Float64(0),
+							Date:       testconvert.Time(time.Date(2021, time.February, 1, 10, 10, 10, 10, time.UTC)),
+						},
+						Steps: &[]flag.ProgressiveRolloutRampStep{
+							{Percentage: testconvert.Float64(25)},
+						},
+						End: &flag.ProgressiveRolloutStep{
+							Variation:  testconvert.String("B"),
+							Percentage: testconvert.Float64(100),
+							Date:       testconvert.Time(time.Date(2021, time.February, 5, 10, 10, 10, 10, time.UTC)),
+						},
+					},
+				},
+			},
+			errorMsg: "invalid progressive rollout, step 0 should have a date and a percentage",
+			wantErr:  assert.Error,
+		},
 		{
 			name: "ignore invalid rule if disabled",
 			fields: fields{
@@ -2005,6 +2175,61 @@ func TestInternalFlag_IsValid(t *testing.T) {
 			errorMsg: "",
 			wantErr:  assert.NoError,
 		},
+		{
+			name: "scheduled step with a type not matching the variations",
+			fields: fields{
+				Variations: &map[string]*interface{}{
+					"A": testconvert.Interface("A"),
+				},
+				DefaultRule: &flag.Rule{VariationResult: testconvert.String("A")},
+				Scheduled: &[]flag.ScheduledStep{
+					{
+						InternalFlag: flag.InternalFlag{Type: testconvert.String("bool")},
+						Date:         testconvert.Time(time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)),
+					},
+				},
+			},
+			errorMsg: "invalid variation A: the value is not of type bool",
+			wantErr:  assert.Error,
+		},
+		{
+			name: "scheduled step with an unknown fallback variation",
+			fields: fields{
+				Variations: &map[string]*interface{}{
+					"A": testconvert.Interface("A"),
+					"B": testconvert.Interface("B"),
+				},
+				DefaultRule: &flag.Rule{VariationResult: testconvert.String("A")},
+				Scheduled: &[]flag.ScheduledStep{
+					{
+						InternalFlag: flag.InternalFlag{FallbackVariation: testconvert.String("C")},
+						Date:         testconvert.Time(time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)),
+					},
+				},
+			},
+			errorMsg: "fallback variation C does not exist",
+			wantErr:  assert.Error,
+		},
+		{
+			name: "scheduled step with an invalid prerequisite",
+			fields: fields{
+				Variations: &map[string]*interface{}{
+					"A": testconvert.Interface("A"),
+					"B": testconvert.Interface("B"),
+				},
+				DefaultRule: &flag.Rule{VariationResult: testconvert.String("A")},
+				Scheduled: &[]flag.ScheduledStep{
+					{
+						InternalFlag: flag.InternalFlag{
+							Prerequisites: &[]flag.Prerequisite{{Key: testconvert.String("other-flag")}},
+						},
+						Date: testconvert.Time(time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)),
+					},
+				},
+			},
+			errorMsg: "the prerequisite other-flag should have a variation",
+			wantErr:  assert.Error,
+		},
 	}
 
 	for _, tt := range tests {
@@ -2018,6 +2243,7 @@ func TestInternalFlag_IsValid(t *testing.T) {
 				Version:         tt.fields.Version,
 				Scheduled:       tt.fields.Scheduled,
 				Experimentation: tt.fields.Experimentation,
+				Segments:        tt.fields.Segments,
 			}
 			err := f.IsValid()
 			errMsg := ""
@@ -2029,3 +2255,222 @@ func TestInternalFlag_IsValid(t *testing.T) {
 		})
 	}
 }
+
+func TestInternalFlag_EvaluationDate(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	f := flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"A": testconvert.Interface("A"),
+			"B": testconvert.Interface("B"),
+			"C": testconvert.Interface("C"),
+		},
+		DefaultRule: &flag.Rule{
+			ProgressiveRollout: &flag.ProgressiveRollout{
+				Initial: &flag.ProgressiveRolloutStep{
+					Variation:  testconvert.String("A"),
+					Percentage: testconvert.Float64(0),
+					Date:       testconvert.Time(start),
+				},
+				End: &flag.ProgressiveRolloutStep{
+					Variation:  testconvert.String("B"),
+					Percentage: testconvert.Float64(100),
+					Date:       testconvert.Time(start.Add(24 * time.Hour)),
+				},
+			},
+		},
+		Experimentation: &flag.ExperimentationRollout{
+			Start: testconvert.Time(start.Add(-24 * time.Hour)),
+			End:   testconvert.Time(start.Add(72 * time.Hour)),
+		},
+		Scheduled: &[]flag.ScheduledStep{
+			{
+				InternalFlag: flag.InternalFlag{
+					DefaultRule: &flag.Rule{
+						ProgressiveRollout: &flag.ProgressiveRollout{
+							End: &flag.ProgressiveRolloutStep{Variation: testconvert.String("C")},
+						},
+					},
+				},
+				Date: testconvert.Time(start.Add(48 * time.Hour)),
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		evaluationDate time.Time
+		wantVariation  string
+		wantReason     string
+	}{
+		{
+			name:           "before the experimentation",
+			evaluationDate: start.Add(-48 * time.Hour),
+			wantVariation:  flag.VariationSDKDefault,
+			wantReason:     flag.ReasonDisabled,
+		},
+		{
+			name:           "before the progressive rollout",
+			evaluationDate: start.Add(-1 * time.Hour),
+			wantVariation:  "A",
+			wantReason:     flag.ReasonSplit,
+		},
+		{
+			name:           "after the progressive rollout",
+			evaluationDate: start.Add(25 * time.Hour),
+			wantVariation:  "B",
+			wantReason:     flag.ReasonSplit,
+		},
+		{
+			name:           "after the scheduled step",
+			evaluationDate: start.Add(49 * time.Hour),
+			wantVariation:  "C",
+			wantReason:     flag.ReasonSplit,
+		},
+		{
+			name:           "after the experimentation",
+			evaluationDate: start.Add(73 * time.Hour),
+			wantVariation:  flag.VariationSDKDefault,
+			wantReason:     flag.ReasonDisabled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, resolutionDetails := f.Value(
+				"my-flag",
+				ffcontext.NewEvaluationContext("user-1"),
+				flag.Context{DefaultSdkValue: "default", EvaluationDate: tt.evaluationDate},
+			)
+			assert.Equal(t, tt.wantVariation, resolutionDetails.Variant)
+			assert.Equal(t, tt.wantReason, resolutionDetails.Reason)
+		})
+	}
+}
+
+func (m membershipListsMock) Contains(listName string, value string) (bool, bool) {
+	list, ok := m[listName]
+	if !ok {
+		return false, false
+	}
+	return slices.Contains(list, value), true
+}
+
+func TestInternalFlag_MultiContext(t *testing.T) {
+	f := flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"enabled":  testconvert.Interface(true),
+			"disabled": testconvert.Interface(false),
+		},
+		Rules: &[]flag.Rule{
+			{
+				Name:            testconvert.String("pro-organizations"),
+				Query:           testconvert.String(`organization.plan eq "pro" and email ew "@example.com"`),
+				VariationResult: testconvert.String("enabled"),
+			},
+			{
+				Name:         testconvert.String("rollout-by-organization"),
+				Query:        testconvert.String(`organization.plan eq "free"`),
+				BucketingKey: testconvert.String("organization"),
+				Percentages: &map[string]float64{
+					"enabled":  50,
+					"disabled": 50,
+				},
+			},
+		},
+		DefaultRule: &flag.Rule{VariationResult: testconvert.String("disabled")},
+	}
+	assert.NoError(t, f.IsValid())
+
+	newContext := func(userKey string, orgKey string, plan string) ffcontext.MultiContext {
+		ctx, err := ffcontext.NewMultiContext(map[string]ffcontext.EvaluationContext{
+			"user": ffcontext.NewEvaluationContextBuilder(userKey).
+				AddCustom("email", userKey+"@example.com").Build(),
+			"organization": ffcontext.NewEvaluationContextBuilder(orgKey).AddCustom("plan", plan).Build(),
+		})
+		assert.NoError(t, err)
+		return ctx
+	}
+
+	tests := []struct {
+		name               string
+		ctx                ffcontext.Context
+		sameVariantAs      []ffcontext.Context
+		wantVariant        string
+		wantReason         flag.ResolutionReason
+		wantRuleName       *string
+		wantBucketingKey   string
+		wantBucketingValue string
+	}{
+		{
+			name:         "query on the attributes of several kinds",
+			ctx:          newContext("user-1", "org-1", "pro"),
+			wantVariant:  "enabled",
+			wantReason:   flag.ReasonTargetingMatch,
+			wantRuleName: testconvert.String("pro-organizations"),
+		},
+		{
+			name: "bucketing by organization",
+			ctx:  newContext("user-1", "org-2", "free"),
+			sameVariantAs: []ffcontext.Context{
+				newContext("user-2", "org-2", "free"),
+				newContext("user-3", "org-2", "free"),
+				newContext("user-4", "org-2", "free"),
+			},
+			wantReason:         flag.ReasonTargetingMatchSplit,
+			wantRuleName:       testconvert.String("rollout-by-organization"),
+			wantBucketingKey:   "organization",
+			wantBucketingValue: "org-2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flagCtx := flag.Context{DefaultSdkValue: false}
+			_, details := f.Value("my-flag", tt.ctx, flagCtx)
+			if tt.wantVariant != "" {
+				assert.Equal(t, tt.wantVariant, details.Variant)
+			}
+			assert.Equal(t, tt.wantReason, details.Reason)
+			assert.Equal(t, tt.wantRuleName, details.RuleName)
+			assert.Equal(t, tt.wantBucketingKey, details.BucketingKey)
+			assert.Equal(t, tt.wantBucketingValue, details.BucketingValue)
+			for _, ctx := range tt.sameVariantAs {
+				_, other := f.Value("my-flag", ctx, flagCtx)
+				assert.Equal(t, details.Variant, other.Variant, "all the users of an organization have the same variation")
+			}
+		})
+	}
+}
+
+func TestInternalFlag_QueryAttributes(t *testing.T) {
+	f := flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"enabled":  testconvert.Interface(true),
+			"disabled": testconvert.Interface(false),
+		},
+		Rules: &[]flag.Rule{
+			{
+				Query:           testconvert.String(`inList("paying", "company.id") and country eq "FR"`),
+				VariationResult: testconvert.String("enabled"),
+			},
+			{
+				Segment:         testconvert.String("beta-testers"),
+				VariationResult: testconvert.String("enabled"),
+			},
+		},
+		Environments: &map[string]flag.EnvironmentOverride{
+			"staging": {
+				Rules: &[]flag.Rule{
+					{
+						Query:           testconvert.String(`plan eq "pro" and country eq "FR"`),
+						VariationResult: testconvert.String("enabled"),
+					},
+				},
+			},
+		},
+		DefaultRule: &flag.Rule{VariationResult: testconvert.String("disabled")},
+	}
+	f.LinkSegments(map[string]flag.Segment{
+		"beta-testers": {Query: testconvert.String(`beta eq true`)},
+	})
+
+	assert.Equal(t, []string{"beta", "company.id", "country", "plan"}, f.QueryAttributes())
+}
@@ -0,0 +1,270 @@
+package flag
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+)
+
+// HoldoutSlice is the name of the slice recorded when the evaluation context is part of the global holdout.
+const HoldoutSlice = "holdout"
+
+// Layer is a group of mutually exclusive experiments, defined once in the configuration file.
+// The traffic of the layer is split in slices and each slice is allocated to a flag,
+// an evaluation context is part of at most one slice, so it sees at most one experiment of the layer.
+type Layer struct {
+	// BucketingKey (optional) is the attribute of the evaluation context used to allocate it to a slice.
+	// Default: the targeting key of the evaluation context.
+	BucketingKey *string `json:"bucketingKey,omitempty" yaml:"bucketingKey,omitempty" toml:"bucketingKey,omitempty" jsonschema:"title=bucketingKey,description=Attribute of the evaluation context used to allocate it to a slice of the layer. Default is the targeting key."` // nolint: lll
+
+	// Seed (optional) is the salt added to the bucketing value before hashing it.
+	// Default: the name of the layer.
+	Seed *string `json:"seed,omitempty" yaml:"seed,omitempty" toml:"seed,omitempty" jsonschema:"title=seed,description=Salt added to the bucketing value before hashing it. Default is the name of the layer."` // nolint: lll
+
+	// HashAlgorithm (optional) is the algorithm used to hash the bucketing value (fnv, murmur3 or sha1).
+	// It is defined on the layer and not taken from the flags, so all the flags of the layer see the same slices.
+	// Default: fnv
+	HashAlgorithm *HashAlgorithm `json:"hashAlgorithm,omitempty" yaml:"hashAlgorithm,omitempty" toml:"hashAlgorithm,omitempty" jsonschema:"enum=fnv,enum=murmur3,enum=sha1,title=hashAlgorithm,description=Algorithm used to hash the bucketing value. Default is fnv."` // nolint: lll
+
+	// Slices are the parts of the traffic allocated to each flag of the layer, in order.
+	// The sum of the percentages should be at most 100, the remaining traffic is not allocated to any flag.
+	Slices *[]LayerSlice `json:"slices,omitempty" yaml:"slices,omitempty" toml:"slices,omitempty" jsonschema:"required,title=slices,description=Parts of the traffic allocated to each flag of the layer."` // nolint: lll
+}
+
+// LayerSlice is a part of the traffic of a layer allocated to a flag.
+type LayerSlice struct {
+	// Flag is the key of the flag allocated to this slice.
+	Flag *string `json:"flag,omitempty" yaml:"flag,omitempty" toml:"flag,omitempty" jsonschema:"required,title=flag,description=Key of the flag allocated to this slice."` // nolint: lll
+
+	// Percentage is the part of the traffic of the layer in this slice.
+	Percentage *float64 `json:"percentage,omitempty" yaml:"percentage,omitempty" toml:"percentage,omitempty" jsonschema:"required,title=percentage,description=Part of the traffic of the layer allocated to the flag."` // nolint: lll
+}
+
+// Holdout is a part of the traffic that never sees any experiment of the layers.
+// It only applies to the flags part of a layer, the flags outside of the layers are evaluated as usual
+// for the evaluation contexts in the holdout.
+type Holdout struct {
+	// Percentage is the part of the traffic in the holdout.
+	Percentage *float64 `json:"percentage,omitempty" yaml:"percentage,omitempty" toml:"percentage,omitempty" jsonschema:"required,title=percentage,description=Part of the traffic that never sees any experiment of the layers."` // nolint: lll
+
+	// BucketingKey (optional) is the attribute of the evaluation context used to select the holdout.
+	// Default: the targeting key of the evaluation context.
+	BucketingKey *string `json:"bucketingKey,omitempty" yaml:"bucketingKey,omitempty" toml:"bucketingKey,omitempty" jsonschema:"title=bucketingKey,description=Attribute of the evaluation context used to select the holdout. Default is the targeting key."` // nolint: lll
+
+	// Seed (optional) is the salt added to the bucketing value before hashing it.
+	// Default: holdout
+	Seed *string `json:"seed,omitempty" yaml:"seed,omitempty" toml:"seed,omitempty" jsonschema:"title=seed,description=Salt added to the bucketing value before hashing it. Default is holdout."` // nolint: lll
+
+	// HashAlgorithm (optional) is the algorithm used to hash the bucketing value (fnv, murmur3 or sha1).
+	// Default: fnv
+	HashAlgorithm *HashAlgorithm `json:"hashAlgorithm,omitempty" yaml:"hashAlgorithm,omitempty" toml:"hashAlgorithm,omitempty" jsonschema:"enum=fnv,enum=murmur3,enum=sha1,title=hashAlgorithm,description=Algorithm used to hash the bucketing value. Default is fnv."` // nolint: lll
+}
+
+// LayerAllocation is the layer of a flag and the global holdout, attached to the flag when loading it.
+type LayerAllocation struct {
+	// Name is the name of the layer.
+	Name string `json:"name,omitempty" yaml:"name,omitempty" toml:"name,omitempty"`
+
+	// Layer is the definition of the layer.
+	Layer Layer `json:"layer,omitempty" yaml:"layer,omitempty" toml:"layer,omitempty"`
+
+	// Holdout (optional) is the global holdout.
+	Holdout *Holdout `json:"holdout,omitempty" yaml:"holdout,omitempty" toml:"holdout,omitempty"`
+}
+
+// layerSelection is the result of the allocation of an evaluation context in the layer of a flag.
+type layerSelection struct {
+	// allocated is true if the evaluation context can see the experiment of the flag
+	allocated bool
+
+	// layer is the name of the layer
+	layer string
+
+	// slice is the flag of the slice where the evaluation context is, HoldoutSlice if it is part of the holdout
+	// and empty if it is in the traffic not allocated to any flag
+	slice string
+}
+
+// IsValid is checking if the layer is valid.
+func (l *Layer) IsValid() error {
+	if len(l.GetSlices()) == 0 {
+		return fmt.Errorf("a layer should have at least one slice")
+	}
+	total := float64(0)
+	flags := make(map[string]bool)
+	for _, slice := range l.GetSlices() {
+		if slice.GetFlag() == "" {
+			return fmt.Errorf("a slice should have a flag")
+		}
+		if flags[slice.GetFlag()] {
+			return fmt.Errorf("the flag %s has several slices in the layer", slice.GetFlag())
+		}
+		flags[slice.GetFlag()] = true
+		if slice.GetPercentage() < 0 {
+			return fmt.Errorf("the percentage of the slice of the flag %s should be positive", slice.GetFlag())
+		}
+		total += slice.GetPercentage()
+	}
+	if total > 100 {
+		return fmt.Errorf("the sum of the percentages of the slices should be at most 100, got %v", total)
+	}
+	return isValidHashAlgorithm(l.GetHashAlgorithm())
+}
+
+// IsValid is checking if the holdout is valid.
+func (h *Holdout) IsValid() error {
+	if h.GetPercentage() < 0 || h.GetPercentage() > 100 {
+		return fmt.Errorf("the percentage of the holdout should be between 0 and 100")
+	}
+	if err := isValidHashAlgorithm(h.GetHashAlgorithm()); err != nil {
+		return fmt.Errorf("invalid holdout: %w", err)
+	}
+	return nil
+}
+
+// GetBucketingKey is the getter of the field BucketingKey
+func (l *Layer) GetBucketingKey() string {
+	if l.BucketingKey == nil {
+		return ""
+	}
+	return *l.BucketingKey
+}
+
+// GetHashAlgorithm is the getter of the field HashAlgorithm
+func (l *Layer) GetHashAlgorithm() HashAlgorithm {
+	if l.HashAlgorithm == nil {
+		return HashAlgorithmFNV
+	}
+	return *l.HashAlgorithm
+}
+
+// GetSlices is the getter of the field Slices
+func (l *Layer) GetSlices() []LayerSlice {
+	if l.Slices == nil {
+		return []LayerSlice{}
+	}
+	return *l.Slices
+}
+
+// GetFlag is the getter of the field Flag
+func (s *LayerSlice) GetFlag() string {
+	if s.Flag == nil {
+		return ""
+	}
+	return *s.Flag
+}
+
+// GetPercentage is the getter of the field Percentage
+func (s *LayerSlice) GetPercentage() float64 {
+	if s.Percentage == nil {
+		return 0
+	}
+	return *s.Percentage
+}
+
+// GetPercentage is the getter of the field Percentage
+func (h *Holdout) GetPercentage() float64 {
+	if h.Percentage == nil {
+		return 0
+	}
+	return *h.Percentage
+}
+
+// GetBucketingKey is the getter of the field BucketingKey
+func (h *Holdout) GetBucketingKey() string {
+	if h.BucketingKey == nil {
+		return ""
+	}
+	return *h.BucketingKey
+}
+
+// GetHashAlgorithm is the getter of the field HashAlgorithm
+func (h *Holdout) GetHashAlgorithm() HashAlgorithm {
+	if h.HashAlgorithm == nil {
+		return HashAlgorithmFNV
+	}
+	return *h.HashAlgorithm
+}
+
+// IsValid is checking if the layer and the holdout of the flag are valid.
+func (a *LayerAllocation) IsValid() error {
+	if err := a.Layer.IsValid(); err != nil {
+		return fmt.Errorf("invalid layer %s: %w", a.Name, err)
+	}
+	if a.Holdout != nil {
+		if err := a.Holdout.IsValid(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectSlice checks if the evaluation context is allocated to the flag in its layer.
+// The global holdout is checked first, then the evaluation context is affected to a slice of the layer.
+// The buckets are computed with the seed and the hash algorithm of the layer (and of the holdout), not the ones
+// of the flag, so every flag of the layer puts an evaluation context in the same slice.
+// An evaluation context without the bucketing attribute is not allocated.
+func (a *LayerAllocation) selectSlice(flagName string, ctx ffcontext.Context) layerSelection {
+	selection := layerSelection{layer: a.Name}
+	if a.Holdout != nil {
+		seed := HoldoutSlice
+		if a.Holdout.Seed != nil {
+			seed = *a.Holdout.Seed
+		}
+		value, err := getBucketingValue(ctx, a.Holdout.GetBucketingKey())
+		if err != nil {
+			return selection
+		}
+		bucket := float64(hashBucket(a.Holdout.GetHashAlgorithm(), seed, value))
+		if bucket < a.Holdout.GetPercentage()*PercentageMultiplier {
+			selection.slice = HoldoutSlice
+			return selection
+		}
+	}
+
+	seed := a.Name
+	if a.Layer.Seed != nil {
+		seed = *a.Layer.Seed
+	}
+	value, err := getBucketingValue(ctx, a.Layer.GetBucketingKey())
+	if err != nil {
+		return selection
+	}
+	bucket := float64(hashBucket(a.Layer.GetHashAlgorithm(), seed, value))
+	start := float64(0)
+	for _, slice := range a.Layer.GetSlices() {
+		end := start + slice.GetPercentage()*PercentageMultiplier
+		if bucket >= start && bucket < end {
+			selection.slice = slice.GetFlag()
+			selection.allocated = slice.GetFlag() == flagName
+			return selection
+		}
+		start = end
+	}
+	return selection
+}
+
+// LinkLayer attaches to the flag the layer that contains it and the global holdout.
+// It returns an error if the flag is part of several layers.
+func (f *InternalFlag) LinkLayer(flagName string, layers map[string]Layer, holdout *Holdout) error {
+	f.Layer = nil
+	layerNames := make([]string, 0, len(layers))
+	for name := range layers {
+		layerNames = append(layerNames, name)
+	}
+	sort.Strings(layerNames)
+
+	for _, name := range layerNames {
+		layer := layers[name]
+		if !slices.ContainsFunc(layer.GetSlices(), func(s LayerSlice) bool { return s.GetFlag() == flagName }) {
+			continue
+		}
+		if f.Layer != nil {
+			return fmt.Errorf("the flag is part of several layers: %s and %s", f.Layer.Name, name)
+		}
+		f.Layer = &LayerAllocation{Name: name, Layer: layer, Holdout: holdout}
+	}
+	return nil
+}
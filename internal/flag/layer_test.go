@@ -0,0 +1,234 @@
+package flag_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+func TestInternalFlag_Layers(t *testing.T) {
+	checkoutSlices := &[]flag.LayerSlice{
+		{Flag: testconvert.String("flag-a"), Percentage: testconvert.Float64(40)},
+		{Flag: testconvert.String("flag-b"), Percentage: testconvert.Float64(40)},
+	}
+	tests := []struct {
+		name string
+		// layer is the layer checkout containing flag-a and flag-b
+		layer   flag.Layer
+		holdout *flag.Holdout
+		// flagHashAlgorithm is the hash algorithm of flag-a, it should not change the allocation
+		flagHashAlgorithm *string
+		wantSlices        []string
+	}{
+		{
+			name:       "layer without holdout",
+			layer:      flag.Layer{Slices: checkoutSlices},
+			wantSlices: []string{"flag-a", "flag-b", ""},
+		},
+		{
+			name:       "layer with a global holdout",
+			layer:      flag.Layer{Slices: checkoutSlices},
+			holdout:    &flag.Holdout{Percentage: testconvert.Float64(10)},
+			wantSlices: []string{"flag-a", "flag-b", flag.HoldoutSlice, ""},
+		},
+		{
+			name: "hash algorithms of the layer and of the holdout",
+			layer: flag.Layer{
+				Slices:        checkoutSlices,
+				Seed:          testconvert.String("checkout-v2"),
+				HashAlgorithm: testconvert.String(flag.HashAlgorithmMurmur3),
+			},
+			holdout: &flag.Holdout{
+				Percentage:    testconvert.Float64(10),
+				HashAlgorithm: testconvert.String(flag.HashAlgorithmSHA1),
+			},
+			wantSlices: []string{"flag-a", "flag-b", flag.HoldoutSlice, ""},
+		},
+		{
+			name:              "hash algorithm of a flag of the layer",
+			layer:             flag.Layer{Slices: checkoutSlices},
+			holdout:           &flag.Holdout{Percentage: testconvert.Float64(10)},
+			flagHashAlgorithm: testconvert.String(flag.HashAlgorithmSHA1),
+			wantSlices:        []string{"flag-a", "flag-b", flag.HoldoutSlice, ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layers := map[string]flag.Layer{"checkout": tt.layer}
+			flags := map[string]*flag.InternalFlag{}
+			for _, flagName := range []string{"flag-a", "flag-b"} {
+				f := &flag.InternalFlag{
+					Variations: &map[string]*interface{}{
+						"control":   testconvert.Interface("control"),
+						"treatment": testconvert.Interface("treatment"),
+					},
+					DefaultRule: &flag.Rule{VariationResult: testconvert.String("treatment")},
+				}
+				if flagName == "flag-a" {
+					f.HashAlgorithm = tt.flagHashAlgorithm
+				}
+				assert.NoError(t, f.LinkLayer(flagName, layers, tt.holdout))
+				assert.NoError(t, f.IsValid())
+				flags[flagName] = f
+			}
+
+			// an evaluation context sees at most one flag of the layer
+			slices := map[string]int{}
+			for i := 0; i < 500; i++ {
+				ctx := ffcontext.NewEvaluationContext(fmt.Sprintf("user-%d", i))
+				_, detailsA, trace := flags["flag-a"].Explain("flag-a", ctx, flag.Context{DefaultSdkValue: "default"})
+				_, detailsB := flags["flag-b"].Value("flag-b", ctx, flag.Context{DefaultSdkValue: "default"})
+				assert.Equal(t, "checkout", detailsA.Layer)
+				assert.Equal(t, detailsA.LayerSlice, detailsB.LayerSlice)
+				slices[detailsA.LayerSlice]++
+
+				switch detailsA.LayerSlice {
+				case "flag-a":
+					assert.Equal(t, "treatment", detailsA.Variant)
+					assert.Equal(t, flag.ReasonNotAllocated, detailsB.Reason)
+				case "flag-b":
+					assert.Equal(t, flag.ReasonNotAllocated, detailsA.Reason)
+					assert.Equal(t, "treatment", detailsB.Variant)
+				default:
+					assert.Equal(t, flag.ReasonNotAllocated, detailsA.Reason)
+					assert.Equal(t, flag.ReasonNotAllocated, detailsB.Reason)
+					assert.Equal(t, flag.VariationSDKDefault, detailsA.Variant)
+				}
+				if detailsA.Reason == flag.ReasonNotAllocated {
+					assert.Equal(t, flag.TraceStepLayer, trace.Steps[len(trace.Steps)-1].Type)
+				}
+			}
+			assert.Len(t, slices, len(tt.wantSlices))
+			for _, slice := range tt.wantSlices {
+				assert.Greater(t, slices[slice], 0, "no evaluation context in the slice %q", slice)
+			}
+		})
+	}
+}
+
+// TestInternalFlag_HoldoutScope checks that the global holdout only applies to the flags of a layer.
+func TestInternalFlag_HoldoutScope(t *testing.T) {
+	layers := map[string]flag.Layer{
+		"checkout": {
+			Slices: &[]flag.LayerSlice{{Flag: testconvert.String("layered-flag"), Percentage: testconvert.Float64(100)}},
+		},
+	}
+	// all the evaluation contexts are in the holdout
+	holdout := &flag.Holdout{Percentage: testconvert.Float64(100)}
+	tests := []struct {
+		name           string
+		flagName       string
+		wantVariant    string
+		wantReason     string
+		wantLayer      string
+		wantLayerSlice string
+	}{
+		{
+			name:           "flag of a layer",
+			flagName:       "layered-flag",
+			wantVariant:    flag.VariationSDKDefault,
+			wantReason:     flag.ReasonNotAllocated,
+			wantLayer:      "checkout",
+			wantLayerSlice: flag.HoldoutSlice,
+		},
+		{
+			name:        "flag outside of the layers",
+			flagName:    "standalone-flag",
+			wantVariant: "treatment",
+			wantReason:  flag.ReasonStatic,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := flag.InternalFlag{
+				Variations: &map[string]*interface{}{
+					"control":   testconvert.Interface("control"),
+					"treatment": testconvert.Interface("treatment"),
+				},
+				DefaultRule: &flag.Rule{VariationResult: testconvert.String("treatment")},
+			}
+			assert.NoError(t, f.LinkLayer(tt.flagName, layers, holdout))
+			assert.NoError(t, f.IsValid())
+
+			for i := 0; i < 100; i++ {
+				ctx := ffcontext.NewEvaluationContext(fmt.Sprintf("user-%d", i))
+				_, got := f.Value(tt.flagName, ctx, flag.Context{DefaultSdkValue: "default"})
+				assert.Equal(t, tt.wantVariant, got.Variant)
+				assert.Equal(t, tt.wantReason, got.Reason)
+				assert.Equal(t, tt.wantLayer, got.Layer)
+				assert.Equal(t, tt.wantLayerSlice, got.LayerSlice)
+			}
+		})
+	}
+}
+
+func TestInternalFlag_IsValidLayers(t *testing.T) {
+	checkout := flag.Layer{
+		Slices: &[]flag.LayerSlice{{Flag: testconvert.String("flag-a"), Percentage: testconvert.Float64(50)}},
+	}
+	tests := []struct {
+		name    string
+		layers  map[string]flag.Layer
+		holdout *flag.Holdout
+		wantErr string
+	}{
+		{
+			name:   "valid layer",
+			layers: map[string]flag.Layer{"checkout": checkout},
+		},
+		{
+			name: "a flag cannot be part of several layers",
+			layers: map[string]flag.Layer{
+				"checkout": checkout,
+				"search":   checkout,
+			},
+			wantErr: "the flag is part of several layers: checkout and search",
+		},
+		{
+			name: "the slices of a layer cannot exceed 100%",
+			layers: map[string]flag.Layer{
+				"checkout": {
+					Slices: &[]flag.LayerSlice{
+						{Flag: testconvert.String("flag-a"), Percentage: testconvert.Float64(60)},
+						{Flag: testconvert.String("flag-b"), Percentage: testconvert.Float64(60)},
+					},
+				},
+			},
+			wantErr: "invalid layer checkout: the sum of the percentages of the slices should be at most 100, got 120",
+		},
+		{
+			name: "unknown hash algorithm of the layer",
+			layers: map[string]flag.Layer{
+				"checkout": {Slices: checkout.Slices, HashAlgorithm: testconvert.String("md5")},
+			},
+			wantErr: "invalid layer checkout: unknown hash algorithm: md5",
+		},
+		{
+			name:    "unknown hash algorithm of the holdout",
+			layers:  map[string]flag.Layer{"checkout": checkout},
+			holdout: &flag.Holdout{Percentage: testconvert.Float64(10), HashAlgorithm: testconvert.String("md5")},
+			wantErr: "invalid holdout: unknown hash algorithm: md5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := flag.InternalFlag{
+				Variations:  &map[string]*interface{}{"enabled": testconvert.Interface(true)},
+				DefaultRule: &flag.Rule{VariationResult: testconvert.String("enabled")},
+			}
+			err := f.LinkLayer("flag-a", tt.layers, tt.holdout)
+			if err == nil {
+				err = f.IsValid()
+			}
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
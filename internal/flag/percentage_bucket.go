@@ -6,3 +6,16 @@ type percentageBucket struct {
 	start float64
 	end   float64
 }
+
+// bucket is an internal representation of the hash used to affect an evaluation context
+// to a percentage bucket.
+type bucket struct {
+	// key is the attribute used to bucket the evaluation context, empty if the targeting key is used
+	key string
+
+	// value of the bucketing attribute for the evaluation context
+	value string
+
+	// hashID is the hash of the flag name and the value, between 0 and MaxPercentage
+	hashID uint32
+}
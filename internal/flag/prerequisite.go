@@ -0,0 +1,40 @@
+package flag
+
+import "fmt"
+
+// Prerequisite is a condition on another flag that should be fulfilled before evaluating
+// the targeting of a flag.
+type Prerequisite struct {
+	// Key is the key of the flag used as prerequisite.
+	Key *string `json:"key,omitempty" yaml:"key,omitempty" toml:"key,omitempty" jsonschema:"required,title=key,description=Key of the flag used as prerequisite."` // nolint: lll
+
+	// Variation is the name of the variation the prerequisite flag should resolve to.
+	Variation *string `json:"variation,omitempty" yaml:"variation,omitempty" toml:"variation,omitempty" jsonschema:"required,title=variation,description=Name of the variation the prerequisite flag should resolve to for the same evaluation context."` // nolint: lll
+}
+
+// IsValid is checking if the prerequisite is valid.
+func (p *Prerequisite) IsValid() error {
+	if p.GetKey() == "" {
+		return fmt.Errorf("a prerequisite should have a key")
+	}
+	if p.GetVariation() == "" {
+		return fmt.Errorf("the prerequisite %s should have a variation", p.GetKey())
+	}
+	return nil
+}
+
+// GetKey is the getter of the field Key
+func (p *Prerequisite) GetKey() string {
+	if p.Key == nil {
+		return ""
+	}
+	return *p.Key
+}
+
+// GetVariation is the getter of the field Variation
+func (p *Prerequisite) GetVariation() string {
+	if p.Variation == nil {
+		return ""
+	}
+	return *p.Variation
+}
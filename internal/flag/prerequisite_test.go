@@ -0,0 +1,133 @@
+package flag_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+func TestInternalFlag_Prerequisites(t *testing.T) {
+	parentFlags := map[string]flag.Flag{
+		"parent-enabled": &flag.InternalFlag{
+			Variations: &map[string]*interface{}{
+				"on":  testconvert.Interface(true),
+				"off": testconvert.Interface(false),
+			},
+			DefaultRule: &flag.Rule{VariationResult: testconvert.String("on")},
+		},
+		"parent-disabled": &flag.InternalFlag{
+			Variations: &map[string]*interface{}{
+				"on":  testconvert.Interface(true),
+				"off": testconvert.Interface(false),
+			},
+			DefaultRule: &flag.Rule{VariationResult: testconvert.String("on")},
+			Disable:     testconvert.Bool(true),
+		},
+	}
+	flagGetter := func(flagKey string) (flag.Flag, error) {
+		f, ok := parentFlags[flagKey]
+		if !ok {
+			return nil, fmt.Errorf("flag [%v] does not exists", flagKey)
+		}
+		return f, nil
+	}
+
+	tests := []struct {
+		name          string
+		prerequisites []flag.Prerequisite
+		fallback      *string
+		flagGetter    func(flagKey string) (flag.Flag, error)
+		want          interface{}
+		want1         flag.ResolutionDetails
+	}{
+		{
+			name: "prerequisite fulfilled",
+			prerequisites: []flag.Prerequisite{
+				{Key: testconvert.String("parent-enabled"), Variation: testconvert.String("on")},
+			},
+			fallback:   testconvert.String("fallback"),
+			flagGetter: flagGetter,
+			want:       "B",
+			want1: flag.ResolutionDetails{
+				Variant:   "B",
+				Reason:    flag.ReasonStatic,
+				Cacheable: true,
+			},
+		},
+		{
+			name: "prerequisite not fulfilled should serve the fallback variation",
+			prerequisites: []flag.Prerequisite{
+				{Key: testconvert.String("parent-enabled"), Variation: testconvert.String("off")},
+			},
+			fallback:   testconvert.String("fallback"),
+			flagGetter: flagGetter,
+			want:       "F",
+			want1: flag.ResolutionDetails{
+				Variant: "fallback",
+				Reason:  flag.ReasonPrerequisiteFailed,
+			},
+		},
+		{
+			name: "disabled prerequisite should serve the SDK default without fallback variation",
+			prerequisites: []flag.Prerequisite{
+				{Key: testconvert.String("parent-disabled"), Variation: testconvert.String("on")},
+			},
+			flagGetter: flagGetter,
+			want:       "default-sdk",
+			want1: flag.ResolutionDetails{
+				Variant: flag.VariationSDKDefault,
+				Reason:  flag.ReasonPrerequisiteFailed,
+			},
+		},
+		{
+			name: "unknown prerequisite flag",
+			prerequisites: []flag.Prerequisite{
+				{Key: testconvert.String("unknown"), Variation: testconvert.String("on")},
+			},
+			fallback:   testconvert.String("fallback"),
+			flagGetter: flagGetter,
+			want:       "F",
+			want1: flag.ResolutionDetails{
+				Variant: "fallback",
+				Reason:  flag.ReasonPrerequisiteFailed,
+			},
+		},
+		{
+			name: "no flag getter in the context",
+			prerequisites: []flag.Prerequisite{
+				{Key: testconvert.String("parent-enabled"), Variation: testconvert.String("on")},
+			},
+			fallback: testconvert.String("fallback"),
+			want:     "F",
+			want1: flag.ResolutionDetails{
+				Variant: "fallback",
+				Reason:  flag.ReasonPrerequisiteFailed,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prerequisites := tt.prerequisites
+			f := flag.InternalFlag{
+				Variations: &map[string]*interface{}{
+					"A":        testconvert.Interface("A"),
+					"B":        testconvert.Interface("B"),
+					"fallback": testconvert.Interface("F"),
+				},
+				DefaultRule:       &flag.Rule{VariationResult: testconvert.String("B")},
+				Prerequisites:     &prerequisites,
+				FallbackVariation: tt.fallback,
+			}
+			got, got1 := f.Value("child-flag", ffcontext.NewEvaluationContext("user-key"), flag.Context{
+				DefaultSdkValue: "default-sdk",
+				FlagGetter:      tt.flagGetter,
+			})
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want1, got1)
+		})
+	}
+}
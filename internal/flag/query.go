@@ -0,0 +1,236 @@
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/nikunjy/rules/parser"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/query"
+)
+
+// QueryFormat is the language used to write the query of a rule.
+type QueryFormat = string
+
+const (
+	// QueryFormatNikunjy is the default query format, based on the nikunjy/rules syntax.
+	QueryFormatNikunjy QueryFormat = "nikunjy"
+	// QueryFormatJSONLogic is the JSONLogic format (https://jsonlogic.com), compatible with flagd.
+	QueryFormatJSONLogic QueryFormat = "jsonlogic"
+	// QueryFormatCEL is the Common Expression Language format (https://cel.dev).
+	QueryFormatCEL QueryFormat = "cel"
+)
+
+// queryCompilers contains the function to parse a query for each supported format.
+var queryCompilers = map[QueryFormat]func(q string) (query.Expression, error){
+	QueryFormatNikunjy:   compileNikunjyQuery,
+	QueryFormatJSONLogic: query.CompileJSONLogic,
+	QueryFormatCEL:       query.CompileCEL,
+}
+
+// nikunjyAttributeRegexp finds the attributes compared in a query in the nikunjy/rules format.
+var nikunjyAttributeRegexp = regexp.MustCompile(
+	`(?i)\b([a-z_][\w.]*)\s*(?:(?:\s(?:eq|ne|lt|gt|le|ge|co|sw|ew|in|pr)\b)|==|!=|<=|>=|<|>)`)
+
+// nikunjyStringRegexp finds the string literals of a query in the nikunjy/rules format.
+var nikunjyStringRegexp = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+
+// nikunjyQuery is a query in the nikunjy/rules format ready to be evaluated.
+type nikunjyQuery struct {
+	query string
+	// evaluators contains the parsed query, a parser.Evaluator keeps the state of its last evaluation
+	// so each evaluation takes its own evaluator from the pool.
+	evaluators sync.Pool
+}
+
+func compileNikunjyQuery(q string) (query.Expression, error) {
+	trimmed := trimQuery(q)
+	if err := parseNikunjyQuery(trimmed); err != nil {
+		return nil, err
+	}
+	return &nikunjyQuery{
+		query: trimmed,
+		evaluators: sync.Pool{New: func() interface{} {
+			evaluator, err := parser.NewEvaluator(trimmed)
+			if err != nil {
+				return nil
+			}
+			return evaluator
+		}},
+	}, nil
+}
+
+// Attributes returns the attributes of the evaluation context compared in the query.
+func (n *nikunjyQuery) Attributes() []string {
+	attributes := make([]string, 0)
+	withoutStrings := nikunjyStringRegexp.ReplaceAllString(n.query, `""`)
+	for _, match := range nikunjyAttributeRegexp.FindAllStringSubmatch(withoutStrings, -1) {
+		attribute := match[1]
+		if isNikunjyKeyword(attribute) || slices.Contains(attributes, attribute) {
+			continue
+		}
+		attributes = append(attributes, attribute)
+	}
+	return attributes
+}
+
+// isNikunjyKeyword checks if a word is an operator or a literal of the nikunjy/rules format.
+func isNikunjyKeyword(word string) bool {
+	switch strings.ToLower(word) {
+	case "and", "or", "not", "true", "false", "null":
+		return true
+	default:
+		return false
+	}
+}
+
+// nikunjySyntaxErrorListener keeps the first syntax error found while parsing a query.
+type nikunjySyntaxErrorListener struct {
+	*antlr.DefaultErrorListener
+	err error
+}
+
+func (l *nikunjySyntaxErrorListener) SyntaxError(
+	_ antlr.Recognizer, _ interface{}, _, column int, msg string, _ antlr.RecognitionException,
+) {
+	if l.err == nil {
+		l.err = fmt.Errorf("syntax error at position %d: %s", column, msg)
+	}
+}
+
+// parseNikunjyQuery parses the query and returns its first syntax error.
+// We are not using parser.NewEvaluator because it ignores the syntax errors.
+func parseNikunjyQuery(q string) (err error) {
+	// antlr panics for some exceptions
+	defer func() {
+		if info := recover(); info != nil {
+			err = fmt.Errorf("%q", info)
+		}
+	}()
+	listener := &nikunjySyntaxErrorListener{DefaultErrorListener: antlr.NewDefaultErrorListener()}
+	lexer := parser.NewJsonQueryLexer(antlr.NewInputStream(q))
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(listener)
+	p := parser.NewJsonQueryParser(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+	p.RemoveErrorListeners()
+	p.AddErrorListener(listener)
+	p.Query()
+	return listener.err
+}
+
+// Evaluate checks if the evaluation context matches the query.
+// An evaluation with an invalid operation (ex: comparing a string with a number) returns an error.
+func (n *nikunjyQuery) Evaluate(ctxMap map[string]interface{}) (bool, error) {
+	evaluator, ok := n.evaluators.Get().(*parser.Evaluator)
+	if !ok {
+		return false, errors.New("impossible to parse the query")
+	}
+	defer n.evaluators.Put(evaluator)
+	return evaluator.Process(ctxMap)
+}
+
+// compileQuery returns the parsed version of the query, it returns an error if the query is not valid.
+func compileQuery(format QueryFormat, q string) (query.Expression, error) {
+	compiler, ok := queryCompilers[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown query format: %s", format)
+	}
+	return compiler(q)
+}
+
+// compiledRules contains the rules of a flag ready to be evaluated, indexed by the fields used to compile them.
+// It is created when the flag is loaded in the cache and shared by all the copies of the flag (ex: the flag with
+// the changes of a scheduled step), so a rule is expanded and parsed only once.
+type compiledRules struct {
+	// rules maps a compiledRuleKey to its *compiledRule.
+	rules sync.Map
+}
+
+// compiledRuleKey contains the fields of a rule used to compile it.
+type compiledRuleKey struct {
+	name        string
+	query       string
+	queryFormat QueryFormat
+	segment     string
+}
+
+// get returns the compiled version of the rule, the rule is compiled the first time it is requested.
+func (c *compiledRules) get(r *Rule, segments map[string]Segment) *compiledRule {
+	key := compiledRuleKey{
+		name: r.GetName(), query: r.GetQuery(), queryFormat: r.GetQueryFormat(), segment: r.GetSegment()}
+	if compiled, ok := c.rules.Load(key); ok {
+		return compiled.(*compiledRule)
+	}
+	compiled, _ := c.rules.LoadOrStore(key, r.compile(segments))
+	return compiled.(*compiledRule)
+}
+
+// compiledRule contains the queries of a rule and of the segment it references, ready to be evaluated.
+type compiledRule struct {
+	// segmentQuery is the query of the segment of the rule, nil if the rule has no segment.
+	segmentQuery *compiledQuery
+	// ruleQuery is the query of the rule, nil if the rule has no query.
+	ruleQuery *compiledQuery
+	// attributes are the attributes of the evaluation context used by the queries.
+	attributes []string
+}
+
+// validate checks that the query of the rule and of its segment can be parsed.
+func (c *compiledRule) validate() error {
+	for _, q := range []*compiledQuery{c.segmentQuery, c.ruleQuery} {
+		if q != nil && q.err != nil {
+			return q.err
+		}
+	}
+	return nil
+}
+
+// compiledQuery is a query ready to be evaluated, with the functions it uses.
+type compiledQuery struct {
+	expression query.Expression
+	calls      []queryFunctionCall
+	// err is the error of the compilation, a query that cannot be compiled never matches.
+	err error
+}
+
+// newCompiledQuery parses a query, the functions of the query have to be already replaced by the check of
+// the attribute containing their result.
+func newCompiledQuery(format QueryFormat, q string, calls []queryFunctionCall) *compiledQuery {
+	expression, err := compileQuery(format, q)
+	return &compiledQuery{expression: expression, calls: calls, err: err}
+}
+
+// matches checks if the evaluation context matches the query, after adding to the evaluation context map the
+// result of the functions of the query.
+func (q *compiledQuery) matches(
+	ctx ffcontext.Context, ctxMap map[string]interface{}, lists MembershipLists) bool {
+	if q.err != nil {
+		return false
+	}
+	for _, call := range q.calls {
+		ctxMap[call.attributeName()] = call.evaluate(ctx, lists)
+	}
+	match, err := q.expression.Evaluate(ctxMap)
+	return err == nil && match
+}
+
+// getAttributes returns the attributes of the evaluation context used by the query and its functions.
+func (q *compiledQuery) getAttributes() []string {
+	attributes := make([]string, 0)
+	if referencer, ok := q.expression.(query.AttributesReferencer); ok && q.err == nil {
+		// the attributes containing the result of the functions are added during the evaluation,
+		// the attributes read by the functions are used instead
+		attributes = slices.DeleteFunc(referencer.Attributes(), isQueryFunctionAttribute)
+	}
+	for _, call := range q.calls {
+		if call.attribute() != "" {
+			attributes = append(attributes, call.attribute())
+		}
+	}
+	return attributes
+}
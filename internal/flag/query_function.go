@@ -0,0 +1,171 @@
+package flag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/query"
+	"github.com/thomaspoignant/go-feature-flag/internal/utils"
+)
+
+const (
+	// queryFunctionInList checks if an attribute of the evaluation context is part of a membership list:
+	// inList("name") for the targeting key or inList("name", "attribute").
+	queryFunctionInList = "inList"
+	// queryFunctionSemVer compares an attribute of the evaluation context with a semantic version:
+	// semver("attribute", ">=", "1.10.0"), the operators are =, !=, <, <=, >, >=, ^ and ~.
+	queryFunctionSemVer = "semver"
+	// queryFunctionInCIDR checks if the IP address in an attribute of the evaluation context is part of
+	// an IPv4 or IPv6 network: inCIDR("attribute", "10.0.0.0/8").
+	queryFunctionInCIDR = "inCIDR"
+)
+
+// queryFunctionRegex matches the functions of the nikunjy query format, their arguments are string literals.
+var queryFunctionRegex = regexp.MustCompile(`\b(inList|semver|inCIDR)\(\s*((?:"[^"]*"\s*,\s*)*"[^"]*")\s*\)`)
+
+// queryFunctionArgRegex matches an argument of a function.
+var queryFunctionArgRegex = regexp.MustCompile(`"([^"]*)"`)
+
+// queryFunctionAttributePrefix is the prefix of the attributes added to the evaluation context
+// with the result of the functions.
+const queryFunctionAttributePrefix = "goff_fn_"
+
+// MembershipLists gives access to the large lists of values loaded from their own retrievers
+// and referenced in the rules with the inList operator.
+type MembershipLists interface {
+	// Contains checks if a value is part of the list, ok is false if the list is not available.
+	Contains(listName string, value string) (contains bool, ok bool)
+}
+
+// queryFunctionCall is a function used in a query in the nikunjy format.
+// The nikunjy format cannot be extended, so each function is computed before the evaluation of the query,
+// and replaced in the query by the check of an attribute containing its result.
+type queryFunctionCall struct {
+	// name is the name of the function
+	name string
+
+	// args are the arguments of the function
+	args []string
+}
+
+// attributeName returns the name of the attribute added to the evaluation context with the result of the function.
+func (c queryFunctionCall) attributeName() string {
+	return fmt.Sprintf("%s%d", queryFunctionAttributePrefix,
+		utils.Hash(c.name+"("+strings.Join(c.args, ",")+")"))
+}
+
+// IsValid is checking if the number of arguments and the literals of the function are valid.
+func (c queryFunctionCall) IsValid() error {
+	switch c.name {
+	case queryFunctionInList:
+		if len(c.args) != 1 && len(c.args) != 2 {
+			return fmt.Errorf("inList expects the name of the list and an optional attribute")
+		}
+	case queryFunctionSemVer:
+		if len(c.args) != 3 {
+			return fmt.Errorf("semver expects an attribute, an operator and a version")
+		}
+		if err := query.CheckSemver(c.args[1], c.args[2]); err != nil {
+			return err
+		}
+	case queryFunctionInCIDR:
+		if len(c.args) != 2 {
+			return fmt.Errorf("inCIDR expects an attribute and a network")
+		}
+		if err := query.CheckCIDR(c.args[1]); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown function: %s", c.name)
+	}
+	return nil
+}
+
+// attribute returns the attribute of the evaluation context used by the function, empty for the targeting key.
+func (c queryFunctionCall) attribute() string {
+	switch {
+	case c.name != queryFunctionInList && len(c.args) > 0:
+		return c.args[0]
+	case c.name == queryFunctionInList && len(c.args) == 2:
+		return c.args[1]
+	default:
+		return ""
+	}
+}
+
+// evaluate computes the result of the function for the evaluation context.
+// An invalid function, an evaluation context without the attribute or a list not available never matches.
+func (c queryFunctionCall) evaluate(ctx ffcontext.Context, lists MembershipLists) bool {
+	if c.IsValid() != nil {
+		return false
+	}
+	value, err := getBucketingValue(ctx, c.attribute())
+	if err != nil {
+		return false
+	}
+
+	switch c.name {
+	case queryFunctionInList:
+		if lists == nil {
+			return false
+		}
+		contains, _ := lists.Contains(c.args[0], value)
+		return contains
+	case queryFunctionSemVer:
+		match, err := query.MatchSemver(value, c.args[1], c.args[2])
+		return err == nil && match
+	default:
+		match, err := query.MatchCIDR(value, c.args[1])
+		return err == nil && match
+	}
+}
+
+// extractQueryFunctionCalls returns all the functions used in a query.
+func extractQueryFunctionCalls(q string) []queryFunctionCall {
+	if !strings.Contains(q, "(") {
+		return []queryFunctionCall{}
+	}
+	matches := queryFunctionRegex.FindAllStringSubmatch(q, -1)
+	calls := make([]queryFunctionCall, 0, len(matches))
+	for _, match := range matches {
+		calls = append(calls, newQueryFunctionCall(match))
+	}
+	return calls
+}
+
+// newQueryFunctionCall creates a queryFunctionCall from a match of queryFunctionRegex.
+func newQueryFunctionCall(match []string) queryFunctionCall {
+	args := make([]string, 0)
+	for _, arg := range queryFunctionArgRegex.FindAllStringSubmatch(match[2], -1) {
+		args = append(args, arg[1])
+	}
+	return queryFunctionCall{name: match[1], args: args}
+}
+
+// validateQueryFunctions checks all the functions used in a query.
+func validateQueryFunctions(q string) error {
+	for _, call := range extractQueryFunctionCalls(q) {
+		if err := call.IsValid(); err != nil {
+			return fmt.Errorf("invalid query: %w", err)
+		}
+	}
+	return nil
+}
+
+// expandQueryFunctions replaces every function of the query by a check of the attribute containing its result,
+// the attributes are added to the evaluation context before evaluating the query.
+func expandQueryFunctions(q string) string {
+	if !strings.Contains(q, "(") {
+		return q
+	}
+	return queryFunctionRegex.ReplaceAllStringFunc(q, func(match string) string {
+		return newQueryFunctionCall(queryFunctionRegex.FindStringSubmatch(match)).attributeName() + " eq true"
+	})
+}
+
+// isQueryFunctionAttribute checks if an attribute has been added to the evaluation context for a function.
+func isQueryFunctionAttribute(attribute string) bool {
+	return strings.HasPrefix(attribute, queryFunctionAttributePrefix)
+}
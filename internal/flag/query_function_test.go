@@ -0,0 +1,100 @@
+package flag_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+// membershipListsMock is a MembershipLists with static lists.
+type membershipListsMock map[string][]string
+
+func TestInternalFlag_InListOperator(t *testing.T) {
+	f := flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"enabled":  testconvert.Interface(true),
+			"disabled": testconvert.Interface(false),
+		},
+		Rules: &[]flag.Rule{
+			{
+				Name:            testconvert.String("paying-accounts"),
+				Query:           testconvert.String(`inList("paying", "company.id") and country eq "FR"`),
+				VariationResult: testconvert.String("enabled"),
+			},
+			{
+				Name:            testconvert.String("beta-testers"),
+				Query:           testconvert.String(`inList("beta")`),
+				VariationResult: testconvert.String("enabled"),
+			},
+		},
+		DefaultRule: &flag.Rule{VariationResult: testconvert.String("disabled")},
+	}
+	assert.NoError(t, f.IsValid())
+	lists := membershipListsMock{
+		"paying": {"acme"},
+		"beta":   {"user-1"},
+	}
+
+	tests := []struct {
+		name         string
+		ctx          ffcontext.Context
+		lists        flag.MembershipLists
+		wantVariant  string
+		wantRuleName *string
+	}{
+		{
+			name: "attribute in the list",
+			ctx: ffcontext.NewEvaluationContextBuilder("user-2").
+				AddCustom("company", map[string]interface{}{"id": "acme"}).
+				AddCustom("country", "FR").Build(),
+			lists:        lists,
+			wantVariant:  "enabled",
+			wantRuleName: testconvert.String("paying-accounts"),
+		},
+		{
+			name: "attribute in the list but the rest of the query does not match",
+			ctx: ffcontext.NewEvaluationContextBuilder("user-2").
+				AddCustom("company", map[string]interface{}{"id": "acme"}).
+				AddCustom("country", "US").Build(),
+			lists:       lists,
+			wantVariant: "disabled",
+		},
+		{
+			name:         "targeting key in the list",
+			ctx:          ffcontext.NewEvaluationContext("user-1"),
+			lists:        lists,
+			wantVariant:  "enabled",
+			wantRuleName: testconvert.String("beta-testers"),
+		},
+		{
+			name:        "not in the lists",
+			ctx:         ffcontext.NewEvaluationContext("user-3"),
+			lists:       lists,
+			wantVariant: "disabled",
+		},
+		{
+			name:        "lists not available",
+			ctx:         ffcontext.NewEvaluationContext("user-1"),
+			wantVariant: "disabled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, details, trace := f.Explain("my-flag", tt.ctx,
+				flag.Context{DefaultSdkValue: false, MembershipLists: tt.lists})
+			assert.Equal(t, tt.wantVariant, details.Variant)
+			assert.Equal(t, tt.wantRuleName, details.RuleName)
+			// the result of the functions is never reported as a missing attribute
+			for _, step := range trace.Steps {
+				if step.Rule != nil {
+					for _, attribute := range step.Rule.MissingAttributes {
+						assert.NotContains(t, attribute, "goff_fn_")
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package flag_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+func TestInternalFlag_IsValidInvalidQuery(t *testing.T) {
+	f := flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"A": testconvert.Interface("A"),
+			"B": testconvert.Interface("B"),
+		},
+		Rules: &[]flag.Rule{
+			{
+				Name:            testconvert.String("rule1"),
+				Query:           testconvert.String(`key eq (`),
+				VariationResult: testconvert.String("A"),
+			},
+		},
+		DefaultRule: &flag.Rule{VariationResult: testconvert.String("B")},
+	}
+	err := f.IsValid()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid query for the rule rule1")
+
+	// a disabled rule is not evaluated, so its query is not checked
+	(*f.Rules)[0].Disable = testconvert.Bool(true)
+	assert.NoError(t, f.IsValid())
+}
+
+func TestInternalFlag_CompileRules(t *testing.T) {
+	f := flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"A": testconvert.Interface("A"),
+			"B": testconvert.Interface("B"),
+			"C": testconvert.Interface("C"),
+		},
+		Rules: &[]flag.Rule{
+			{
+				Name:            testconvert.String("rule1"),
+				Query:           testconvert.String(`inSegment("beta-testers") and country eq "FR"`),
+				VariationResult: testconvert.String("A"),
+			},
+		},
+		DefaultRule: &flag.Rule{VariationResult: testconvert.String("B")},
+		Scheduled: &[]flag.ScheduledStep{
+			{
+				InternalFlag: flag.InternalFlag{
+					Rules: &[]flag.Rule{
+						{
+							Name:  testconvert.String("rule1"),
+							Query: testconvert.String(`inSegment("beta-testers") and country eq "DE"`),
+						},
+						{
+							Name:            testconvert.String("rule2"),
+							Segment:         testconvert.String("beta-testers"),
+							VariationResult: testconvert.String("C"),
+						},
+					},
+				},
+				Date: testconvert.Time(time.Now().Add(-1 * time.Second)),
+			},
+		},
+	}
+	f.LinkSegments(map[string]flag.Segment{
+		"beta-testers": {Query: testconvert.String(`beta eq true`)},
+	})
+	f.CompileRules()
+
+	tests := []struct {
+		name string
+		ctx  ffcontext.Context
+		want string
+	}{
+		{
+			name: "query of the scheduled step",
+			ctx:  ffcontext.NewEvaluationContextBuilder("user-1").AddCustom("beta", true).AddCustom("country", "DE").Build(),
+			want: "A",
+		},
+		{
+			name: "query replaced by the scheduled step",
+			ctx:  ffcontext.NewEvaluationContextBuilder("user-1").AddCustom("beta", true).AddCustom("country", "FR").Build(),
+			want: "C",
+		},
+		{
+			name: "segment not matching",
+			ctx:  ffcontext.NewEvaluationContextBuilder("user-1").AddCustom("beta", false).AddCustom("country", "DE").Build(),
+			want: "B",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := f.Value("my-flag", tt.ctx, flag.Context{DefaultSdkValue: "default"})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestInternalFlag_CompileRules_ConcurrentEvaluations(t *testing.T) {
+	f := flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"A": testconvert.Interface("A"),
+			"B": testconvert.Interface("B"),
+		},
+		Rules: &[]flag.Rule{
+			{
+				Name:            testconvert.String("rule1"),
+				Query:           testconvert.String(`age gt 18`),
+				VariationResult: testconvert.String("A"),
+			},
+		},
+		DefaultRule: &flag.Rule{VariationResult: testconvert.String("B")},
+	}
+	f.CompileRules()
+
+	// an invalid operation (comparing a string with a number) in an evaluation should not affect the others.
+	contexts := map[string]ffcontext.Context{
+		"A": ffcontext.NewEvaluationContextBuilder("user-1").AddCustom("age", 20).Build(),
+		"B": ffcontext.NewEvaluationContextBuilder("user-2").AddCustom("age", "twenty").Build(),
+	}
+	var wg sync.WaitGroup
+	errs := make(chan string, 200)
+	for i := 0; i < 100; i++ {
+		for want, ctx := range contexts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if got, _ := f.Value("my-flag", ctx, flag.Context{DefaultSdkValue: "default"}); got != want {
+					errs <- fmt.Sprintf("expected %s, got %v", want, got)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestInternalFlag_QueryFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		queryFormat *string
+		query       string
+		want        string
+		wantErr     string
+	}{
+		{
+			name:  "default format is nikunjy",
+			query: `email ew "@gofeatureflag.org" and age gt 18`,
+			want:  "A",
+		},
+		{
+			name:        "jsonlogic format",
+			queryFormat: testconvert.String("jsonlogic"),
+			query:       `{"and": [{"ends_with": [{"var": "email"}, "@gofeatureflag.org"]}, {">": [{"var": "age"}, 18]}]}`,
+			want:        "A",
+		},
+		{
+			name:        "jsonlogic format with the flag key",
+			queryFormat: testconvert.String("jsonlogic"),
+			query:       `{"==": [{"var": "$flagd.flagKey"}, "my-flag"]}`,
+			want:        "A",
+		},
+		{
+			name:        "jsonlogic unsupported operator",
+			queryFormat: testconvert.String("jsonlogic"),
+			query:       `{"string_contains": [{"var": "email"}, "john"]}`,
+			wantErr:     "unknown operator string_contains",
+		},
+		{
+			name:        "cel format",
+			queryFormat: testconvert.String("cel"),
+			query:       `email.endsWith("@gofeatureflag.org") && age > 18`,
+			want:        "A",
+		},
+		{
+			name:        "cel format with an unknown function",
+			queryFormat: testconvert.String("cel"),
+			query:       `email.endsWith("@gofeatureflag.org") && isBetaTester(email)`,
+			wantErr:     "invalid query for the rule rule1",
+		},
+		{
+			name:        "cel format not matching",
+			queryFormat: testconvert.String("cel"),
+			query:       `email.endsWith("@example.com")`,
+			want:        "B",
+		},
+		{
+			name:        "invalid jsonlogic query",
+			queryFormat: testconvert.String("jsonlogic"),
+			query:       `{"==": [`,
+			wantErr:     "invalid query for the rule rule1",
+		},
+		{
+			name:        "unknown query format",
+			queryFormat: testconvert.String("sql"),
+			query:       `email = 'john@gofeatureflag.org'`,
+			wantErr:     "unknown query format: sql",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := flag.InternalFlag{
+				Variations: &map[string]*interface{}{
+					"A": testconvert.Interface("A"),
+					"B": testconvert.Interface("B"),
+				},
+				Rules: &[]flag.Rule{
+					{
+						Name:            testconvert.String("rule1"),
+						Query:           testconvert.String(tt.query),
+						QueryFormat:     tt.queryFormat,
+						VariationResult: testconvert.String("A"),
+					},
+				},
+				DefaultRule: &flag.Rule{VariationResult: testconvert.String("B")},
+			}
+			err := f.IsValid()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			ctx := ffcontext.NewEvaluationContextBuilder("user-1").
+				AddCustom("email", "john@gofeatureflag.org").
+				AddCustom("age", 42).
+				Build()
+			got, _ := f.Value("my-flag", ctx, flag.Context{DefaultSdkValue: "default"})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -18,9 +18,30 @@ type ResolutionDetails struct {
 	// RuleName (optional) is the name of the associated rule if we have one
 	RuleName *string
 
+	// TargetName (optional) is the name of the target list if the evaluation context was part of one
+	TargetName *string
+
 	// Cacheable is set to true if an SDK/provider can cache the value locally.
 	Cacheable bool
 
 	// Metadata is a field containing information about your flag such as an issue tracker link, a description, etc ...
 	Metadata map[string]interface{}
+
+	// BucketingKey (optional) is the attribute used to select the percentage bucket of the evaluation context,
+	// it is empty when the targeting key is used or when the variation was not selected using a percentage.
+	BucketingKey string
+
+	// BucketingValue (optional) is the value of the bucketing attribute hashed to select the percentage bucket.
+	BucketingValue string
+
+	// StickyAssignment is set to true if the variation was assigned to the evaluation context
+	// during a previous evaluation and kept by the sticky bucketing store.
+	StickyAssignment bool
+
+	// Layer (optional) is the name of the experiment layer of the flag.
+	Layer string
+
+	// LayerSlice (optional) is the slice of the layer where the evaluation context is: the key of the flag
+	// allocated to the slice, "holdout" for the global holdout or empty if the traffic is not allocated.
+	LayerSlice string
 }
@@ -40,4 +40,20 @@ const (
 
 	// ReasonOffline Indicates that GO Feature Flag is currently evaluating in offline mode.
 	ReasonOffline ResolutionReason = "OFFLINE"
+
+	// ReasonPrerequisiteFailed Indicates that one of the prerequisite flags did not resolve
+	// to the expected variation, so the fallback variation has been served.
+	ReasonPrerequisiteFailed ResolutionReason = "PREREQUISITE_FAILED"
+
+	// ReasonOutsideTimeWindow Indicates that the flag is evaluated outside of its time windows,
+	// so the default value has been served.
+	ReasonOutsideTimeWindow ResolutionReason = "OUTSIDE_TIME_WINDOW"
+
+	// ReasonOutsideRuleTimeWindow Indicates that no rule matched and that at least one rule was skipped
+	// because the evaluation date is outside of its time windows, so the default rule has been served.
+	ReasonOutsideRuleTimeWindow ResolutionReason = "OUTSIDE_RULE_TIME_WINDOW"
+
+	// ReasonNotAllocated Indicates that the evaluation context is not allocated to the experiment of the flag
+	// in its layer (part of another slice or of the global holdout), so the default value has been served.
+	ReasonNotAllocated ResolutionReason = "NOT_ALLOCATED"
 )
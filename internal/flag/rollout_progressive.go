@@ -1,6 +1,7 @@
 package flag
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -11,6 +12,127 @@ type ProgressiveRollout struct {
 
 	// End contains what describes the end status of the rollout.
 	End *ProgressiveRolloutStep `json:"end,omitempty" yaml:"end,omitempty" toml:"end,omitempty" jsonschema:"title=initial,description=A description of the end state of the rollout."` // nolint: lll
+
+	// Steps (optional) is an ordered list of intermediate steps between the initial and the end step.
+	// The percentage ramps linearly from one step to the next one, except if a step is on hold.
+	Steps *[]ProgressiveRolloutRampStep `json:"steps,omitempty" yaml:"steps,omitempty" toml:"steps,omitempty" jsonschema:"title=steps,description=Ordered list of intermediate steps between the initial and the end step." render:"omitnil"` // nolint: lll
+}
+
+// ProgressiveRolloutRampStep is an intermediate step of a progressive rollout.
+type ProgressiveRolloutRampStep struct {
+	// Percentage is the percentage of the rollout reached at the date of the step.
+	Percentage *float64 `json:"percentage,omitempty" yaml:"percentage,omitempty" toml:"percentage,omitempty" jsonschema:"required,title=percentage,description=The percentage of the rollout reached at the date of the step."` // nolint: lll
+
+	// Date is the time when the percentage of the step is reached.
+	Date *time.Time `json:"date,omitempty" yaml:"date,omitempty" toml:"date,omitempty" jsonschema:"required,title=date,description=Date is the time when the percentage of the step is reached."` // nolint: lll
+
+	// Hold (optional) keeps the percentage of the step until the date of the next step,
+	// instead of ramping up progressively to the next percentage.
+	Hold *bool `json:"hold,omitempty" yaml:"hold,omitempty" toml:"hold,omitempty" jsonschema:"title=hold,description=Keep the percentage of the step until the date of the next step instead of ramping up to it."` // nolint: lll
+}
+
+// rampPoint is a point of the release ramp of a progressive rollout.
+type rampPoint struct {
+	date       time.Time
+	percentage float64
+	hold       bool
+}
+
+// GetSteps is the getter of the field Steps
+func (p *ProgressiveRollout) GetSteps() []ProgressiveRolloutRampStep {
+	if p.Steps == nil {
+		return []ProgressiveRolloutRampStep{}
+	}
+	return *p.Steps
+}
+
+// getEndPercentage returns the percentage of the end step, an end percentage that is missing
+// or above 100 means that we want to reach 100%.
+func (p *ProgressiveRollout) getEndPercentage() float64 {
+	if p.End == nil || p.End.getPercentage() == 0 || p.End.getPercentage() > 100 {
+		return 100
+	}
+	return p.End.getPercentage()
+}
+
+// getRamp returns all the points of the release ramp ordered by date, from the initial step to the end step.
+// The intermediate steps without date or out of order are ignored.
+func (p *ProgressiveRollout) getRamp() []rampPoint {
+	ramp := []rampPoint{{date: *p.Initial.Date, percentage: p.Initial.getPercentage()}}
+	for _, step := range p.GetSteps() {
+		if step.Date == nil || step.Date.Before(ramp[len(ramp)-1].date) || !step.Date.Before(*p.End.Date) {
+			continue
+		}
+		ramp = append(ramp, rampPoint{date: *step.Date, percentage: step.getPercentage(), hold: step.isHold()})
+	}
+	return append(ramp, rampPoint{date: *p.End.Date, percentage: p.getEndPercentage()})
+}
+
+// getPercentageAt returns the percentage of the rollout at a specific time.
+func (p *ProgressiveRollout) getPercentageAt(now time.Time) float64 {
+	ramp := p.getRamp()
+	for i := 0; i < len(ramp)-1; i++ {
+		from, to := ramp[i], ramp[i+1]
+		if !now.Before(to.date) {
+			continue
+		}
+		if from.hold {
+			return from.percentage
+		}
+		elapsed := float64(now.Sub(from.date)) / float64(to.date.Sub(from.date))
+		return from.percentage + (to.percentage-from.percentage)*elapsed
+	}
+	return ramp[len(ramp)-1].percentage
+}
+
+// isValidRamp checks that the intermediate steps are complete and ordered.
+func (p *ProgressiveRollout) isValidRamp() error {
+	previousPercentage := float64(0)
+	if p.Initial != nil {
+		previousPercentage = p.Initial.getPercentage()
+	}
+	var previousDate *time.Time
+	if p.Initial != nil {
+		previousDate = p.Initial.Date
+	}
+
+	for i, step := range p.GetSteps() {
+		if step.Date == nil || step.Percentage == nil {
+			return fmt.Errorf("invalid progressive rollout, step %d should have a date and a percentage", i)
+		}
+		if step.getPercentage() < 0 || step.getPercentage() > 100 {
+			return fmt.Errorf("invalid progressive rollout, step %d percentage should be between 0 and 100", i)
+		}
+		if step.getPercentage() < previousPercentage {
+			return fmt.Errorf("invalid progressive rollout, step %d percentage should not be lower "+
+				"than the previous one: %v/%v", i, previousPercentage, step.getPercentage())
+		}
+		if previousDate != nil && step.Date.Before(*previousDate) {
+			return fmt.Errorf("invalid progressive rollout, step %d date should be after the previous one", i)
+		}
+		if p.End != nil && p.End.Date != nil && !step.Date.Before(*p.End.Date) {
+			return fmt.Errorf("invalid progressive rollout, step %d date should be before the end date", i)
+		}
+		previousPercentage = step.getPercentage()
+		previousDate = step.Date
+	}
+
+	if len(p.GetSteps()) > 0 && p.getEndPercentage() < previousPercentage {
+		return fmt.Errorf("invalid progressive rollout, end percentage should not be lower "+
+			"than the last step percentage: %v/%v", previousPercentage, p.getEndPercentage())
+	}
+	return nil
+}
+
+func (s *ProgressiveRolloutRampStep) getPercentage() float64 {
+	if s.Percentage == nil {
+		return 0
+	}
+	return *s.Percentage
+}
+
+func (s *ProgressiveRolloutRampStep) isHold() bool {
+	return s.Hold != nil && *s.Hold
 }
 
 // ProgressiveRolloutStep define a progressive rollout step (initial and end)
@@ -9,3 +9,9 @@ type ScheduledStep struct {
 	InternalFlag `yaml:",inline"`
 	Date         *time.Time `json:"date,omitempty" yaml:"date,omitempty" toml:"date,omitempty"`
 }
+
+// scheduledSnapshot is the flag as it is after the scheduled steps planned up to the date.
+type scheduledSnapshot struct {
+	date time.Time
+	flag *InternalFlag
+}
@@ -0,0 +1,255 @@
+package flag_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+// newScheduledFlag returns a flag with a scheduled step at start changing its rules, default rule,
+// experimentation and version.
+func newScheduledFlag(start time.Time) flag.InternalFlag {
+	return flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"A": testconvert.Interface("A"),
+			"B": testconvert.Interface("B"),
+		},
+		Rules: &[]flag.Rule{
+			{
+				Name:            testconvert.String("rule1"),
+				Query:           testconvert.String("key eq \"user-1\""),
+				VariationResult: testconvert.String("A"),
+			},
+		},
+		DefaultRule: &flag.Rule{
+			Percentages: &map[string]float64{"A": 50, "B": 50},
+		},
+		Experimentation: &flag.ExperimentationRollout{
+			Start: testconvert.Time(start.Add(-24 * time.Hour)),
+			End:   testconvert.Time(start.Add(72 * time.Hour)),
+		},
+		Version: testconvert.String("1"),
+		Scheduled: &[]flag.ScheduledStep{
+			{
+				InternalFlag: flag.InternalFlag{
+					Variations: &map[string]*interface{}{"C": testconvert.Interface("C")},
+					Rules: &[]flag.Rule{
+						{
+							Name:            testconvert.String("rule1"),
+							VariationResult: testconvert.String("C"),
+						},
+						{
+							Name:            testconvert.String("rule2"),
+							Query:           testconvert.String("key eq \"user-2\""),
+							VariationResult: testconvert.String("B"),
+						},
+					},
+					DefaultRule: &flag.Rule{
+						Percentages: &map[string]float64{"A": 0, "B": 100},
+					},
+					Experimentation: &flag.ExperimentationRollout{
+						Start: testconvert.Time(start.Add(-48 * time.Hour)),
+					},
+					Version: testconvert.String("2"),
+				},
+				Date: testconvert.Time(start),
+			},
+		},
+	}
+}
+
+func TestInternalFlag_GetFlagAt(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		date time.Time
+		// resolveScheduledSteps is true if the steps are resolved like when loading the flag in the cache
+		resolveScheduledSteps bool
+		// wantSameFlag is true if no step is applied and the flag itself is returned
+		wantSameFlag           bool
+		wantVersion            string
+		wantDefaultPercentages map[string]float64
+		wantExperimentation    flag.ExperimentationRollout
+		wantRules              []flag.Rule
+		wantVariations         []string
+	}{
+		{
+			name:         "no step applied returns the same flag",
+			date:         start.Add(-1 * time.Hour),
+			wantSameFlag: true,
+		},
+		{
+			name:                   "step applied returns the effective flag",
+			date:                   start.Add(1 * time.Hour),
+			wantVersion:            "2",
+			wantDefaultPercentages: map[string]float64{"A": 0, "B": 100},
+			wantExperimentation: flag.ExperimentationRollout{
+				Start: testconvert.Time(start.Add(-48 * time.Hour)),
+				End:   testconvert.Time(start.Add(72 * time.Hour)),
+			},
+			wantRules: []flag.Rule{
+				{
+					Name:            testconvert.String("rule1"),
+					Query:           testconvert.String("key eq \"user-1\""),
+					VariationResult: testconvert.String("C"),
+				},
+				{
+					Name:            testconvert.String("rule2"),
+					Query:           testconvert.String("key eq \"user-2\""),
+					VariationResult: testconvert.String("B"),
+				},
+			},
+			wantVariations: []string{"A", "B", "C"},
+		},
+		{
+			name:                  "no step applied on a flag loaded in the cache returns the same flag",
+			date:                  start,
+			resolveScheduledSteps: true,
+			wantSameFlag:          true,
+		},
+		{
+			name:                   "step applied on a flag loaded in the cache returns the resolved flag",
+			date:                   start.Add(1 * time.Nanosecond),
+			resolveScheduledSteps:  true,
+			wantVersion:            "2",
+			wantDefaultPercentages: map[string]float64{"A": 0, "B": 100},
+			wantExperimentation: flag.ExperimentationRollout{
+				Start: testconvert.Time(start.Add(-48 * time.Hour)),
+				End:   testconvert.Time(start.Add(72 * time.Hour)),
+			},
+			wantRules: []flag.Rule{
+				{
+					Name:            testconvert.String("rule1"),
+					Query:           testconvert.String("key eq \"user-1\""),
+					VariationResult: testconvert.String("C"),
+				},
+				{
+					Name:            testconvert.String("rule2"),
+					Query:           testconvert.String("key eq \"user-2\""),
+					VariationResult: testconvert.String("B"),
+				},
+			},
+			wantVariations: []string{"A", "B", "C"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newScheduledFlag(start)
+			if tt.resolveScheduledSteps {
+				f.ResolveScheduledSteps()
+			}
+			got := f.GetFlagAt(tt.date)
+			// resolving the flag again returns the same result and never modifies the flag
+			assert.Equal(t, got, f.GetFlagAt(tt.date))
+			if tt.resolveScheduledSteps {
+				// the steps are merged once when loading the flag
+				assert.Same(t, got, f.GetFlagAt(tt.date))
+			}
+			ignoreUnexported := cmpopts.IgnoreUnexported(flag.InternalFlag{})
+			assert.Empty(t, cmp.Diff(newScheduledFlag(start), f, ignoreUnexported))
+			if tt.wantSameFlag {
+				assert.Same(t, &f, got)
+				return
+			}
+
+			assert.Equal(t, tt.wantVersion, got.GetVersion())
+			assert.Equal(t, tt.wantDefaultPercentages, got.GetDefaultRule().GetPercentages())
+			assert.Equal(t, tt.wantExperimentation, *got.Experimentation)
+			assert.Empty(t, cmp.Diff(tt.wantRules, got.GetRules(), ignoreUnexported))
+			for _, variation := range tt.wantVariations {
+				assert.Equal(t, variation, got.GetVariationValue(variation))
+			}
+		})
+	}
+}
+
+func TestInternalFlag_ScheduledStepFields(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	prerequisites := []flag.Prerequisite{{Key: testconvert.String("other-flag"), Variation: testconvert.String("on")}}
+	tests := []struct {
+		name                  string
+		flag                  flag.InternalFlag
+		step                  flag.InternalFlag
+		wantType              string
+		wantPrerequisites     []flag.Prerequisite
+		wantFallbackVariation string
+		wantValue             interface{}
+	}{
+		{
+			name:              "step declaring the type",
+			step:              flag.InternalFlag{Type: testconvert.String("string")},
+			wantType:          "string",
+			wantPrerequisites: []flag.Prerequisite{},
+			wantValue:         "A",
+		},
+		{
+			name:                  "step adding prerequisites",
+			flag:                  flag.InternalFlag{FallbackVariation: testconvert.String("B")},
+			step:                  flag.InternalFlag{Prerequisites: &prerequisites},
+			wantPrerequisites:     prerequisites,
+			wantFallbackVariation: "B",
+			wantValue:             "B",
+		},
+		{
+			name: "step changing the fallback variation",
+			flag: flag.InternalFlag{
+				Prerequisites:     &prerequisites,
+				FallbackVariation: testconvert.String("A"),
+			},
+			step:                  flag.InternalFlag{FallbackVariation: testconvert.String("B")},
+			wantPrerequisites:     prerequisites,
+			wantFallbackVariation: "B",
+			wantValue:             "B",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.flag
+			f.Variations = &map[string]*interface{}{
+				"A": testconvert.Interface("A"),
+				"B": testconvert.Interface("B"),
+			}
+			f.DefaultRule = &flag.Rule{VariationResult: testconvert.String("A")}
+			f.Scheduled = &[]flag.ScheduledStep{{InternalFlag: tt.step, Date: testconvert.Time(start)}}
+			assert.NoError(t, f.IsValid())
+			f.ResolveScheduledSteps()
+
+			got := f.GetFlagAt(start.Add(time.Hour))
+			assert.Equal(t, tt.wantType, got.GetType())
+			assert.Equal(t, tt.wantPrerequisites, got.GetPrerequisites())
+			assert.Equal(t, tt.wantFallbackVariation, got.GetFallbackVariation())
+
+			// the prerequisites are never fulfilled without flag getter
+			value, _ := f.Value("my-flag", ffcontext.NewEvaluationContext("user-1"),
+				flag.Context{EvaluationDate: start.Add(time.Hour)})
+			assert.Equal(t, tt.wantValue, value)
+		})
+	}
+}
+
+func TestInternalFlag_GetFlagAtConcurrentEvaluations(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	f := newScheduledFlag(start)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, resolutionDetails := f.Value(
+				"my-flag",
+				ffcontext.NewEvaluationContext("user-2"),
+				flag.Context{DefaultSdkValue: "default", EvaluationDate: start.Add(time.Duration(i+1) * time.Minute)},
+			)
+			assert.Equal(t, flag.ReasonTargetingMatch, resolutionDetails.Reason)
+		}(i)
+	}
+	wg.Wait()
+	assert.Empty(t, cmp.Diff(newScheduledFlag(start), f, cmpopts.IgnoreUnexported(flag.InternalFlag{})))
+}
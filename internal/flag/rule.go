@@ -4,12 +4,14 @@ import (
 	"errors"
 	"fmt"
 	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"maps"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
-	"github.com/nikunjy/rules/parser"
 	"github.com/thomaspoignant/go-feature-flag/internal/internalerror"
+	"github.com/thomaspoignant/go-feature-flag/internal/query"
 	"github.com/thomaspoignant/go-feature-flag/internal/utils"
 )
 
@@ -22,6 +24,14 @@ type Rule struct {
 	// Query represents an antlr query in the nikunjy/rules format
 	Query *string `json:"query,omitempty" yaml:"query,omitempty" toml:"query,omitempty" jsonschema:"title=query,description=The query that allow to check in the evaluation context match. Note: in the defaultRule field query is ignored."` // nolint: lll
 
+	// QueryFormat (optional) is the language used to write the query: nikunjy, jsonlogic or cel.
+	// Default: nikunjy
+	QueryFormat *string `json:"queryFormat,omitempty" yaml:"queryFormat,omitempty" toml:"queryFormat,omitempty" jsonschema:"enum=nikunjy,enum=jsonlogic,enum=cel,default=nikunjy,title=queryFormat,description=Language used to write the query of the rule (nikunjy / jsonlogic / cel). Default is nikunjy." render:"omitnil"` // nolint: lll
+
+	// Segment (optional) is the name of a segment the evaluation context should be part of for the rule to apply.
+	// If both Segment and Query are set, the evaluation context should match both.
+	Segment *string `json:"segment,omitempty" yaml:"segment,omitempty" toml:"segment,omitempty" jsonschema:"title=segment,description=Name of a segment the evaluation context should be part of for the rule to apply. Note: in the defaultRule field segment is ignored." render:"omitnil"` // nolint: lll
+
 	// VariationResult represents the variation name to use if the rule apply for the user.
 	// In case we have a percentage field in the config VariationResult is ignored
 	VariationResult *string `json:"variation,omitempty" yaml:"variation,omitempty" toml:"variation,omitempty" jsonschema:"title=variation,description=The variation name to use if the rule apply for the user. In case we have a percentage field in the config this field is ignored"` // nolint: lll
@@ -36,22 +46,44 @@ type Rule struct {
 	// Before the start date we will serve the initial percentage and, after we will serve the end percentage.
 	ProgressiveRollout *ProgressiveRollout `json:"progressiveRollout,omitempty" yaml:"progressiveRollout,omitempty" toml:"progressiveRollout,omitempty" jsonschema:"title=progressiveRollout,description=Configure a progressive rollout deployment of your flag."` // nolint: lll
 
+	// BucketingKey (optional) is the attribute of the evaluation context used to affect a user to a percentage bucket.
+	// If not set, the bucketing key of the flag is used.
+	BucketingKey *string `json:"bucketingKey,omitempty" yaml:"bucketingKey,omitempty" toml:"bucketingKey,omitempty" jsonschema:"title=bucketingKey,description=Attribute of the evaluation context used to affect a user to a percentage bucket (ex: organizationId). Default is the targeting key." render:"omitnil"` // nolint: lll
+
+	// TimeWindows (optional) is a list of recurring periods of time where the rule applies.
+	// Note: in the defaultRule field time windows are not allowed.
+	TimeWindows *[]TimeWindow `json:"timeWindows,omitempty" yaml:"timeWindows,omitempty" toml:"timeWindows,omitempty" jsonschema:"title=timeWindows,description=Recurring periods of time where the rule applies. Note: not allowed in the defaultRule field." render:"omitnil"` // nolint: lll
+
 	// Disable indicates that this rule is disabled.
 	Disable *bool `json:"disable,omitempty" yaml:"disable,omitempty" toml:"disable,omitempty" jsonschema:"title=disable,description=Indicates that this rule is disabled."` // nolint: lll
 }
 
 // Evaluate is checking if the rule apply to for the user.
 // If yes it returns the variation you should use for this rule.
-func (r *Rule) Evaluate(ctx ffcontext.Context, hashID uint32, isDefault bool,
+// segments contains the segments that the rule can reference, lists the membership lists used by the
+// inList operator and evaluationDate is the date used for the time windows and the progressive rollout.
+func (r *Rule) Evaluate(ctx ffcontext.Context, hashID uint32, isDefault bool, segments map[string]Segment,
+	lists MembershipLists, evaluationDate time.Time,
+) (string, error) {
+	return r.evaluate("", ctx, hashID, isDefault, r.compile(segments), lists, evaluationDate)
+}
+
+// evaluate is checking if the rule apply to for the user with the queries of the rule already compiled,
+// flagName is the name of the evaluated flag.
+func (r *Rule) evaluate(flagName string, ctx ffcontext.Context, hashID uint32, isDefault bool,
+	compiled *compiledRule, lists MembershipLists, evaluationDate time.Time,
 ) (string, error) {
 	// Check if the rule apply for this user
-	ruleApply := isDefault || r.GetQuery() == "" || parser.Evaluate(r.GetTrimmedQuery(), utils.ContextToMap(ctx))
+	if !isDefault && !r.IsDisable() && !isInTimeWindows(r.GetTimeWindows(), evaluationDate) {
+		return "", &internalerror.RuleOutsideTimeWindow{Context: ctx}
+	}
+	ruleApply := isDefault || r.isMatching(flagName, ctx, compiled, lists, evaluationDate)
 	if !ruleApply || (!isDefault && r.IsDisable()) {
 		return "", &internalerror.RuleNotApply{Context: ctx}
 	}
 
 	if r.ProgressiveRollout != nil {
-		variation, err := r.getVariationFromProgressiveRollout(hashID)
+		variation, err := r.getVariationFromProgressiveRollout(hashID, evaluationDate)
 		if err != nil {
 			return variation, err
 		}
@@ -72,6 +104,76 @@ func (r *Rule) Evaluate(ctx ffcontext.Context, hashID uint32, isDefault bool,
 	return "", fmt.Errorf("error in the configuration, no variation available for this rule")
 }
 
+// isMatching checks if the evaluation context matches the segment and the query of the rule.
+// The queries also receive the flagd properties ($flagd.flagKey and $flagd.timestamp) used by the JSONLogic rules.
+func (r *Rule) isMatching(flagName string, ctx ffcontext.Context, compiled *compiledRule, lists MembershipLists,
+	evaluationDate time.Time,
+) bool {
+	if r.Segment == nil && r.GetQuery() == "" {
+		return true
+	}
+
+	ctxMap := utils.ContextToMap(ctx)
+	ctxMap[query.FlagdProperties] = map[string]interface{}{"flagKey": flagName, "timestamp": evaluationDate.Unix()}
+	if compiled.segmentQuery != nil && !compiled.segmentQuery.matches(ctx, ctxMap, lists) {
+		return false
+	}
+	return compiled.ruleQuery == nil || compiled.ruleQuery.matches(ctx, ctxMap, lists)
+}
+
+// compile expands and parses the query of the rule and the query of its segment.
+// The inSegment operators are replaced by the queries of the segments and the functions (inList, semver,
+// inCIDR) by the check of their result, they are only available with the nikunjy query format.
+func (r *Rule) compile(segments map[string]Segment) *compiledRule {
+	compiled := &compiledRule{}
+	if r.Segment != nil {
+		segment, ok := segments[r.GetSegment()]
+		if !ok {
+			compiled.segmentQuery = &compiledQuery{err: fmt.Errorf("unknown segment: %s", r.GetSegment())}
+		} else {
+			compiled.segmentQuery = newCompiledQuery(QueryFormatNikunjy, expandQueryFunctions(segment.GetQuery()),
+				extractQueryFunctionCalls(segment.GetQuery()))
+			if compiled.segmentQuery.err != nil {
+				compiled.segmentQuery.err = fmt.Errorf("invalid query for the segment %s: %w",
+					r.GetSegment(), compiled.segmentQuery.err)
+			}
+		}
+	}
+
+	if r.GetQuery() != "" {
+		compiled.ruleQuery = r.compileQuery(segments)
+	}
+
+	compiled.attributes = make([]string, 0)
+	for _, q := range []*compiledQuery{compiled.segmentQuery, compiled.ruleQuery} {
+		if q != nil {
+			compiled.attributes = append(compiled.attributes, q.getAttributes()...)
+		}
+	}
+	return compiled
+}
+
+// compileQuery expands and parses the query of the rule.
+func (r *Rule) compileQuery(segments map[string]Segment) *compiledQuery {
+	if r.GetQueryFormat() != QueryFormatNikunjy {
+		return r.wrapQueryError(newCompiledQuery(r.GetQueryFormat(), r.GetQuery(), nil))
+	}
+	withSegments, err := expandSegments(r.GetQuery(), segments)
+	if err != nil {
+		return &compiledQuery{err: err}
+	}
+	return r.wrapQueryError(newCompiledQuery(
+		QueryFormatNikunjy, expandQueryFunctions(withSegments), extractQueryFunctionCalls(withSegments)))
+}
+
+// wrapQueryError adds the name of the rule to the error of a query that cannot be parsed.
+func (r *Rule) wrapQueryError(q *compiledQuery) *compiledQuery {
+	if q.err != nil {
+		q.err = fmt.Errorf("invalid query for the rule %s: %w", r.GetName(), q.err)
+	}
+	return q
+}
+
 // IsDynamic is a function that allows to know if the rule has a dynamic result or not.
 func (r *Rule) IsDynamic() bool {
 	hasPercentage100 := false
@@ -84,7 +186,7 @@ func (r *Rule) IsDynamic() bool {
 	return r.ProgressiveRollout != nil || (r.Percentages != nil && len(r.GetPercentages()) > 0 && !hasPercentage100)
 }
 
-func (r *Rule) getVariationFromProgressiveRollout(hash uint32) (string, error) {
+func (r *Rule) getVariationFromProgressiveRollout(hash uint32, now time.Time) (string, error) {
 	isRolloutValid := r.ProgressiveRollout != nil &&
 		r.ProgressiveRollout.Initial != nil &&
 		r.ProgressiveRollout.Initial.Date != nil &&
@@ -95,26 +197,12 @@ func (r *Rule) getVariationFromProgressiveRollout(hash uint32) (string, error) {
 		r.ProgressiveRollout.End.Date.After(*r.ProgressiveRollout.Initial.Date)
 
 	if isRolloutValid {
-		now := time.Now()
 		if now.Before(*r.ProgressiveRollout.Initial.Date) {
 			return *r.ProgressiveRollout.Initial.Variation, nil
 		}
 
 		// We are between initial and end
-		initialPercentage := r.ProgressiveRollout.Initial.getPercentage() * PercentageMultiplier
-		if r.ProgressiveRollout.End.getPercentage() == 0 || r.ProgressiveRollout.End.getPercentage() > 100 {
-			max := float64(100)
-			r.ProgressiveRollout.End.Percentage = &max
-		}
-		endPercentage := r.ProgressiveRollout.End.getPercentage() * PercentageMultiplier
-
-		nbSec := r.ProgressiveRollout.End.Date.Unix() - r.ProgressiveRollout.Initial.Date.Unix()
-		percentage := endPercentage - initialPercentage
-		percentPerSec := percentage / float64(nbSec)
-
-		c := now.Unix() - r.ProgressiveRollout.Initial.Date.Unix()
-		currentPercentage := float64(c)*percentPerSec + initialPercentage
-
+		currentPercentage := r.ProgressiveRollout.getPercentageAt(now) * PercentageMultiplier
 		if hash < uint32(currentPercentage) {
 			return r.ProgressiveRollout.End.getVariation(), nil
 		}
@@ -181,12 +269,37 @@ func (r *Rule) MergeRules(updatedRule Rule) {
 		r.Query = updatedRule.Query
 	}
 
+	if updatedRule.QueryFormat != nil {
+		r.QueryFormat = updatedRule.QueryFormat
+	}
+
+	if updatedRule.Segment != nil {
+		r.Segment = updatedRule.Segment
+	}
+
+	if updatedRule.BucketingKey != nil {
+		r.BucketingKey = updatedRule.BucketingKey
+	}
+
 	if updatedRule.VariationResult != nil {
 		r.VariationResult = updatedRule.VariationResult
 	}
 
+	if updatedRule.TimeWindows != nil {
+		r.TimeWindows = updatedRule.TimeWindows
+	}
+
 	if updatedRule.ProgressiveRollout != nil {
 		c := r.GetProgressiveRollout()
+		// the steps are copied to keep the rule we are merging into unchanged.
+		if c.Initial != nil {
+			initial := *c.Initial
+			c.Initial = &initial
+		}
+		if c.End != nil {
+			end := *c.End
+			c.End = &end
+		}
 		if updatedRule.ProgressiveRollout.Initial != nil {
 			c.Initial.mergeStep(updatedRule.ProgressiveRollout.Initial)
 		}
@@ -194,12 +307,17 @@ func (r *Rule) MergeRules(updatedRule Rule) {
 		if updatedRule.ProgressiveRollout.End != nil {
 			c.End.mergeStep(updatedRule.ProgressiveRollout.End)
 		}
+
+		// the intermediate steps are replaced as a whole, since they only make sense together.
+		if updatedRule.ProgressiveRollout.Steps != nil {
+			c.Steps = updatedRule.ProgressiveRollout.Steps
+		}
 		r.ProgressiveRollout = &c
 	}
 
 	if updatedRule.Percentages != nil {
 		updatedPercentages := updatedRule.GetPercentages()
-		mergedPercentages := r.GetPercentages()
+		mergedPercentages := maps.Clone(r.GetPercentages())
 		for key, percentage := range updatedPercentages {
 			// When you set a negative percentage we are not taking it in consideration.
 			if percentage < 0 {
@@ -223,10 +341,30 @@ func (r *Rule) IsValid(defaultRule bool) error {
 	}
 
 	// targeting without query
-	if !defaultRule && r.Query == nil {
+	if !defaultRule && r.Query == nil && r.Segment == nil && r.TimeWindows == nil {
 		return fmt.Errorf("each targeting should have a query")
 	}
 
+	// Query format
+	if _, ok := queryCompilers[r.GetQueryFormat()]; !ok {
+		return fmt.Errorf("unknown query format: %s", r.GetQueryFormat())
+	}
+	if r.GetQueryFormat() == QueryFormatNikunjy {
+		if err := validateQueryFunctions(r.GetQuery()); err != nil {
+			return err
+		}
+	}
+
+	// Time windows
+	if defaultRule && r.TimeWindows != nil {
+		return fmt.Errorf("the default rule cannot have time windows")
+	}
+	for _, window := range r.GetTimeWindows() {
+		if err := window.IsValid(); err != nil {
+			return err
+		}
+	}
+
 	// Validate the percentage of the rule
 	if r.Percentages != nil {
 		count := float64(0)
@@ -246,12 +384,24 @@ func (r *Rule) IsValid(defaultRule bool) error {
 			"than end percentage: %v/%v",
 			r.GetProgressiveRollout().Initial.getPercentage(), r.GetProgressiveRollout().End.getPercentage())
 	}
+
+	// Progressive rollout: check that the intermediate steps are ordered
+	if r.ProgressiveRollout != nil {
+		if err := r.ProgressiveRollout.isValidRamp(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // GetTrimmedQuery is removing the break lines and return
 func (r *Rule) GetTrimmedQuery() string {
-	splitQuery := strings.Split(r.GetQuery(), "\n")
+	return trimQuery(r.GetQuery())
+}
+
+// trimQuery is removing the break lines of a query
+func trimQuery(query string) string {
+	splitQuery := strings.Split(query, "\n")
 	for index, item := range splitQuery {
 		splitQuery[index] = strings.TrimLeft(item, " ")
 	}
@@ -266,6 +416,49 @@ func (r *Rule) GetQuery() string {
 	return *r.Query
 }
 
+// GetQueryFormat is the getter of the field QueryFormat
+func (r *Rule) GetQueryFormat() QueryFormat {
+	if r.QueryFormat == nil {
+		return QueryFormatNikunjy
+	}
+	return *r.QueryFormat
+}
+
+func (r *Rule) GetSegment() string {
+	if r.Segment == nil {
+		return ""
+	}
+	return *r.Segment
+}
+
+// GetSegmentReferences returns the names of all the segments used by the rule,
+// either with the segment field or with the inSegment operator in the query.
+func (r *Rule) GetSegmentReferences() []string {
+	references := make([]string, 0)
+	if r.GetQueryFormat() == QueryFormatNikunjy {
+		references = extractSegmentReferences(r.GetQuery())
+	}
+	if r.Segment != nil {
+		references = append(references, r.GetSegment())
+	}
+	return references
+}
+
+func (r *Rule) GetBucketingKey() string {
+	if r.BucketingKey == nil {
+		return ""
+	}
+	return *r.BucketingKey
+}
+
+// GetTimeWindows is the getter of the field TimeWindows
+func (r *Rule) GetTimeWindows() []TimeWindow {
+	if r.TimeWindows == nil {
+		return []TimeWindow{}
+	}
+	return *r.TimeWindows
+}
+
 func (r *Rule) GetVariationResult() string {
 	if r.VariationResult == nil {
 		return ""
@@ -309,7 +502,7 @@ func (r *Rule) GetProgressiveRollout() ProgressiveRollout {
 // If you want to edit a rule this rule should have a name already to be able to
 // target the updates to the right place.
 func MergeSetOfRules(initialRules []Rule, updates []Rule) *[]Rule {
-	collection := initialRules
+	collection := slices.Clone(initialRules)
 	modified := make(map[string]Rule, 0)
 	for _, update := range updates {
 		ruleName := update.Name
@@ -17,6 +17,7 @@ func TestRule_Evaluate(t *testing.T) {
 		user      ffcontext.Context
 		hashID    uint32
 		isDefault bool
+		segments  map[string]flag.Segment
 	}
 	tests := []struct {
 		name    string
@@ -76,6 +77,92 @@ func TestRule_Evaluate(t *testing.T) {
 			want:    "variation_A",
 			wantErr: assert.NoError,
 		},
+		{
+			name: "User match the segment",
+			rule: flag.Rule{
+				Name:            testconvert.String("rule1"),
+				VariationResult: testconvert.String("variation_A"),
+				Segment:         testconvert.String("beta-testers"),
+			},
+			args: args{
+				isDefault: false,
+				user:      ffcontext.NewEvaluationContextBuilder("abc").AddCustom("beta", true).Build(),
+				segments: map[string]flag.Segment{
+					"beta-testers": {Query: testconvert.String("beta eq true")},
+				},
+			},
+			want:    "variation_A",
+			wantErr: assert.NoError,
+		},
+		{
+			name: "User match the query but not the segment",
+			rule: flag.Rule{
+				Name:            testconvert.String("rule1"),
+				VariationResult: testconvert.String("variation_A"),
+				Query:           testconvert.String("key eq \"abc\""),
+				Segment:         testconvert.String("beta-testers"),
+			},
+			args: args{
+				isDefault: false,
+				user:      ffcontext.NewEvaluationContextBuilder("abc").AddCustom("beta", false).Build(),
+				segments: map[string]flag.Segment{
+					"beta-testers": {Query: testconvert.String("beta eq true")},
+				},
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name: "Rule referencing an unknown segment does not apply",
+			rule: flag.Rule{
+				Name:            testconvert.String("rule1"),
+				VariationResult: testconvert.String("variation_A"),
+				Segment:         testconvert.String("unknown"),
+			},
+			args: args{
+				isDefault: false,
+				user:      ffcontext.NewEvaluationContext("abc"),
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name: "User match the query using the inSegment operator",
+			rule: flag.Rule{
+				Name:            testconvert.String("rule1"),
+				VariationResult: testconvert.String("variation_A"),
+				Query:           testconvert.String("inSegment(\"eu-paid\") and key eq \"abc\""),
+			},
+			args: args{
+				isDefault: false,
+				user: ffcontext.NewEvaluationContextBuilder("abc").
+					AddCustom("region", "eu").
+					AddCustom("plan", "paid").
+					Build(),
+				segments: map[string]flag.Segment{
+					"eu-paid": {Query: testconvert.String("region eq \"eu\" and plan eq \"paid\"")},
+				},
+			},
+			want:    "variation_A",
+			wantErr: assert.NoError,
+		},
+		{
+			name: "User does not match the inSegment operator",
+			rule: flag.Rule{
+				Name:            testconvert.String("rule1"),
+				VariationResult: testconvert.String("variation_A"),
+				Query:           testconvert.String("inSegment(\"eu-paid\")"),
+			},
+			args: args{
+				isDefault: false,
+				user: ffcontext.NewEvaluationContextBuilder("abc").
+					AddCustom("region", "us").
+					AddCustom("plan", "paid").
+					Build(),
+				segments: map[string]flag.Segment{
+					"eu-paid": {Query: testconvert.String("region eq \"eu\" and plan eq \"paid\"")},
+				},
+			},
+			wantErr: assert.Error,
+		},
 		{
 			name: "No match and no default variation",
 			rule: flag.Rule{
@@ -352,6 +439,71 @@ func TestRule_Evaluate(t *testing.T) {
 			wantErr: assert.NoError,
 			want:    "variation_C",
 		},
+		{
+			name: "Progressive rollout with steps serve the percentage of the current step",
+			rule: flag.Rule{
+				Name: testconvert.String("rule1"),
+				ProgressiveRollout: &flag.ProgressiveRollout{
+					Initial: &flag.ProgressiveRolloutStep{
+						Variation:  testconvert.String("variation_B"),
+						Percentage: testconvert.Float64(0),
+						Date:       testconvert.Time(time.Now().Add(-10 * time.Second)),
+					},
+					Steps: &[]flag.ProgressiveRolloutRampStep{
+						{
+							Percentage: testconvert.Float64(100),
+							Date:       testconvert.Time(time.Now().Add(-5 * time.Second)),
+						},
+					},
+					End: &flag.ProgressiveRolloutStep{
+						Variation:  testconvert.String("variation_C"),
+						Percentage: testconvert.Float64(100),
+						Date:       testconvert.Time(time.Now().Add(10 * time.Second)),
+					},
+				},
+			},
+			args: args{
+				user:   ffcontext.NewEvaluationContext("userkey"),
+				hashID: utils.Hash("flagname+userKey") % flag.MaxPercentage,
+			},
+			wantErr: assert.NoError,
+			want:    "variation_C",
+		},
+		{
+			name: "Progressive rollout with step on hold",
+			rule: flag.Rule{
+				Name: testconvert.String("rule1"),
+				ProgressiveRollout: &flag.ProgressiveRollout{
+					Initial: &flag.ProgressiveRolloutStep{
+						Variation:  testconvert.String("variation_B"),
+						Percentage: testconvert.Float64(0),
+						Date:       testconvert.Time(time.Now().Add(-10 * time.Second)),
+					},
+					Steps: &[]flag.ProgressiveRolloutRampStep{
+						{
+							Percentage: testconvert.Float64(0),
+							Date:       testconvert.Time(time.Now().Add(-5 * time.Second)),
+							Hold:       testconvert.Bool(true),
+						},
+						{
+							Percentage: testconvert.Float64(100),
+							Date:       testconvert.Time(time.Now().Add(5 * time.Second)),
+						},
+					},
+					End: &flag.ProgressiveRolloutStep{
+						Variation:  testconvert.String("variation_C"),
+						Percentage: testconvert.Float64(100),
+						Date:       testconvert.Time(time.Now().Add(10 * time.Second)),
+					},
+				},
+			},
+			args: args{
+				user:   ffcontext.NewEvaluationContext("userkey"),
+				hashID: utils.Hash("flagname+userKey") % flag.MaxPercentage,
+			},
+			wantErr: assert.NoError,
+			want:    "variation_B",
+		},
 		{
 			name: "Progressive rollout initial before end",
 			rule: flag.Rule{
@@ -481,7 +633,7 @@ func TestRule_Evaluate(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.rule.Evaluate(tt.args.user, tt.args.hashID, tt.args.isDefault)
+			got, err := tt.rule.Evaluate(tt.args.user, tt.args.hashID, tt.args.isDefault, tt.args.segments, nil, time.Now())
 			if !tt.wantErr(t, err, fmt.Sprintf("Evaluate(%v, %v, %v)", tt.args.user, tt.args.hashID, tt.args.isDefault)) {
 				return
 			}
@@ -687,6 +839,59 @@ func TestRule_MergeRules(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "merge rule with progressive rollout replace steps",
+			originalRule: flag.Rule{
+				Name: testconvert.String("rule1"),
+				ProgressiveRollout: &flag.ProgressiveRollout{
+					Initial: &flag.ProgressiveRolloutStep{
+						Variation: testconvert.String("variation_B"),
+						Date:      testconvert.Time(time.Date(2021, time.February, 1, 10, 10, 10, 10, time.UTC)),
+					},
+					Steps: &[]flag.ProgressiveRolloutRampStep{
+						{
+							Percentage: testconvert.Float64(10),
+							Date:       testconvert.Time(time.Date(2021, time.February, 2, 10, 10, 10, 10, time.UTC)),
+						},
+					},
+					End: &flag.ProgressiveRolloutStep{
+						Variation: testconvert.String("variation_C"),
+						Date:      testconvert.Time(time.Date(2021, time.February, 3, 10, 10, 10, 10, time.UTC)),
+					},
+				},
+			},
+			updatedRule: flag.Rule{
+				ProgressiveRollout: &flag.ProgressiveRollout{
+					Steps: &[]flag.ProgressiveRolloutRampStep{
+						{
+							Percentage: testconvert.Float64(5),
+							Date:       testconvert.Time(time.Date(2021, time.February, 2, 10, 10, 10, 10, time.UTC)),
+							Hold:       testconvert.Bool(true),
+						},
+					},
+				},
+			},
+			want: flag.Rule{
+				Name: testconvert.String("rule1"),
+				ProgressiveRollout: &flag.ProgressiveRollout{
+					Initial: &flag.ProgressiveRolloutStep{
+						Variation: testconvert.String("variation_B"),
+						Date:      testconvert.Time(time.Date(2021, time.February, 1, 10, 10, 10, 10, time.UTC)),
+					},
+					Steps: &[]flag.ProgressiveRolloutRampStep{
+						{
+							Percentage: testconvert.Float64(5),
+							Date:       testconvert.Time(time.Date(2021, time.February, 2, 10, 10, 10, 10, time.UTC)),
+							Hold:       testconvert.Bool(true),
+						},
+					},
+					End: &flag.ProgressiveRolloutStep{
+						Variation: testconvert.String("variation_C"),
+						Date:      testconvert.Time(time.Date(2021, time.February, 3, 10, 10, 10, 10, time.UTC)),
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -695,3 +900,92 @@ func TestRule_MergeRules(t *testing.T) {
 		})
 	}
 }
+
+func TestRule_QueryFunctions(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		ctx     ffcontext.Context
+		want    bool
+		wantErr string
+	}{
+		{
+			name:  "semver compares the versions as numbers",
+			query: `semver("appVersion", ">=", "1.10.0")`,
+			ctx:   ffcontext.NewEvaluationContextBuilder("user-1").AddCustom("appVersion", "1.10.2").Build(),
+			want:  true,
+		},
+		{
+			name:  "semver with a pre-release",
+			query: `semver("appVersion", ">=", "1.10.0")`,
+			ctx:   ffcontext.NewEvaluationContextBuilder("user-1").AddCustom("appVersion", "1.10.0-beta.1").Build(),
+			want:  false,
+		},
+		{
+			name:  "inCIDR with a nested attribute and another operator",
+			query: `inCIDR("device.ip", "192.168.0.0/16") and country eq "FR"`,
+			ctx: ffcontext.NewEvaluationContextBuilder("user-1").
+				AddCustom("device", map[string]interface{}{"ip": "192.168.1.12"}).
+				AddCustom("country", "FR").Build(),
+			want: true,
+		},
+		{
+			name:  "inCIDR with an IPv6 address",
+			query: `inCIDR("ip", "2001:db8::/32")`,
+			ctx:   ffcontext.NewEvaluationContextBuilder("user-1").AddCustom("ip", "2001:db9::1").Build(),
+			want:  false,
+		},
+		{
+			name:  "attribute is not a valid version",
+			query: `semver("appVersion", ">=", "1.10.0")`,
+			ctx:   ffcontext.NewEvaluationContextBuilder("user-1").AddCustom("appVersion", "latest").Build(),
+			want:  false,
+		},
+		{
+			name:  "missing attribute",
+			query: `inCIDR("ip", "10.0.0.0/8")`,
+			ctx:   ffcontext.NewEvaluationContext("user-1"),
+			want:  false,
+		},
+		{
+			name:    "malformed version",
+			query:   `semver("appVersion", ">=", "1.x")`,
+			wantErr: "invalid query: invalid semantic version: 1.x",
+		},
+		{
+			name:    "unknown semver operator",
+			query:   `semver("appVersion", "=>", "1.10.0")`,
+			wantErr: "invalid query: unknown semantic version operator: =>",
+		},
+		{
+			name:    "malformed network",
+			query:   `inCIDR("ip", "10.0.0.300/8")`,
+			wantErr: "invalid query: invalid CIDR: 10.0.0.300/8",
+		},
+		{
+			name:    "wrong number of arguments",
+			query:   `inCIDR("ip")`,
+			wantErr: "invalid query: inCIDR expects an attribute and a network",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := flag.Rule{
+				Query:           testconvert.String(tt.query),
+				VariationResult: testconvert.String("enabled"),
+			}
+			if tt.wantErr != "" {
+				assert.EqualError(t, rule.IsValid(false), tt.wantErr)
+				return
+			}
+			assert.NoError(t, rule.IsValid(false))
+			variation, err := rule.Evaluate(tt.ctx, 0, false, nil, nil, time.Now())
+			if tt.want {
+				assert.NoError(t, err)
+				assert.Equal(t, "enabled", variation)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
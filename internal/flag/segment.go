@@ -0,0 +1,79 @@
+package flag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// inSegmentRegex matches the inSegment("name") operator used in a rule query.
+var inSegmentRegex = regexp.MustCompile(`inSegment\(\s*"([^"]*)"\s*\)`)
+
+// Segment is a named audience defined once in the configuration file and that can be
+// referenced by the targeting rules of every flag.
+type Segment struct {
+	// Description (optional) is a human-readable explanation of who is part of the segment.
+	Description *string `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty" jsonschema:"title=description,description=Human-readable explanation of who is part of the segment."` // nolint: lll
+
+	// Query represents an antlr query in the nikunjy/rules format
+	Query *string `json:"query,omitempty" yaml:"query,omitempty" toml:"query,omitempty" jsonschema:"required,title=query,description=The query that allow to check if the evaluation context is part of the segment."` // nolint: lll
+}
+
+// IsValid is checking if the segment is valid.
+func (s *Segment) IsValid() error {
+	if strings.TrimSpace(s.GetQuery()) == "" {
+		return fmt.Errorf("a segment should have a query")
+	}
+	if len(extractSegmentReferences(s.GetQuery())) > 0 {
+		return fmt.Errorf("a segment cannot reference another segment")
+	}
+	if err := validateQueryFunctions(s.GetQuery()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetQuery is the getter of the field Query
+func (s *Segment) GetQuery() string {
+	if s.Query == nil {
+		return ""
+	}
+	return *s.Query
+}
+
+// GetDescription is the getter of the field Description
+func (s *Segment) GetDescription() string {
+	if s.Description == nil {
+		return ""
+	}
+	return *s.Description
+}
+
+// extractSegmentReferences returns the name of all the segments used with the inSegment operator in a query.
+func extractSegmentReferences(query string) []string {
+	matches := inSegmentRegex.FindAllStringSubmatch(query, -1)
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// expandSegments replaces every inSegment("name") operator in the query by the query of the segment.
+// It returns an error if the query references a segment that does not exist.
+func expandSegments(query string, segments map[string]Segment) (string, error) {
+	if !strings.Contains(query, "inSegment") {
+		return query, nil
+	}
+	var err error
+	expanded := inSegmentRegex.ReplaceAllStringFunc(query, func(match string) string {
+		name := inSegmentRegex.FindStringSubmatch(match)[1]
+		segment, ok := segments[name]
+		if !ok {
+			err = fmt.Errorf("unknown segment: %s", name)
+			return match
+		}
+		return "(" + segment.GetQuery() + ")"
+	})
+	return expanded, err
+}
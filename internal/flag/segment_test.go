@@ -0,0 +1,46 @@
+package flag_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+func TestInternalFlag_LinkSegments(t *testing.T) {
+	segments := map[string]flag.Segment{
+		"beta-testers": {Query: testconvert.String("beta eq true")},
+		"eu-paid":      {Query: testconvert.String("region eq \"eu\" and plan eq \"paid\"")},
+		"unused":       {Query: testconvert.String("key eq \"abc\"")},
+	}
+
+	f := flag.InternalFlag{
+		Rules: &[]flag.Rule{
+			{
+				Segment:         testconvert.String("beta-testers"),
+				VariationResult: testconvert.String("B"),
+			},
+		},
+		Scheduled: &[]flag.ScheduledStep{
+			{
+				InternalFlag: flag.InternalFlag{
+					Rules: &[]flag.Rule{
+						{
+							Query:           testconvert.String(`inSegment("eu-paid")`),
+							VariationResult: testconvert.String("B"),
+						},
+					},
+				},
+				Date: testconvert.Time(time.Now().Add(1 * time.Hour)),
+			},
+		},
+	}
+
+	f.LinkSegments(segments)
+	assert.Equal(t, map[string]flag.Segment{
+		"beta-testers": segments["beta-testers"],
+		"eu-paid":      segments["eu-paid"],
+	}, f.GetSegments())
+}
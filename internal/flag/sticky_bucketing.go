@@ -0,0 +1,30 @@
+package flag
+
+// stickyVariation returns the variation already assigned to the bucketing value if the store has one,
+// otherwise the computed variation is stored as the assignment of the bucketing value.
+// The store is used on a best effort basis, if it is not available (or if the context of the evaluation is
+// canceled) the computed variation is served.
+// If assign is false, the computed variation is not stored (ex: when explaining an evaluation).
+// It returns the variation to serve and true if it comes from a previous assignment.
+func (f *InternalFlag) stickyVariation(
+	flagContext Context,
+	flagName string,
+	bucketingValue string,
+	computedVariation string,
+	assign bool,
+) (string, bool) {
+	ctx := flagContext.GetContext()
+	store := flagContext.StickyBucketingStore
+	assigned, ok, err := store.Get(ctx, flagName, bucketingValue)
+	if err != nil {
+		return computedVariation, false
+	}
+	// an assignment to a variation removed from the flag is replaced.
+	if _, exists := f.GetVariations()[assigned]; ok && exists {
+		return assigned, true
+	}
+	if assign {
+		_ = store.Set(ctx, flagName, bucketingValue, computedVariation)
+	}
+	return computedVariation, false
+}
@@ -0,0 +1,82 @@
+package flag_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/stickybucketing/inmemorystore"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+func TestInternalFlag_StickyBucketing(t *testing.T) {
+	tests := []struct {
+		name string
+		// assignment is the variation already stored for user-1, empty if there is none
+		assignment   string
+		percentages  map[string]float64
+		withoutStore bool
+		want         flag.ResolutionDetails
+		// wantStored is the variation stored for user-1 after the evaluation
+		wantStored string
+	}{
+		{
+			name:        "the first evaluation stores the assignment",
+			percentages: map[string]float64{"A": 1, "B": 99},
+			want:        flag.ResolutionDetails{Variant: "B", Reason: flag.ReasonSplit},
+			wantStored:  "B",
+		},
+		{
+			name:        "the stored assignment is kept when the percentages change",
+			assignment:  "A",
+			percentages: map[string]float64{"A": 1, "B": 99},
+			want:        flag.ResolutionDetails{Variant: "A", Reason: flag.ReasonSplit, StickyAssignment: true},
+			wantStored:  "A",
+		},
+		{
+			name:         "without a store the variation is computed with the percentages",
+			assignment:   "A",
+			percentages:  map[string]float64{"A": 1, "B": 99},
+			withoutStore: true,
+			want:         flag.ResolutionDetails{Variant: "B", Reason: flag.ReasonSplit},
+			wantStored:   "A",
+		},
+		{
+			name:        "an assignment to a removed variation is replaced",
+			assignment:  "C",
+			percentages: map[string]float64{"A": 1, "B": 99},
+			want:        flag.ResolutionDetails{Variant: "B", Reason: flag.ReasonSplit},
+			wantStored:  "B",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := flag.InternalFlag{
+				Variations: &map[string]*interface{}{
+					"A": testconvert.Interface("A"),
+					"B": testconvert.Interface("B"),
+				},
+				DefaultRule: &flag.Rule{Percentages: &tt.percentages},
+			}
+			store := &inmemorystore.Store{}
+			if tt.assignment != "" {
+				assert.NoError(t, store.Set(context.Background(), "my-flag", "user-1", tt.assignment))
+			}
+			flagContext := flag.Context{DefaultSdkValue: "default", StickyBucketingStore: store}
+			if tt.withoutStore {
+				flagContext.StickyBucketingStore = nil
+			}
+
+			_, got := f.Value("my-flag", ffcontext.NewEvaluationContext("user-1"), flagContext)
+			assert.Equal(t, tt.want.Variant, got.Variant)
+			assert.Equal(t, tt.want.Reason, got.Reason)
+			assert.Equal(t, tt.want.StickyAssignment, got.StickyAssignment)
+			stored, ok, err := store.Get(context.Background(), "my-flag", "user-1")
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, tt.wantStored, stored)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package flag
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+)
+
+// Target is a list of values of an attribute of the evaluation context that always receive the same variation.
+// It is an efficient alternative to a rule with a query like `key in ["a", "b", ...]` when you have a lot of values.
+type Target struct {
+	// Name (optional) is the name of the list, it is added in the metadata of the evaluation.
+	Name *string `json:"name,omitempty" yaml:"name,omitempty" toml:"name,omitempty" jsonschema:"title=name,description=Name of the list it is added in the metadata of the evaluation (evaluatedTargetName)."` // nolint: lll
+
+	// Variation is the variation served to the evaluation contexts of the list.
+	Variation *string `json:"variation,omitempty" yaml:"variation,omitempty" toml:"variation,omitempty" jsonschema:"required,title=variation,description=Variation served to the evaluation contexts of the list."` // nolint: lll
+
+	// Attribute (optional) is the attribute of the evaluation context compared to the values of the list
+	// (nested attributes are separated by a dot, ex: "company.id").
+	// Default: the targeting key of the evaluation context.
+	Attribute *string `json:"attribute,omitempty" yaml:"attribute,omitempty" toml:"attribute,omitempty" jsonschema:"title=attribute,description=Attribute of the evaluation context compared to the values of the list. Default is the targeting key."` // nolint: lll
+
+	// Values are the values of the attribute targeted by the list.
+	Values *[]string `json:"values,omitempty" yaml:"values,omitempty" toml:"values,omitempty" jsonschema:"required,title=values,description=Values of the attribute targeted by the list."` // nolint: lll
+}
+
+// targetIndex contains the values of the targets indexed for a constant time lookup.
+// The sets are identified by the values of their target, so they are shared by the copies of the flag.
+type targetIndex map[*[]string]map[string]struct{}
+
+// IsValid is checking if the target is valid.
+func (t *Target) IsValid(variations map[string]*interface{}) error {
+	if t.Variation == nil {
+		return fmt.Errorf("a target should have a variation")
+	}
+	if _, ok := variations[t.GetVariation()]; !ok {
+		return fmt.Errorf("invalid variation: %s does not exists", t.GetVariation())
+	}
+	if len(t.GetValues()) == 0 {
+		return fmt.Errorf("a target should have at least one value")
+	}
+	return nil
+}
+
+// contains checks if the evaluation context is part of the list.
+// The values indexed when loading the flag are used if available, otherwise the values are scanned.
+func (t *Target) contains(ctx ffcontext.Context, index targetIndex) bool {
+	value, err := getBucketingValue(ctx, t.GetAttribute())
+	if err != nil {
+		return false
+	}
+	if valueSet, ok := index[t.Values]; ok {
+		_, found := valueSet[value]
+		return found
+	}
+	return slices.Contains(t.GetValues(), value)
+}
+
+// GetName is the getter of the field Name
+func (t *Target) GetName() string {
+	if t.Name == nil {
+		return ""
+	}
+	return *t.Name
+}
+
+// GetVariation is the getter of the field Variation
+func (t *Target) GetVariation() string {
+	if t.Variation == nil {
+		return ""
+	}
+	return *t.Variation
+}
+
+// GetAttribute is the getter of the field Attribute
+func (t *Target) GetAttribute() string {
+	if t.Attribute == nil {
+		return ""
+	}
+	return *t.Attribute
+}
+
+// GetValues is the getter of the field Values
+func (t *Target) GetValues() []string {
+	if t.Values == nil {
+		return []string{}
+	}
+	return *t.Values
+}
+
+// IndexTargets builds the sets of values of the targets of the flag (including the ones from the scheduled
+// rollout steps), so the evaluation context is found in constant time.
+func (f *InternalFlag) IndexTargets() {
+	f.targetIndex = nil
+	index := targetIndex{}
+	index.add(f.Targets)
+	for _, step := range f.GetScheduled() {
+		index.add(step.Targets)
+	}
+	if len(index) > 0 {
+		f.targetIndex = index
+	}
+}
+
+// add builds the sets of values of the targets.
+func (i targetIndex) add(targets *[]Target) {
+	if targets == nil {
+		return
+	}
+	for _, target := range *targets {
+		if target.Values == nil {
+			continue
+		}
+		valueSet := make(map[string]struct{}, len(target.GetValues()))
+		for _, value := range target.GetValues() {
+			valueSet[value] = struct{}{}
+		}
+		i[target.Values] = valueSet
+	}
+}
+
+// matchTarget returns the first target of the flag containing the evaluation context.
+func (f *InternalFlag) matchTarget(ctx ffcontext.Context) (*Target, bool) {
+	for index, target := range f.GetTargets() {
+		if target.contains(ctx, f.targetIndex) {
+			return &f.GetTargets()[index], true
+		}
+	}
+	return nil, false
+}
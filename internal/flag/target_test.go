@@ -0,0 +1,133 @@
+package flag_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+func TestInternalFlag_Targets(t *testing.T) {
+	tests := []struct {
+		name            string
+		ctx             ffcontext.Context
+		wantVariant     string
+		wantReason      flag.ResolutionReason
+		wantTargetName  *string
+		wantLastStep    flag.TraceStepType
+		wantLastMessage string
+	}{
+		{
+			name:            "targeting key in a list is evaluated before the rules",
+			ctx:             ffcontext.NewEvaluationContext("user-2"),
+			wantVariant:     "enabled",
+			wantReason:      flag.ReasonTargetingMatch,
+			wantTargetName:  testconvert.String("beta-testers"),
+			wantLastStep:    flag.TraceStepTargets,
+			wantLastMessage: "the evaluation context is part of beta-testers, serving the variation enabled",
+		},
+		{
+			name: "nested attribute in a list",
+			ctx: ffcontext.NewEvaluationContextBuilder("user-3").
+				AddCustom("company", map[string]interface{}{"id": "acme"}).Build(),
+			wantVariant:  "disabled",
+			wantReason:   flag.ReasonTargetingMatch,
+			wantLastStep: flag.TraceStepTargets,
+		},
+		{
+			name:         "not in any list",
+			ctx:          ffcontext.NewEvaluationContext("user-3"),
+			wantVariant:  "disabled",
+			wantReason:   flag.ReasonTargetingMatch,
+			wantLastStep: flag.TraceStepRule,
+		},
+	}
+	for _, tt := range tests {
+		for _, indexed := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s (indexed: %v)", tt.name, indexed), func(t *testing.T) {
+				f := flag.InternalFlag{
+					Variations: &map[string]*interface{}{
+						"enabled":  testconvert.Interface(true),
+						"disabled": testconvert.Interface(false),
+					},
+					Targets: &[]flag.Target{
+						{
+							Name:      testconvert.String("beta-testers"),
+							Variation: testconvert.String("enabled"),
+							Values:    &[]string{"user-1", "user-2"},
+						},
+						{
+							Variation: testconvert.String("disabled"),
+							Attribute: testconvert.String("company.id"),
+							Values:    &[]string{"acme"},
+						},
+					},
+					Rules: &[]flag.Rule{
+						{
+							Name:            testconvert.String("all-users"),
+							Query:           testconvert.String(`key co "user"`),
+							VariationResult: testconvert.String("disabled"),
+						},
+					},
+					DefaultRule: &flag.Rule{VariationResult: testconvert.String("disabled")},
+				}
+				assert.NoError(t, f.IsValid())
+				if indexed {
+					f.IndexTargets()
+				}
+				_, details, trace := f.Explain("my-flag", tt.ctx, flag.Context{DefaultSdkValue: false})
+				assert.Equal(t, tt.wantVariant, details.Variant)
+				assert.Equal(t, tt.wantReason, details.Reason)
+				assert.Equal(t, tt.wantTargetName, details.TargetName)
+				lastStep := trace.Steps[len(trace.Steps)-1]
+				assert.Equal(t, tt.wantLastStep, lastStep.Type)
+				if tt.wantLastMessage != "" {
+					assert.Equal(t, tt.wantLastMessage, lastStep.Message)
+				}
+			})
+		}
+	}
+}
+
+func TestInternalFlag_IsValidTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  flag.Target
+		wantErr string
+	}{
+		{
+			name:   "valid target",
+			target: flag.Target{Variation: testconvert.String("enabled"), Values: &[]string{"user-1"}},
+		},
+		{
+			name:    "a target should use an existing variation",
+			target:  flag.Target{Variation: testconvert.String("unknown"), Values: &[]string{"user-1"}},
+			wantErr: "invalid variation: unknown does not exists",
+		},
+		{
+			name:    "a target should have values",
+			target:  flag.Target{Variation: testconvert.String("enabled")},
+			wantErr: "a target should have at least one value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := flag.InternalFlag{
+				Variations: &map[string]*interface{}{
+					"enabled":  testconvert.Interface(true),
+					"disabled": testconvert.Interface(false),
+				},
+				Targets:     &[]flag.Target{tt.target},
+				DefaultRule: &flag.Rule{VariationResult: testconvert.String("disabled")},
+			}
+			if tt.wantErr != "" {
+				assert.EqualError(t, f.IsValid(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, f.IsValid())
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package flag
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timeOfDayLayout is the format of the start and end time of a time window.
+const timeOfDayLayout = "15:04"
+
+// locationCache keeps the timezones already loaded, to avoid reading the timezone database at each evaluation.
+var locationCache sync.Map
+
+// weekdays contains the accepted names of the days in a time window.
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// TimeWindow is a recurring period of time, defined by days of the week and a time of the day.
+// ex: every Sunday from 02:00 to 04:00 in the Europe/Paris timezone.
+type TimeWindow struct {
+	// Days (optional) is the list of days of the week where the window applies (ex: monday, tue ...).
+	// If empty the window applies every day.
+	Days *[]string `json:"days,omitempty" yaml:"days,omitempty" toml:"days,omitempty" jsonschema:"title=days,description=Days of the week where the window applies (ex: monday or mon). If empty the window applies every day."` // nolint: lll
+
+	// StartTime (optional) is the time of the day when the window starts, in the format HH:MM.
+	// Default: 00:00
+	StartTime *string `json:"startTime,omitempty" yaml:"startTime,omitempty" toml:"startTime,omitempty" jsonschema:"title=startTime,description=Time of the day when the window starts in the format HH:MM. Default is 00:00."` // nolint: lll
+
+	// EndTime (optional) is the time of the day when the window ends (excluded), in the format HH:MM.
+	// If EndTime is before StartTime, the window ends the next day.
+	// Default: end of the day
+	EndTime *string `json:"endTime,omitempty" yaml:"endTime,omitempty" toml:"endTime,omitempty" jsonschema:"title=endTime,description=Time of the day when the window ends in the format HH:MM. If before startTime the window ends the next day. Default is the end of the day."` // nolint: lll
+
+	// Timezone (optional) is the IANA name of the timezone of the window (ex: Europe/Paris).
+	// Default: UTC
+	Timezone *string `json:"timezone,omitempty" yaml:"timezone,omitempty" toml:"timezone,omitempty" jsonschema:"title=timezone,description=IANA name of the timezone of the window (ex: Europe/Paris). Default is UTC."` // nolint: lll
+}
+
+// IsValid is checking if the time window is valid.
+func (w *TimeWindow) IsValid() error {
+	for _, day := range w.GetDays() {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("invalid time window, unknown day: %s", day)
+		}
+	}
+	start, err := w.getStartTime()
+	if err != nil {
+		return fmt.Errorf("invalid time window, start time should be in the format HH:MM: %w", err)
+	}
+	end, err := w.getEndTime()
+	if err != nil {
+		return fmt.Errorf("invalid time window, end time should be in the format HH:MM: %w", err)
+	}
+	if start == end {
+		return fmt.Errorf("invalid time window, start and end time should be different")
+	}
+	if _, err := w.getLocation(); err != nil {
+		return fmt.Errorf("invalid time window, unknown timezone %s: %w", w.GetTimezone(), err)
+	}
+	return nil
+}
+
+// contains checks if the date is inside the time window.
+func (w *TimeWindow) contains(date time.Time) bool {
+	location, err := w.getLocation()
+	if err != nil {
+		return false
+	}
+	start, err := w.getStartTime()
+	if err != nil {
+		return false
+	}
+	end, err := w.getEndTime()
+	if err != nil {
+		return false
+	}
+
+	local := date.In(location)
+	now := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second
+	if start < end {
+		return now >= start && now < end && w.appliesOn(local.Weekday())
+	}
+
+	// the window ends the next day, so the day of the window is the day it started.
+	if now >= start {
+		return w.appliesOn(local.Weekday())
+	}
+	return now < end && w.appliesOn(local.AddDate(0, 0, -1).Weekday())
+}
+
+// appliesOn checks if the window is configured for this day of the week.
+func (w *TimeWindow) appliesOn(day time.Weekday) bool {
+	if len(w.GetDays()) == 0 {
+		return true
+	}
+	for _, d := range w.GetDays() {
+		if weekday, ok := weekdays[strings.ToLower(d)]; ok && weekday == day {
+			return true
+		}
+	}
+	return false
+}
+
+// getStartTime returns the start time as a duration since midnight.
+func (w *TimeWindow) getStartTime() (time.Duration, error) {
+	if w.StartTime == nil {
+		return 0, nil
+	}
+	return parseTimeOfDay(*w.StartTime)
+}
+
+// getEndTime returns the end time as a duration since midnight.
+func (w *TimeWindow) getEndTime() (time.Duration, error) {
+	if w.EndTime == nil {
+		return 24 * time.Hour, nil
+	}
+	return parseTimeOfDay(*w.EndTime)
+}
+
+// getLocation returns the timezone of the time window.
+func (w *TimeWindow) getLocation() (*time.Location, error) {
+	if w.Timezone == nil {
+		return time.UTC, nil
+	}
+	if location, ok := locationCache.Load(*w.Timezone); ok {
+		return location.(*time.Location), nil
+	}
+	location, err := time.LoadLocation(*w.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	locationCache.Store(*w.Timezone, location)
+	return location, nil
+}
+
+// GetDays is the getter of the field Days
+func (w *TimeWindow) GetDays() []string {
+	if w.Days == nil {
+		return []string{}
+	}
+	return *w.Days
+}
+
+// GetTimezone is the getter of the field Timezone
+func (w *TimeWindow) GetTimezone() string {
+	if w.Timezone == nil {
+		return ""
+	}
+	return *w.Timezone
+}
+
+// parseTimeOfDay converts a time in the format HH:MM to a duration since midnight.
+func parseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse(timeOfDayLayout, strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// isInTimeWindows checks if the date is inside at least one of the time windows.
+// No time window means that there is no restriction.
+func isInTimeWindows(windows []TimeWindow, date time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	for _, window := range windows {
+		if window.contains(date) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package flag_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+func TestInternalFlag_TimeWindows(t *testing.T) {
+	now := time.Now().In(time.UTC)
+	currentWindow := flag.TimeWindow{
+		StartTime: testconvert.String(now.Add(-1 * time.Hour).Format("15:04")),
+		EndTime:   testconvert.String(now.Add(1 * time.Hour).Format("15:04")),
+		Timezone:  testconvert.String("UTC"),
+	}
+	futureWindow := flag.TimeWindow{
+		StartTime: testconvert.String(now.Add(1 * time.Hour).Format("15:04")),
+		EndTime:   testconvert.String(now.Add(2 * time.Hour).Format("15:04")),
+		Timezone:  testconvert.String("UTC"),
+	}
+
+	tests := []struct {
+		name         string
+		timeWindows  *[]flag.TimeWindow
+		rules        *[]flag.Rule
+		want         interface{}
+		wantVariant  string
+		wantReason   flag.ResolutionReason
+		wantRuleName *string
+		wantSkipped  bool
+	}{
+		{
+			name:        "flag inside its time window should be evaluated",
+			timeWindows: &[]flag.TimeWindow{futureWindow, currentWindow},
+			want:        "A",
+			wantVariant: "A",
+			wantReason:  flag.ReasonStatic,
+		},
+		{
+			name:        "flag outside its time window should serve the default value",
+			timeWindows: &[]flag.TimeWindow{futureWindow},
+			want:        "default-sdk",
+			wantVariant: flag.VariationSDKDefault,
+			wantReason:  flag.ReasonOutsideTimeWindow,
+		},
+		{
+			name: "flag outside of the days of its time window should serve the default value",
+			timeWindows: &[]flag.TimeWindow{{
+				Days:     &[]string{now.AddDate(0, 0, 2).Weekday().String()},
+				Timezone: testconvert.String("UTC"),
+			}},
+			want:        "default-sdk",
+			wantVariant: flag.VariationSDKDefault,
+			wantReason:  flag.ReasonOutsideTimeWindow,
+		},
+		{
+			name: "rule should only apply inside its time window",
+			rules: &[]flag.Rule{
+				{
+					Name:            testconvert.String("future"),
+					TimeWindows:     &[]flag.TimeWindow{futureWindow},
+					VariationResult: testconvert.String("A"),
+				},
+				{
+					Name:            testconvert.String("current"),
+					TimeWindows:     &[]flag.TimeWindow{currentWindow},
+					VariationResult: testconvert.String("B"),
+				},
+			},
+			want:         "B",
+			wantVariant:  "B",
+			wantReason:   flag.ReasonTargetingMatch,
+			wantRuleName: testconvert.String("current"),
+			wantSkipped:  true,
+		},
+		{
+			name: "default rule served because a rule is outside its time window",
+			rules: &[]flag.Rule{
+				{
+					Name:            testconvert.String("future"),
+					TimeWindows:     &[]flag.TimeWindow{futureWindow},
+					VariationResult: testconvert.String("B"),
+				},
+			},
+			want:        "A",
+			wantVariant: "A",
+			wantReason:  flag.ReasonOutsideRuleTimeWindow,
+			wantSkipped: true,
+		},
+		{
+			name: "rule not matching is not reported as outside its time window",
+			rules: &[]flag.Rule{
+				{
+					Name:            testconvert.String("current"),
+					Query:           testconvert.String(`key eq "user-2"`),
+					TimeWindows:     &[]flag.TimeWindow{currentWindow},
+					VariationResult: testconvert.String("B"),
+				},
+			},
+			want:        "A",
+			wantVariant: "A",
+			wantReason:  flag.ReasonDefault,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := flag.InternalFlag{
+				Variations: &map[string]*interface{}{
+					"A": testconvert.Interface("A"),
+					"B": testconvert.Interface("B"),
+				},
+				Rules:       tt.rules,
+				DefaultRule: &flag.Rule{VariationResult: testconvert.String("A")},
+				TimeWindows: tt.timeWindows,
+			}
+			assert.NoError(t, f.IsValid())
+			got, details, trace := f.Explain("my-flag", ffcontext.NewEvaluationContext("user-1"),
+				flag.Context{DefaultSdkValue: "default-sdk"})
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantVariant, details.Variant)
+			assert.Equal(t, tt.wantReason, details.Reason)
+			assert.Equal(t, tt.wantRuleName, details.RuleName)
+			assert.False(t, details.Cacheable)
+			skipped := false
+			for _, step := range trace.Steps {
+				if step.Type == flag.TraceStepRuleOutsideTimeWindow {
+					skipped = true
+					assert.False(t, step.Rule.InTimeWindows)
+				}
+			}
+			assert.Equal(t, tt.wantSkipped, skipped)
+		})
+	}
+}
+
+func TestInternalFlag_IsValidTimeWindows(t *testing.T) {
+	tests := []struct {
+		name     string
+		window   flag.TimeWindow
+		errorMsg string
+	}{
+		{
+			name:     "unknown day",
+			window:   flag.TimeWindow{Days: &[]string{"someday"}},
+			errorMsg: "invalid time window, unknown day: someday",
+		},
+		{
+			name:     "invalid start time",
+			window:   flag.TimeWindow{StartTime: testconvert.String("2am")},
+			errorMsg: "invalid time window, start time should be in the format HH:MM",
+		},
+		{
+			name:     "same start and end time",
+			window:   flag.TimeWindow{StartTime: testconvert.String("02:00"), EndTime: testconvert.String("02:00")},
+			errorMsg: "invalid time window, start and end time should be different",
+		},
+		{
+			name:     "unknown timezone",
+			window:   flag.TimeWindow{Timezone: testconvert.String("Europe/Nowhere")},
+			errorMsg: "invalid time window, unknown timezone Europe/Nowhere",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := flag.InternalFlag{
+				Variations:  &map[string]*interface{}{"A": testconvert.Interface("A")},
+				DefaultRule: &flag.Rule{VariationResult: testconvert.String("A")},
+				TimeWindows: &[]flag.TimeWindow{tt.window},
+			}
+			err := f.IsValid()
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package flag
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/thomaspoignant/go-feature-flag/internal/schema"
+)
+
+// ValueType is the type of the values of a flag, it can be declared in the configuration of the flag.
+type ValueType = string
+
+const (
+	ValueTypeBool   ValueType = "bool"
+	ValueTypeString ValueType = "string"
+	ValueTypeInt    ValueType = "int"
+	ValueTypeFloat  ValueType = "float"
+	ValueTypeJSON   ValueType = "json"
+)
+
+// isValidValueType checks if the type is one of the types available for a flag.
+func isValidValueType(valueType ValueType) error {
+	switch valueType {
+	case ValueTypeBool, ValueTypeString, ValueTypeInt, ValueTypeFloat, ValueTypeJSON:
+		return nil
+	default:
+		return fmt.Errorf("invalid type %s, available types are %s, %s, %s, %s and %s", valueType,
+			ValueTypeBool, ValueTypeString, ValueTypeInt, ValueTypeFloat, ValueTypeJSON)
+	}
+}
+
+// MatchValueType checks if the value is of the declared type of a flag.
+// An int accepts the numbers without decimal part and a json accepts objects and arrays.
+func MatchValueType(valueType ValueType, value interface{}) bool {
+	switch valueType {
+	case ValueTypeBool:
+		_, ok := value.(bool)
+		return ok
+	case ValueTypeString:
+		_, ok := value.(string)
+		return ok
+	case ValueTypeInt:
+		switch v := value.(type) {
+		case int, int64:
+			return true
+		case float64:
+			return v == math.Trunc(v)
+		}
+		return false
+	case ValueTypeFloat:
+		switch value.(type) {
+		case int, int64, float64:
+			return true
+		}
+		return false
+	case ValueTypeJSON:
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// ConvertToValueType converts the value to the Go type of the declared type of a flag,
+// the numbers of an int flag are returned as int and the ones of a float flag as float64.
+func ConvertToValueType(valueType ValueType, value interface{}) interface{} {
+	switch valueType {
+	case ValueTypeInt:
+		switch v := value.(type) {
+		case float64:
+			return int(v)
+		case int64:
+			return int(v)
+		}
+	case ValueTypeFloat:
+		switch v := value.(type) {
+		case int:
+			return float64(v)
+		case int64:
+			return float64(v)
+		}
+	}
+	return value
+}
+
+// isValidVariationValue checks that the value of a variation follows the declared type and the schema of the flag.
+func (f *InternalFlag) isValidVariationValue(name string, value interface{}) error {
+	if f.Type != nil && !MatchValueType(f.GetType(), value) {
+		return fmt.Errorf("invalid variation %s: the value is not of type %s", name, f.GetType())
+	}
+	if f.Schema != nil {
+		if err := schema.Validate(f.GetSchema(), value); err != nil {
+			return fmt.Errorf("invalid variation %s: the value does not match the schema: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package flag_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+func TestInternalFlag_TypeAndSchema(t *testing.T) {
+	schema := &map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"url"},
+		"properties": map[string]interface{}{
+			"url":     map[string]interface{}{"type": "string"},
+			"retries": map[string]interface{}{"type": "integer", "minimum": 0},
+		},
+	}
+	regional := testconvert.Interface(map[string]interface{}{
+		"url": "https://{{ .region }}.cdn.example.com", "retries": float64(3),
+	})
+
+	tests := []struct {
+		name        string
+		flagType    *string
+		schema      *map[string]interface{}
+		variations  *map[string]*interface{}
+		defaultRule string
+		scheduled   *[]flag.ScheduledStep
+		wantErr     string
+	}{
+		{
+			name:     "valid flag",
+			flagType: testconvert.String(flag.ValueTypeJSON),
+			schema:   schema,
+			variations: &map[string]*interface{}{
+				"regional": regional,
+				"global":   testconvert.Interface(map[string]interface{}{"url": "https://cdn.example.com"}),
+			},
+			defaultRule: "global",
+		},
+		{
+			name:     "unknown type",
+			flagType: testconvert.String("object"),
+			schema:   schema,
+			variations: &map[string]*interface{}{
+				"regional": regional,
+				"global":   testconvert.Interface(map[string]interface{}{"url": "https://cdn.example.com"}),
+			},
+			defaultRule: "global",
+			wantErr:     "invalid type object, available types are bool, string, int, float and json",
+		},
+		{
+			name:     "variation not of the declared type",
+			flagType: testconvert.String(flag.ValueTypeInt),
+			variations: &map[string]*interface{}{
+				"low":  testconvert.Interface(float64(1)),
+				"high": testconvert.Interface(2.5),
+			},
+			defaultRule: "low",
+			wantErr:     "invalid variation high: the value is not of type int",
+		},
+		{
+			name:     "variation not matching the schema",
+			flagType: testconvert.String(flag.ValueTypeJSON),
+			schema:   schema,
+			variations: &map[string]*interface{}{
+				"regional": regional,
+				"global":   testconvert.Interface(map[string]interface{}{"retries": float64(1)}),
+			},
+			defaultRule: "global",
+			wantErr:     "invalid variation global: the value does not match the schema: /: missing properties: 'url'",
+		},
+		{
+			name:     "variation of a scheduled step not matching the schema",
+			flagType: testconvert.String(flag.ValueTypeJSON),
+			schema:   schema,
+			variations: &map[string]*interface{}{
+				"regional": regional,
+				"global":   testconvert.Interface(map[string]interface{}{"url": "https://cdn.example.com"}),
+			},
+			defaultRule: "global",
+			scheduled: &[]flag.ScheduledStep{
+				{
+					InternalFlag: flag.InternalFlag{
+						Variations: &map[string]*interface{}{
+							"global": testconvert.Interface(map[string]interface{}{
+								"url": "https://cdn.example.com", "retries": float64(-1),
+							}),
+						},
+					},
+					Date: testconvert.Time(time.Now().Add(time.Hour)),
+				},
+			},
+			wantErr: "invalid variation global: the value does not match the schema: /retries: must be >= 0 but found -1", // nolint: lll
+		},
+		{
+			name:     "invalid schema",
+			flagType: testconvert.String(flag.ValueTypeJSON),
+			schema:   &map[string]interface{}{"type": "map"},
+			variations: &map[string]*interface{}{
+				"global": testconvert.Interface(map[string]interface{}{"url": "https://cdn.example.com"}),
+			},
+			defaultRule: "global",
+			wantErr: `invalid schema: /type: value must be one of "array", "boolean", "integer", "null", ` +
+				`"number", "object", "string"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := flag.InternalFlag{
+				Type:        tt.flagType,
+				Schema:      tt.schema,
+				Variations:  tt.variations,
+				DefaultRule: &flag.Rule{VariationResult: testconvert.String(tt.defaultRule)},
+				Scheduled:   tt.scheduled,
+			}
+			err := f.IsValid()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestConvertToValueType(t *testing.T) {
+	tests := []struct {
+		name      string
+		valueType flag.ValueType
+		value     interface{}
+		want      interface{}
+	}{
+		{
+			name:      "float to int",
+			valueType: flag.ValueTypeInt,
+			value:     float64(3),
+			want:      3,
+		},
+		{
+			name:      "int to float",
+			valueType: flag.ValueTypeFloat,
+			value:     3,
+			want:      float64(3),
+		},
+		{
+			name:      "string unchanged",
+			valueType: flag.ValueTypeString,
+			value:     "3",
+			want:      "3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, flag.ConvertToValueType(tt.valueType, tt.value))
+		})
+	}
+}
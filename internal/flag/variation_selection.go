@@ -15,6 +15,19 @@ type variationSelection struct {
 	// ruleName (optional) is the name of the associated rule if we have one
 	ruleName *string
 
+	// targetName (optional) is the name of the target list if the variation was selected by a target
+	targetName *string
+
 	// cacheable is set to true if a provider/SDK can cache the value
 	cacheable bool
+
+	// bucketingKey is the attribute used to bucket the evaluation context,
+	// empty if the targeting key is used or if the variation was not selected by a percentage
+	bucketingKey string
+
+	// bucketingValue is the value of the bucketing attribute for the evaluation context
+	bucketingValue string
+
+	// sticky is set to true if the variation comes from the sticky bucketing store
+	sticky bool
 }
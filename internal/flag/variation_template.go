@@ -0,0 +1,145 @@
+package flag
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/utils"
+)
+
+// templateDelimiter is the marker of a template in a string value of a variation.
+const templateDelimiter = "{{"
+
+// variationTemplates returns the templates of the string values of a variation
+// (including the string values of a JSON variation).
+func variationTemplates(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		if strings.Contains(v, templateDelimiter) {
+			return []string{v}
+		}
+	case []interface{}:
+		templates := make([]string, 0)
+		for _, item := range v {
+			templates = append(templates, variationTemplates(item)...)
+		}
+		return templates
+	case map[string]interface{}:
+		templates := make([]string, 0)
+		for _, item := range v {
+			templates = append(templates, variationTemplates(item)...)
+		}
+		return templates
+	}
+	return nil
+}
+
+// validateVariationTemplate checks that all the templates of the value of a variation can be parsed.
+func validateVariationTemplate(value interface{}) error {
+	for _, source := range variationTemplates(value) {
+		if _, err := parseVariationTemplate(source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseVariationTemplate parses a template, a missing attribute of the evaluation context is an error
+// when rendering it.
+func parseVariationTemplate(source string) (*template.Template, error) {
+	tmpl, err := template.New("variation").Option("missingkey=error").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %q: %w", source, err)
+	}
+	return tmpl, nil
+}
+
+// ParseVariationTemplates parses the templates of the variations of the flag (including the ones from the
+// scheduled rollout steps) and keeps them in the flag, so the evaluations do not parse the templates.
+// It is done once when loading the flag in the cache, only if the flag has templated variations.
+func (f *InternalFlag) ParseVariationTemplates() {
+	if !f.IsTemplatedVariations() {
+		return
+	}
+	f.templates = make(map[string]*template.Template)
+	variations := []map[string]*interface{}{f.GetVariations()}
+	for _, step := range f.GetScheduled() {
+		variations = append(variations, step.GetVariations())
+	}
+	for _, values := range variations {
+		for _, value := range values {
+			if value == nil {
+				continue
+			}
+			for _, source := range variationTemplates(*value) {
+				if tmpl, err := parseVariationTemplate(source); err == nil {
+					f.templates[source] = tmpl
+				}
+			}
+		}
+	}
+}
+
+// getVariationTemplate returns the parsed template, a flag that has not been loaded by the cache
+// parses it on the fly.
+func (f *InternalFlag) getVariationTemplate(source string) (*template.Template, error) {
+	if tmpl, ok := f.templates[source]; ok {
+		return tmpl, nil
+	}
+	return parseVariationTemplate(source)
+}
+
+// renderVariationTemplate renders the templates of the value of a variation with the attributes
+// of the evaluation context. The value of the variation is copied, it is never modified.
+func (f *InternalFlag) renderVariationTemplate(value interface{}, data map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if !strings.Contains(v, templateDelimiter) {
+			return v, nil
+		}
+		tmpl, err := f.getVariationTemplate(v)
+		if err != nil {
+			return nil, err
+		}
+		var rendered strings.Builder
+		if err := tmpl.Execute(&rendered, data); err != nil {
+			return nil, err
+		}
+		return rendered.String(), nil
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for index, item := range v {
+			renderedItem, err := f.renderVariationTemplate(item, data)
+			if err != nil {
+				return nil, err
+			}
+			items[index] = renderedItem
+		}
+		return items, nil
+	case map[string]interface{}:
+		items := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			renderedItem, err := f.renderVariationTemplate(item, data)
+			if err != nil {
+				return nil, err
+			}
+			items[key] = renderedItem
+		}
+		return items, nil
+	}
+	return value, nil
+}
+
+// renderVariationValue returns the value of the variation with its templates rendered for the evaluation context.
+// The values are rendered only if the flag has templated variations, the boolean is true if the value
+// depends on the evaluation context.
+func (f *InternalFlag) renderVariationValue(name string, ctx ffcontext.Context) (interface{}, bool, error) {
+	value := f.GetVariationValue(name)
+	if !f.IsTemplatedVariations() || len(variationTemplates(value)) == 0 {
+		return value, false, nil
+	}
+	rendered, err := f.renderVariationTemplate(value, utils.ContextToMap(ctx))
+	return rendered, true, err
+}
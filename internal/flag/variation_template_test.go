@@ -0,0 +1,132 @@
+package flag_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+func TestInternalFlag_VariationTemplates(t *testing.T) {
+	regional := map[string]interface{}{
+		"url":     "https://{{ .region }}.cdn.example.com",
+		"tenants": []interface{}{"{{ .company.id }}", "shared"},
+		"retries": float64(3),
+	}
+	global := map[string]interface{}{
+		"url":     "https://cdn.example.com",
+		"tenants": []interface{}{"shared"},
+		"retries": float64(3),
+	}
+	sdkDefault := map[string]interface{}{}
+	tests := []struct {
+		name                string
+		templatedVariations *bool
+		ctx                 ffcontext.Context
+		want                interface{}
+		wantVariant         string
+		wantErrorCode       flag.ErrorCode
+		wantCacheable       bool
+	}{
+		{
+			name:                "render the attributes of the evaluation context",
+			templatedVariations: testconvert.Bool(true),
+			ctx: ffcontext.NewEvaluationContextBuilder("user-1").
+				AddCustom("region", "eu").
+				AddCustom("company", map[string]interface{}{"id": "acme"}).Build(),
+			want: map[string]interface{}{
+				"url":     "https://eu.cdn.example.com",
+				"tenants": []interface{}{"acme", "shared"},
+				"retries": float64(3),
+			},
+			wantVariant: "regional",
+		},
+		{
+			name:                "variation without template is cacheable",
+			templatedVariations: testconvert.Bool(true),
+			ctx:                 ffcontext.NewEvaluationContext("user-1"),
+			want:                global,
+			wantVariant:         "global",
+			wantCacheable:       true,
+		},
+		{
+			name:                "missing attribute returns the SDK default",
+			templatedVariations: testconvert.Bool(true),
+			ctx:                 ffcontext.NewEvaluationContextBuilder("user-1").AddCustom("region", "us").Build(),
+			want:                sdkDefault,
+			wantVariant:         flag.VariationSDKDefault,
+			wantErrorCode:       flag.ErrorTemplateRendering,
+		},
+		{
+			name:          "templates are not rendered if the flag has no templated variations",
+			ctx:           ffcontext.NewEvaluationContextBuilder("user-1").AddCustom("region", "us").Build(),
+			want:          regional,
+			wantVariant:   "regional",
+			wantCacheable: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := flag.InternalFlag{
+				Variations: &map[string]*interface{}{
+					"regional": testconvert.Interface(regional),
+					"global":   testconvert.Interface(global),
+				},
+				Rules: &[]flag.Rule{
+					{
+						Query:           testconvert.String(`region in ["eu", "us"]`),
+						VariationResult: testconvert.String("regional"),
+					},
+				},
+				DefaultRule:         &flag.Rule{VariationResult: testconvert.String("global")},
+				TemplatedVariations: tt.templatedVariations,
+			}
+			assert.NoError(t, f.IsValid())
+			f.ParseVariationTemplates()
+
+			value, details := f.Value("my-flag", tt.ctx, flag.Context{DefaultSdkValue: sdkDefault})
+			assert.Equal(t, tt.want, value)
+			assert.Equal(t, tt.wantVariant, details.Variant)
+			assert.Equal(t, tt.wantErrorCode, details.ErrorCode)
+			assert.Equal(t, tt.wantCacheable, details.Cacheable)
+			assert.Equal(t, "https://{{ .region }}.cdn.example.com",
+				f.GetVariationValue("regional").(map[string]interface{})["url"], "the variation should not be modified")
+		})
+	}
+}
+
+func TestInternalFlag_IsValidVariationTemplates(t *testing.T) {
+	tests := []struct {
+		name                string
+		templatedVariations *bool
+		wantErr             string
+	}{
+		{
+			name:                "invalid template",
+			templatedVariations: testconvert.Bool(true),
+			wantErr:             "invalid variation A: invalid template",
+		},
+		{
+			name: "template not checked if the flag has no templated variations",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := flag.InternalFlag{
+				Variations: &map[string]*interface{}{
+					"A": testconvert.Interface("https://{{ .region }.cdn.example.com"),
+				},
+				DefaultRule:         &flag.Rule{VariationResult: testconvert.String("A")},
+				TemplatedVariations: tt.templatedVariations,
+			}
+			err := f.IsValid()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -9,6 +9,7 @@ type FlagState struct {
 	Value         interface{}            `json:"value"`
 	Timestamp     int64                  `json:"timestamp"`
 	VariationType string                 `json:"variationType"`
+	ValueType     string                 `json:"valueType,omitempty"`
 	TrackEvents   bool                   `json:"trackEvents"`
 	Failed        bool                   `json:"-"`
 	ErrorCode     flag.ErrorCode         `json:"errorCode"`
@@ -0,0 +1,15 @@
+package internalerror
+
+import (
+	"fmt"
+)
+
+// BucketingKeyMissing is returned when the attribute used to bucket the evaluation
+// context is not available in the context.
+type BucketingKeyMissing struct {
+	BucketingKey string
+}
+
+func (m *BucketingKeyMissing) Error() string {
+	return fmt.Sprintf("bucketing key %s is missing in the evaluation context", m.BucketingKey)
+}
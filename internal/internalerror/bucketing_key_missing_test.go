@@ -0,0 +1,12 @@
+package internalerror
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketingKeyMissing_Error(t *testing.T) {
+	m := &BucketingKeyMissing{BucketingKey: "organizationId"}
+	assert.EqualError(t, m, "bucketing key organizationId is missing in the evaluation context")
+}
@@ -0,0 +1,17 @@
+package internalerror
+
+import (
+	"fmt"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+)
+
+// RuleOutsideTimeWindow is returned when a rule is skipped because the evaluation date is outside its time windows.
+type RuleOutsideTimeWindow struct {
+	Context ffcontext.Context
+}
+
+func (m *RuleOutsideTimeWindow) Error() string {
+	return fmt.Sprintf("Rule does not apply for this user %s, the evaluation date is outside its time windows",
+		m.Context.GetKey())
+}
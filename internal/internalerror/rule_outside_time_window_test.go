@@ -0,0 +1,28 @@
+package internalerror
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+)
+
+func TestRuleOutsideTimeWindow_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		context ffcontext.Context
+		want    string
+	}{
+		{
+			name:    "Test RuleOutsideTimeWindow_Error",
+			context: ffcontext.NewEvaluationContext("test"),
+			want:    "Rule does not apply for this user test, the evaluation date is outside its time windows",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &RuleOutsideTimeWindow{Context: tt.context}
+			assert.EqualError(t, m, tt.want)
+		})
+	}
+}
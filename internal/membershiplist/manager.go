@@ -0,0 +1,158 @@
+package membershiplist
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/utils/fflog"
+)
+
+// Status is the state of a membership list.
+type Status struct {
+	// Size is the number of values in the list.
+	Size int `json:"size"`
+
+	// LastRefresh is the last time the list was successfully retrieved.
+	LastRefresh time.Time `json:"lastRefresh"`
+
+	// Error (optional) is the error of the last retrieval, the previous version of the list is kept in that case.
+	Error string `json:"error,omitempty"`
+}
+
+// Manager keeps in memory the membership lists, each list is loaded by its own retriever.
+// The lists are stored as sets, so checking if a value is part of a list does not depend on its size.
+type Manager struct {
+	names            []string
+	retrieverManager *retriever.Manager
+	logger           *log.Logger
+
+	mutex   sync.RWMutex
+	lists   map[string]map[string]struct{}
+	status  map[string]Status
+	version uint64
+}
+
+// NewManager creates a new Manager for the lists, indexed by their names.
+func NewManager(ctx context.Context, retrievers map[string]retriever.Retriever, logger *log.Logger) *Manager {
+	names := make([]string, 0, len(retrievers))
+	for name := range retrievers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	orderedRetrievers := make([]retriever.Retriever, 0, len(names))
+	for _, name := range names {
+		orderedRetrievers = append(orderedRetrievers, retrievers[name])
+	}
+
+	return &Manager{
+		names:            names,
+		retrieverManager: retriever.NewManager(ctx, orderedRetrievers, logger),
+		logger:           logger,
+		lists:            make(map[string]map[string]struct{}, len(names)),
+		status:           make(map[string]Status, len(names)),
+	}
+}
+
+// Init initializes the retrievers of the lists.
+func (m *Manager) Init(ctx context.Context) error {
+	return m.retrieverManager.Init(ctx)
+}
+
+// Shutdown shuts down the retrievers of the lists.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	return m.retrieverManager.Shutdown(ctx)
+}
+
+// Refresh retrieves all the lists in parallel.
+// If a list cannot be retrieved, the previous version is kept and an error is returned.
+func (m *Manager) Refresh(ctx context.Context) error {
+	retrievers := m.retrieverManager.GetRetrievers()
+	type result struct {
+		name   string
+		values map[string]struct{}
+		err    error
+	}
+
+	results := make(chan result, len(retrievers))
+	var wg sync.WaitGroup
+	for index, r := range retrievers {
+		wg.Add(1)
+		go func(name string, r retriever.Retriever) {
+			defer wg.Done()
+			if rr, ok := r.(retriever.InitializableRetriever); ok && rr.Status() != retriever.RetrieverReady {
+				results <- result{name: name, err: fmt.Errorf("retriever not ready")}
+				return
+			}
+			content, err := r.Retrieve(ctx)
+			if err != nil {
+				results <- result{name: name, err: err}
+				return
+			}
+			values, err := Parse(content)
+			results <- result{name: name, values: values, err: err}
+		}(m.names[index], r)
+	}
+	wg.Wait()
+	close(results)
+
+	errs := make([]string, 0)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for res := range results {
+		status := m.status[res.name]
+		if res.err != nil {
+			status.Error = res.err.Error()
+			m.status[res.name] = status
+			errs = append(errs, fmt.Sprintf("%s: %s", res.name, res.err))
+			fflog.Printf(m.logger, "error: impossible to retrieve the membership list %s: %v", res.name, res.err)
+			continue
+		}
+		m.lists[res.name] = res.values
+		m.status[res.name] = Status{Size: len(res.values), LastRefresh: time.Now()}
+		m.version++
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("impossible to retrieve the membership lists: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// Contains checks if a value is part of the list, ok is false if the list has never been retrieved.
+func (m *Manager) Contains(listName string, value string) (bool, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	list, ok := m.lists[listName]
+	if !ok {
+		return false, false
+	}
+	_, contains := list[value]
+	return contains, true
+}
+
+// Version changes each time a list is updated, the results depending on the lists should not be reused
+// after a change of version.
+func (m *Manager) Version() uint64 {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.version
+}
+
+// GetStatus returns the state of all the lists.
+func (m *Manager) GetStatus() map[string]Status {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	status := make(map[string]Status, len(m.names))
+	for _, name := range m.names {
+		status[name] = m.status[name]
+	}
+	return status
+}
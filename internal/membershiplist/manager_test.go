@@ -0,0 +1,69 @@
+package membershiplist_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/internal/membershiplist"
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
+)
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "accounts.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("account-1\naccount-2\n"), 0600))
+
+	manager := membershiplist.NewManager(ctx, map[string]retriever.Retriever{
+		"accounts": &fileretriever.Retriever{Path: path},
+	}, nil)
+	assert.NoError(t, manager.Init(ctx))
+
+	// the lists are not available before the first refresh
+	contains, ok := manager.Contains("accounts", "account-1")
+	assert.False(t, contains)
+	assert.False(t, ok)
+	assert.Equal(t, uint64(0), manager.Version())
+
+	assert.NoError(t, manager.Refresh(ctx))
+	assert.Equal(t, uint64(1), manager.Version())
+	contains, ok = manager.Contains("accounts", "account-1")
+	assert.True(t, contains)
+	assert.True(t, ok)
+	contains, ok = manager.Contains("accounts", "account-3")
+	assert.False(t, contains)
+	assert.True(t, ok)
+	_, ok = manager.Contains("unknown", "account-1")
+	assert.False(t, ok)
+
+	status := manager.GetStatus()["accounts"]
+	assert.Equal(t, 2, status.Size)
+	assert.False(t, status.LastRefresh.IsZero())
+	assert.Empty(t, status.Error)
+	assert.NoError(t, manager.Shutdown(ctx))
+}
+
+func TestManager_KeepPreviousValuesOnError(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "accounts.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`["account-1"]`), 0600))
+
+	manager := membershiplist.NewManager(ctx, map[string]retriever.Retriever{
+		"accounts": &fileretriever.Retriever{Path: path},
+	}, nil)
+	assert.NoError(t, manager.Refresh(ctx))
+
+	assert.NoError(t, os.Remove(path))
+	assert.Error(t, manager.Refresh(ctx))
+	assert.Equal(t, uint64(1), manager.Version(), "a list that cannot be retrieved does not change the version")
+	contains, ok := manager.Contains("accounts", "account-1")
+	assert.True(t, contains)
+	assert.True(t, ok)
+
+	status := manager.GetStatus()["accounts"]
+	assert.Equal(t, 1, status.Size)
+	assert.NotEmpty(t, status.Error)
+}
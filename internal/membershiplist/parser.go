@@ -0,0 +1,55 @@
+package membershiplist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Parse reads the content of a membership list and returns its values as a set.
+// The content is either a JSON array or a list of values separated by new lines (empty lines are ignored).
+func Parse(content []byte) (map[string]struct{}, error) {
+	trimmed := bytes.TrimSpace(content)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		return parseJSONArray(trimmed)
+	}
+
+	values := make(map[string]struct{})
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	for scanner.Scan() {
+		value := strings.TrimSpace(scanner.Text())
+		if value == "" {
+			continue
+		}
+		values[value] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parseJSONArray reads a JSON array of strings or numbers, the numbers are kept as they are written.
+func parseJSONArray(content []byte) (map[string]struct{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var items []interface{}
+	if err := decoder.Decode(&items); err != nil {
+		return nil, fmt.Errorf("invalid JSON array: %w", err)
+	}
+
+	values := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			values[v] = struct{}{}
+		case json.Number:
+			values[v.String()] = struct{}{}
+		default:
+			return nil, fmt.Errorf("invalid value in the JSON array: %v", item)
+		}
+	}
+	return values, nil
+}
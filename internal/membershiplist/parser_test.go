@@ -0,0 +1,55 @@
+package membershiplist_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/internal/membershiplist"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]struct{}
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "newline-delimited",
+			content: "account-1\n  account-2  \n\naccount-3\n",
+			want:    map[string]struct{}{"account-1": {}, "account-2": {}, "account-3": {}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "JSON array of strings and numbers",
+			content: `["account-1", 1234567890123]`,
+			want:    map[string]struct{}{"account-1": {}, "1234567890123": {}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "empty content",
+			content: "",
+			want:    map[string]struct{}{},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "invalid JSON array",
+			content: `["account-1",`,
+			wantErr: assert.Error,
+		},
+		{
+			name:    "JSON array with an object",
+			content: `[{"id": "account-1"}]`,
+			wantErr: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := membershiplist.Parse([]byte(tt.content))
+			tt.wantErr(t, err)
+			if err == nil {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/ext"
+)
+
+// celBaseEnv is the CEL environment with the functions available in all the expressions,
+// the attributes of the evaluation context are declared for each expression when compiling it.
+var celBaseEnv = newCELBaseEnv()
+
+// celTypeNames are the identifiers of the CEL types, they are not attributes of the evaluation context.
+var celTypeNames = map[string]bool{
+	"bool": true, "bytes": true, "double": true, "dyn": true, "int": true, "list": true, "map": true,
+	"null_type": true, "string": true, "type": true, "uint": true,
+}
+
+func newCELBaseEnv() *cel.Env {
+	env, err := cel.NewEnv(
+		ext.Strings(),
+		cel.CrossTypeNumericComparisons(true),
+		cel.Function("semver",
+			cel.Overload("semver_string_string_string",
+				[]*cel.Type{cel.StringType, cel.StringType, cel.StringType}, cel.BoolType,
+				cel.FunctionBinding(celSemver))),
+		cel.Function("inCIDR",
+			cel.Overload("inCIDR_string_string",
+				[]*cel.Type{cel.StringType, cel.StringType}, cel.BoolType,
+				cel.BinaryBinding(celInCIDR))),
+	)
+	if err != nil {
+		panic(fmt.Sprintf("impossible to create the CEL environment: %s", err))
+	}
+	return env
+}
+
+// celExpression is a CEL expression ready to be evaluated.
+type celExpression struct {
+	program    cel.Program
+	attributes []string
+}
+
+// CompileCEL parses and checks an expression written in the Common Expression Language (https://cel.dev).
+// The standard definitions, the string extensions, a semver(version, operator, other) function and
+// an inCIDR(ip, network) function are available.
+// The attributes of the evaluation context used by the expression are declared with a dynamic type.
+func CompileCEL(query string) (Expression, error) {
+	parsed, issues := celBaseEnv.Parse(query)
+	if issues.Err() != nil {
+		return nil, fmt.Errorf("invalid CEL expression: %w", issues.Err())
+	}
+
+	variables := celVariables(parsed.NativeRep().Expr(), map[string]bool{}, make([]string, 0))
+	options := make([]cel.EnvOption, 0, len(variables)+1)
+	for _, variable := range variables {
+		options = append(options, cel.Variable(variable, cel.DynType))
+	}
+	if !slices.Contains(variables, targetingKeyAttribute) {
+		options = append(options, cel.Variable(targetingKeyAttribute, cel.DynType))
+	}
+	env, err := celBaseEnv.Extend(options...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CEL expression: %w", err)
+	}
+
+	checked, issues := env.Check(parsed)
+	if issues.Err() != nil {
+		return nil, fmt.Errorf("invalid CEL expression: %w", issues.Err())
+	}
+	if outputType := checked.OutputType(); outputType != cel.BoolType && outputType != cel.DynType {
+		return nil, fmt.Errorf("invalid CEL expression: the expression should return a bool, got %s", outputType)
+	}
+	program, err := env.Program(checked)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CEL expression: %w", err)
+	}
+	return &celExpression{
+		program:    program,
+		attributes: celAttributes(parsed.NativeRep().Expr(), map[string]bool{}, make([]string, 0)),
+	}, nil
+}
+
+// Evaluate checks if the evaluation context matches the CEL expression, the expression should return a bool.
+func (e *celExpression) Evaluate(data map[string]interface{}) (bool, error) {
+	activation := data
+	if _, ok := data[targetingKeyAttribute]; !ok {
+		if key, ok := data["key"]; ok {
+			activation = make(map[string]interface{}, len(data)+1)
+			for name, value := range data {
+				activation[name] = value
+			}
+			activation[targetingKeyAttribute] = key
+		}
+	}
+
+	result, _, err := e.program.Eval(activation)
+	if err != nil {
+		return false, err
+	}
+	match, ok := result.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("the CEL expression should return a bool, got %s", result.Type())
+	}
+	return match, nil
+}
+
+// Attributes returns the attributes of the evaluation context used by the CEL expression.
+func (e *celExpression) Attributes() []string {
+	return append([]string{}, e.attributes...)
+}
+
+// celVariables returns the identifiers of the expression that are attributes of the evaluation context,
+// bound contains the variables of the macros that are not attributes of the context.
+func celVariables(expr ast.Expr, bound map[string]bool, variables []string) []string {
+	celVisit(expr, bound, func(e ast.Expr, bound map[string]bool) bool {
+		if e.Kind() == ast.IdentKind && !bound[e.AsIdent()] && !celTypeNames[e.AsIdent()] {
+			variables = appendUnique(variables, e.AsIdent())
+		}
+		return true
+	})
+	return variables
+}
+
+// celAttributes returns the attributes of the evaluation context used by the expression,
+// a field selection on an attribute is returned as a dot-separated path (ex: company.id).
+func celAttributes(expr ast.Expr, bound map[string]bool, attributes []string) []string {
+	celVisit(expr, bound, func(e ast.Expr, bound map[string]bool) bool {
+		switch e.Kind() {
+		case ast.SelectKind:
+			if e.AsSelect().IsTestOnly() {
+				// has() checks if the attribute exists, a missing attribute is expected here.
+				return false
+			}
+			if path, ok := celSelectPath(e, bound); ok {
+				attributes = appendUnique(attributes, path)
+				return false
+			}
+		case ast.IdentKind:
+			if !bound[e.AsIdent()] && !celTypeNames[e.AsIdent()] {
+				attributes = appendUnique(attributes, e.AsIdent())
+			}
+		}
+		return true
+	})
+	return attributes
+}
+
+// celSelectPath returns the dot-separated path of a field selection on an attribute (ex: company.id).
+func celSelectPath(expr ast.Expr, bound map[string]bool) (string, bool) {
+	switch expr.Kind() {
+	case ast.IdentKind:
+		return expr.AsIdent(), !bound[expr.AsIdent()] && !celTypeNames[expr.AsIdent()]
+	case ast.SelectKind:
+		path, ok := celSelectPath(expr.AsSelect().Operand(), bound)
+		return path + "." + expr.AsSelect().FieldName(), ok
+	default:
+		return "", false
+	}
+}
+
+// celVisit calls visit on the expression and, if visit returns true, on its sub-expressions.
+// The variables of the macros (ex: g in groups.exists(g, g == "beta")) are added to bound in their scope.
+func celVisit(expr ast.Expr, bound map[string]bool, visit func(e ast.Expr, bound map[string]bool) bool) {
+	if !visit(expr, bound) {
+		return
+	}
+	switch expr.Kind() {
+	case ast.SelectKind:
+		celVisit(expr.AsSelect().Operand(), bound, visit)
+	case ast.CallKind:
+		call := expr.AsCall()
+		if call.IsMemberFunction() {
+			celVisit(call.Target(), bound, visit)
+		}
+		for _, arg := range call.Args() {
+			celVisit(arg, bound, visit)
+		}
+	case ast.ListKind:
+		for _, element := range expr.AsList().Elements() {
+			celVisit(element, bound, visit)
+		}
+	case ast.MapKind:
+		for _, entry := range expr.AsMap().Entries() {
+			celVisit(entry.AsMapEntry().Key(), bound, visit)
+			celVisit(entry.AsMapEntry().Value(), bound, visit)
+		}
+	case ast.ComprehensionKind:
+		comprehension := expr.AsComprehension()
+		celVisit(comprehension.IterRange(), bound, visit)
+		scope := make(map[string]bool, len(bound)+2)
+		for name := range bound {
+			scope[name] = true
+		}
+		scope[comprehension.IterVar()] = true
+		scope[comprehension.AccuVar()] = true
+		for _, e := range []ast.Expr{
+			comprehension.AccuInit(), comprehension.LoopCondition(), comprehension.LoopStep(), comprehension.Result(),
+		} {
+			celVisit(e, scope, visit)
+		}
+	}
+}
+
+// celSemver is the implementation of the semver(version, operator, other) function.
+func celSemver(args ...ref.Val) ref.Val {
+	version, okVersion := args[0].(types.String)
+	operator, okOperator := args[1].(types.String)
+	other, okOther := args[2].(types.String)
+	if !okVersion || !okOperator || !okOther {
+		return types.NewErr("semver() expects 3 strings")
+	}
+	match, err := MatchSemver(string(version), string(operator), string(other))
+	if err != nil {
+		return types.WrapErr(err)
+	}
+	return types.Bool(match)
+}
+
+// celInCIDR is the implementation of the inCIDR(ip, network) function.
+func celInCIDR(ip ref.Val, cidr ref.Val) ref.Val {
+	ipValue, okIP := ip.(types.String)
+	cidrValue, okCIDR := cidr.(types.String)
+	if !okIP || !okCIDR {
+		return types.NewErr("inCIDR() expects 2 strings")
+	}
+	match, err := MatchCIDR(string(ipValue), string(cidrValue))
+	if err != nil {
+		return types.WrapErr(err)
+	}
+	return types.Bool(match)
+}
@@ -0,0 +1,191 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/internal/query"
+)
+
+func TestCompileCEL(t *testing.T) {
+	data := map[string]interface{}{
+		"key":       "user-1",
+		"email":     "john@gofeatureflag.org",
+		"age":       42,
+		"score":     12.5,
+		"groups":    []string{"beta", "admin"},
+		"company":   map[string]interface{}{"id": 1234, "plan": "enterprise"},
+		"createdAt": "2024-01-15T10:00:00Z",
+		"version":   "1.4.2",
+		"ip":        "10.1.2.3",
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		want       bool
+		wantErr    assert.ErrorAssertionFunc
+		wantCompil assert.ErrorAssertionFunc
+	}{
+		{
+			name:  "equality",
+			query: `email == "john@gofeatureflag.org"`,
+			want:  true,
+		},
+		{
+			name:  "targetingKey is an alias of the key",
+			query: `targetingKey == "user-1"`,
+			want:  true,
+		},
+		{
+			name:  "nested attribute and in operator",
+			query: `company.id == 1234 && company.plan in ["enterprise", "pro"]`,
+			want:  true,
+		},
+		{
+			name:  "array intersection",
+			query: `groups.exists(g, g in ["admin", "staff"])`,
+			want:  true,
+		},
+		{
+			name:  "all macro",
+			query: `groups.all(g, g.startsWith("b"))`,
+			want:  false,
+		},
+		{
+			name:  "filter and size",
+			query: `size(groups.filter(g, g != "beta")) == 1`,
+			want:  true,
+		},
+		{
+			name:  "arithmetic and comparison",
+			query: `age * 2 + 1 > 80 && score < 20.0`,
+			want:  true,
+		},
+		{
+			name:  "string functions",
+			query: `email.endsWith("@gofeatureflag.org") && email.matches("^[a-z]+@")`,
+			want:  true,
+		},
+		{
+			name:  "date arithmetic",
+			query: `timestamp(createdAt) + duration("720h") > timestamp("2024-02-01T00:00:00Z")`,
+			want:  true,
+		},
+		{
+			name:  "has macro",
+			query: `has(company.plan) && !has(company.country)`,
+			want:  true,
+		},
+		{
+			name:  "ternary operator",
+			query: `age > 18 ? email.contains("john") : false`,
+			want:  true,
+		},
+		{
+			name:  "semantic version",
+			query: `semver(version, "~", "1.4.0")`,
+			want:  true,
+		},
+		{
+			name:  "IP address in a network",
+			query: `inCIDR(ip, "10.0.0.0/8") && !inCIDR(ip, "192.168.0.0/16")`,
+			want:  true,
+		},
+		{
+			name:  "error absorbed by the or operator",
+			query: `country == "FR" || age == 42`,
+			want:  true,
+		},
+		{
+			name:    "missing attribute",
+			query:   `country == "FR"`,
+			want:    false,
+			wantErr: assert.Error,
+		},
+		{
+			name:       "expression not returning a bool",
+			query:      `age + 1`,
+			wantCompil: assert.Error,
+		},
+		{
+			name:    "attribute not containing a bool",
+			query:   `company`,
+			want:    false,
+			wantErr: assert.Error,
+		},
+		{
+			name:  "string extensions",
+			query: `email.lowerAscii().split("@")[1] == "gofeatureflag.org"`,
+			want:  true,
+		},
+		{
+			name:  "comparison between an int and a double",
+			query: `age > 41.5`,
+			want:  true,
+		},
+		{
+			name:       "unknown function",
+			query:      `unknown(email)`,
+			wantCompil: assert.Error,
+		},
+		{
+			name:       "invalid syntax",
+			query:      `email == `,
+			wantCompil: assert.Error,
+		},
+		{
+			name:       "unterminated string",
+			query:      `email == "john`,
+			wantCompil: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantCompil == nil {
+				tt.wantCompil = assert.NoError
+			}
+			if tt.wantErr == nil {
+				tt.wantErr = assert.NoError
+			}
+			expression, err := query.CompileCEL(tt.query)
+			if !tt.wantCompil(t, err) || err != nil {
+				return
+			}
+			got, err := expression.Evaluate(data)
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCELAttributes(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "attributes and nested attributes",
+			query: `email.endsWith("@gofeatureflag.org") && company.id == 1234`,
+			want:  []string{"email", "company.id"},
+		},
+		{
+			name:  "variables of the macros are not attributes",
+			query: `groups.exists(g, g == "beta" && age > 18)`,
+			want:  []string{"groups", "age"},
+		},
+		{
+			name:  "attribute checked by the has macro",
+			query: `has(company.plan) && size(groups) > 0`,
+			want:  []string{"groups"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expression, err := query.CompileCEL(tt.query)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, expression.(query.AttributesReferencer).Attributes())
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package query
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// CheckCIDR checks that the network is a valid IPv4 or IPv6 CIDR (ex: 10.0.0.0/8 or 2001:db8::/32).
+func CheckCIDR(cidr string) error {
+	if _, err := netip.ParsePrefix(strings.TrimSpace(cidr)); err != nil {
+		return fmt.Errorf("invalid CIDR: %s", cidr)
+	}
+	return nil
+}
+
+// MatchCIDR checks if the IP address is part of the network.
+// An IPv4-mapped IPv6 address (ex: ::ffff:10.0.0.1) is considered as an IPv4 address.
+func MatchCIDR(ip string, cidr string) (bool, error) {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+	if err != nil {
+		return false, fmt.Errorf("invalid CIDR: %s", cidr)
+	}
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return false, fmt.Errorf("invalid IP address: %s", ip)
+	}
+	return prefix.Masked().Contains(addr.Unmap()), nil
+}
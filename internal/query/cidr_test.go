@@ -0,0 +1,34 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/internal/query"
+)
+
+func TestMatchCIDR(t *testing.T) {
+	tests := []struct {
+		name    string
+		ip      string
+		cidr    string
+		want    bool
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{name: "IPv4 in the network", ip: "10.1.2.3", cidr: "10.0.0.0/8", want: true, wantErr: assert.NoError},
+		{name: "IPv4 outside the network", ip: "11.1.2.3", cidr: "10.0.0.0/8", want: false, wantErr: assert.NoError},
+		{name: "IPv6 in the network", ip: "2001:db8::1", cidr: "2001:db8::/32", want: true, wantErr: assert.NoError},
+		{name: "IPv4-mapped IPv6 address", ip: "::ffff:10.1.2.3", cidr: "10.0.0.0/8", want: true, wantErr: assert.NoError},
+		{name: "IPv4 in an IPv6 network", ip: "10.1.2.3", cidr: "2001:db8::/32", want: false, wantErr: assert.NoError},
+		{name: "network not masked", ip: "10.1.2.3", cidr: "10.1.2.200/24", want: true, wantErr: assert.NoError},
+		{name: "invalid IP address", ip: "10.1.2", cidr: "10.0.0.0/8", want: false, wantErr: assert.Error},
+		{name: "invalid network", ip: "10.1.2.3", cidr: "10.0.0.0", want: false, wantErr: assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := query.MatchCIDR(tt.ip, tt.cidr)
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package query
+
+// Expression is a query parsed and ready to be evaluated against an evaluation context.
+type Expression interface {
+	// Evaluate checks if the evaluation context matches the query.
+	Evaluate(data map[string]interface{}) (bool, error)
+}
+
+// AttributesReferencer is implemented by the expressions able to list the attributes
+// of the evaluation context they are using.
+type AttributesReferencer interface {
+	// Attributes returns the attributes used by the expression (ex: "email" or "company.id").
+	Attributes() []string
+}
+
+// MissingAttributes returns the attributes that are not available in the evaluation context.
+func MissingAttributes(attributes []string, data map[string]interface{}) []string {
+	missing := make([]string, 0)
+	for _, attribute := range attributes {
+		if _, ok := lookup(data, attribute); !ok {
+			missing = appendUnique(missing, attribute)
+		}
+	}
+	return missing
+}
+
+// Lookup returns the value of an attribute of the evaluation context, nested attributes can be accessed
+// with a dot-separated path (ex: "company.id") and list items with their index (ex: "groups.0").
+func Lookup(data map[string]interface{}, attribute string) (interface{}, bool) {
+	return lookup(data, attribute)
+}
+
+// appendUnique adds a value to the list if it is not already in it.
+func appendUnique(list []string, value string) []string {
+	for _, item := range list {
+		if item == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
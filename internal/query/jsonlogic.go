@@ -0,0 +1,802 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/spaolacci/murmur3"
+)
+
+// FlagdProperties is the attribute added to the evaluation context of the JSONLogic rules with the properties
+// provided by flagd: $flagd.flagKey (the name of the flag) and $flagd.timestamp (the evaluation date as a unix
+// timestamp).
+const FlagdProperties = "$flagd"
+
+// jsonLogicOperation is the implementation of a JSONLogic operator.
+// It receives the raw arguments, so each operator can decide which arguments it evaluates.
+type jsonLogicOperation func(args []interface{}, data interface{}) (interface{}, error)
+
+// jsonLogicOperations contains all the supported operators, it is initialized in init to allow
+// the operators to call evaluateJSONLogic recursively.
+var jsonLogicOperations map[string]jsonLogicOperation
+
+// nolint: funlen
+func init() {
+	jsonLogicOperations = map[string]jsonLogicOperation{
+		"var":          jsonLogicVar,
+		"missing":      jsonLogicMissing,
+		"missing_some": jsonLogicMissingSome,
+		"if":           jsonLogicIf,
+		"?:":           jsonLogicIf,
+		"==":           compareArgs(func(a, b interface{}) bool { return looseEquals(a, b) }),
+		"!=":           compareArgs(func(a, b interface{}) bool { return !looseEquals(a, b) }),
+		"===":          compareArgs(strictEquals),
+		"!==":          compareArgs(func(a, b interface{}) bool { return !strictEquals(a, b) }),
+		"!":            evaluatedArgs(func(args []interface{}) (interface{}, error) { return !truthy(first(args)), nil }),
+		"!!":           evaluatedArgs(func(args []interface{}) (interface{}, error) { return truthy(first(args)), nil }),
+		"or":           jsonLogicOr,
+		"and":          jsonLogicAnd,
+		"<":            jsonLogicLess(false),
+		"<=":           jsonLogicLess(true),
+		">":            compareNumericArgs(func(c int) bool { return c > 0 }),
+		">=":           compareNumericArgs(func(c int) bool { return c >= 0 }),
+		"max":          evaluatedArgs(jsonLogicMinMax(func(a, b float64) bool { return a > b })),
+		"min":          evaluatedArgs(jsonLogicMinMax(func(a, b float64) bool { return a < b })),
+		"+":            evaluatedArgs(jsonLogicAdd),
+		"-":            evaluatedArgs(jsonLogicSubtract),
+		"*":            evaluatedArgs(jsonLogicMultiply),
+		"/":            evaluatedArgs(jsonLogicArithmetic(func(a, b float64) float64 { return a / b })),
+		"%":            evaluatedArgs(jsonLogicArithmetic(math.Mod)),
+		"in":           evaluatedArgs(jsonLogicIn),
+		"cat":          evaluatedArgs(jsonLogicCat),
+		"substr":       evaluatedArgs(jsonLogicSubstr),
+		"merge":        evaluatedArgs(jsonLogicMerge),
+		"map":          jsonLogicMap,
+		"filter":       jsonLogicFilter,
+		"reduce":       jsonLogicReduce,
+		"all":          jsonLogicAll,
+		"some":         jsonLogicSome,
+		"none":         jsonLogicNone,
+		"starts_with":  evaluatedArgs(jsonLogicStringOperation(strings.HasPrefix)),
+		"ends_with":    evaluatedArgs(jsonLogicStringOperation(strings.HasSuffix)),
+		"sem_ver":      evaluatedArgs(jsonLogicSemVer),
+		"in_cidr":      evaluatedArgs(jsonLogicInCIDR),
+		"fractional":   jsonLogicFractional,
+	}
+}
+
+// jsonLogicExpression is a JSONLogic rule ready to be evaluated.
+type jsonLogicExpression struct {
+	rule interface{}
+}
+
+// CompileJSONLogic parses a JSONLogic rule (https://jsonlogic.com), the operators "starts_with", "ends_with",
+// "sem_ver" and "fractional" are also available to be compatible with flagd, and "in_cidr" checks if an IP
+// address is part of a network.
+// A rule using an operator that is not supported is rejected.
+func CompileJSONLogic(query string) (Expression, error) {
+	var rule interface{}
+	decoder := json.NewDecoder(strings.NewReader(query))
+	if err := decoder.Decode(&rule); err != nil {
+		return nil, fmt.Errorf("invalid JSONLogic rule: %w", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("invalid JSONLogic rule: unexpected content after the rule")
+	}
+	if err := validateJSONLogic(rule); err != nil {
+		return nil, err
+	}
+	return &jsonLogicExpression{rule: rule}, nil
+}
+
+// Evaluate checks if the evaluation context matches the JSONLogic rule.
+func (e *jsonLogicExpression) Evaluate(data map[string]interface{}) (bool, error) {
+	result, err := evaluateJSONLogic(e.rule, data)
+	if err != nil {
+		return false, err
+	}
+	return truthy(result), nil
+}
+
+// Attributes returns the attributes of the evaluation context read by the "var" operators of the rule.
+// The operators iterating over a list (map, filter, all, ...) read the items of the list, so only their
+// first argument is inspected.
+func (e *jsonLogicExpression) Attributes() []string {
+	return jsonLogicAttributes(e.rule, make([]string, 0))
+}
+
+func jsonLogicAttributes(rule interface{}, attributes []string) []string {
+	switch value := rule.(type) {
+	case []interface{}:
+		for _, item := range value {
+			attributes = jsonLogicAttributes(item, attributes)
+		}
+	case map[string]interface{}:
+		for operator, rawArgs := range value {
+			args := toArgs(rawArgs)
+			switch operator {
+			case "var":
+				if path, ok := first(args).(string); ok && path != "" && !strings.HasPrefix(path, FlagdProperties) {
+					attributes = appendUnique(attributes, path)
+				}
+			case "map", "filter", "reduce", "all", "some", "none":
+				attributes = jsonLogicAttributes(first(args), attributes)
+			default:
+				attributes = jsonLogicAttributes(args, attributes)
+			}
+		}
+	}
+	return attributes
+}
+
+// validateJSONLogic checks that all the operators used in the rule exist.
+func validateJSONLogic(rule interface{}) error {
+	switch value := rule.(type) {
+	case []interface{}:
+		for _, item := range value {
+			if err := validateJSONLogic(item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		if len(value) != 1 {
+			return fmt.Errorf("invalid JSONLogic rule: an operation should have exactly one operator")
+		}
+		for operator, args := range value {
+			if _, ok := jsonLogicOperations[operator]; !ok {
+				return fmt.Errorf("invalid JSONLogic rule: unknown operator %s", operator)
+			}
+			return validateJSONLogic(args)
+		}
+	}
+	return nil
+}
+
+// evaluateJSONLogic evaluates a rule against the data.
+func evaluateJSONLogic(rule interface{}, data interface{}) (interface{}, error) {
+	switch value := rule.(type) {
+	case []interface{}:
+		result := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			evaluated, err := evaluateJSONLogic(item, data)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, evaluated)
+		}
+		return result, nil
+	case map[string]interface{}:
+		for operator, args := range value {
+			operation, ok := jsonLogicOperations[operator]
+			if !ok {
+				return nil, fmt.Errorf("unknown operator %s", operator)
+			}
+			return operation(toArgs(args), data)
+		}
+		return value, nil
+	default:
+		return value, nil
+	}
+}
+
+// toArgs converts the arguments of an operator to a list, an operator can receive a single argument
+// without an array (ex: {"var": "name"}).
+func toArgs(args interface{}) []interface{} {
+	if list, ok := args.([]interface{}); ok {
+		return list
+	}
+	return []interface{}{args}
+}
+
+// evaluateArgs evaluates all the arguments of an operator.
+func evaluateArgs(args []interface{}, data interface{}) ([]interface{}, error) {
+	evaluated := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		value, err := evaluateJSONLogic(arg, data)
+		if err != nil {
+			return nil, err
+		}
+		evaluated = append(evaluated, value)
+	}
+	return evaluated, nil
+}
+
+// evaluatedArgs creates an operation that receives its arguments already evaluated.
+func evaluatedArgs(operation func(args []interface{}) (interface{}, error)) jsonLogicOperation {
+	return func(args []interface{}, data interface{}) (interface{}, error) {
+		evaluated, err := evaluateArgs(args, data)
+		if err != nil {
+			return nil, err
+		}
+		return operation(evaluated)
+	}
+}
+
+// compareArgs creates an operation comparing its 2 first arguments.
+func compareArgs(compare func(a, b interface{}) bool) jsonLogicOperation {
+	return evaluatedArgs(func(args []interface{}) (interface{}, error) {
+		if len(args) < 2 {
+			return false, nil
+		}
+		return compare(args[0], args[1]), nil
+	})
+}
+
+// compareNumericArgs creates an operation comparing its 2 first arguments as numbers.
+func compareNumericArgs(accept func(c int) bool) jsonLogicOperation {
+	return evaluatedArgs(func(args []interface{}) (interface{}, error) {
+		if len(args) < 2 {
+			return false, nil
+		}
+		c, ok := looseCompare(args[0], args[1])
+		return ok && accept(c), nil
+	})
+}
+
+func first(args []interface{}) interface{} {
+	if len(args) == 0 {
+		return nil
+	}
+	return args[0]
+}
+
+func jsonLogicVar(args []interface{}, data interface{}) (interface{}, error) {
+	evaluated, err := evaluateArgs(args, data)
+	if err != nil {
+		return nil, err
+	}
+	var defaultValue interface{}
+	if len(evaluated) > 1 {
+		defaultValue = evaluated[1]
+	}
+
+	path := toString(first(evaluated))
+	if path == "" {
+		return data, nil
+	}
+	value, ok := lookup(data, path)
+	if !ok || value == nil {
+		return defaultValue, nil
+	}
+	return normalize(value), nil
+}
+
+func jsonLogicMissing(args []interface{}, data interface{}) (interface{}, error) {
+	evaluated, err := evaluateArgs(args, data)
+	if err != nil {
+		return nil, err
+	}
+	// {"missing": {"merge": [...]}} gives the list of keys as first argument
+	if len(evaluated) == 1 {
+		if list, ok := evaluated[0].([]interface{}); ok {
+			evaluated = list
+		}
+	}
+
+	missing := make([]interface{}, 0)
+	for _, key := range evaluated {
+		value, ok := lookup(data, toString(key))
+		if !ok || value == nil || value == "" {
+			missing = append(missing, key)
+		}
+	}
+	return missing, nil
+}
+
+func jsonLogicMissingSome(args []interface{}, data interface{}) (interface{}, error) {
+	evaluated, err := evaluateArgs(args, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(evaluated) < 2 {
+		return nil, fmt.Errorf("missing_some expects 2 arguments")
+	}
+	needed, _ := toFloat(evaluated[0])
+	keys, _ := evaluated[1].([]interface{})
+	missing, err := jsonLogicMissing(keys, data)
+	if err != nil {
+		return nil, err
+	}
+	if float64(len(keys)-len(missing.([]interface{}))) >= needed {
+		return []interface{}{}, nil
+	}
+	return missing, nil
+}
+
+func jsonLogicIf(args []interface{}, data interface{}) (interface{}, error) {
+	for i := 0; i+1 < len(args); i += 2 {
+		condition, err := evaluateJSONLogic(args[i], data)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(condition) {
+			return evaluateJSONLogic(args[i+1], data)
+		}
+	}
+	if len(args)%2 == 1 {
+		return evaluateJSONLogic(args[len(args)-1], data)
+	}
+	return nil, nil
+}
+
+func jsonLogicOr(args []interface{}, data interface{}) (interface{}, error) {
+	var value interface{}
+	for _, arg := range args {
+		var err error
+		value, err = evaluateJSONLogic(arg, data)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(value) {
+			return value, nil
+		}
+	}
+	return value, nil
+}
+
+func jsonLogicAnd(args []interface{}, data interface{}) (interface{}, error) {
+	var value interface{}
+	for _, arg := range args {
+		var err error
+		value, err = evaluateJSONLogic(arg, data)
+		if err != nil {
+			return nil, err
+		}
+		if !truthy(value) {
+			return value, nil
+		}
+	}
+	return value, nil
+}
+
+// jsonLogicLess handles "<" and "<=", with 3 arguments it checks that the second one is between the others.
+func jsonLogicLess(orEqual bool) jsonLogicOperation {
+	accept := func(c int) bool { return c < 0 || (orEqual && c == 0) }
+	return evaluatedArgs(func(args []interface{}) (interface{}, error) {
+		if len(args) < 2 {
+			return false, nil
+		}
+		for i := 0; i+1 < len(args) && i < 2; i++ {
+			c, ok := looseCompare(args[i], args[i+1])
+			if !ok || !accept(c) {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+func jsonLogicMinMax(better func(a, b float64) bool) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		if len(args) == 0 {
+			return nil, nil
+		}
+		var result float64
+		for i, arg := range args {
+			value, ok := toNumber(arg)
+			if !ok {
+				return nil, nil
+			}
+			if i == 0 || better(value, result) {
+				result = value
+			}
+		}
+		return result, nil
+	}
+}
+
+func jsonLogicAdd(args []interface{}) (interface{}, error) {
+	sum := float64(0)
+	for _, arg := range args {
+		value, ok := toNumber(arg)
+		if !ok {
+			return nil, nil
+		}
+		sum += value
+	}
+	return sum, nil
+}
+
+func jsonLogicSubtract(args []interface{}) (interface{}, error) {
+	if len(args) == 1 {
+		value, ok := toNumber(args[0])
+		if !ok {
+			return nil, nil
+		}
+		return -value, nil
+	}
+	return jsonLogicArithmetic(func(a, b float64) float64 { return a - b })(args)
+}
+
+func jsonLogicMultiply(args []interface{}) (interface{}, error) {
+	product := float64(1)
+	for _, arg := range args {
+		value, ok := toNumber(arg)
+		if !ok {
+			return nil, nil
+		}
+		product *= value
+	}
+	return product, nil
+}
+
+func jsonLogicArithmetic(operation func(a, b float64) float64) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		if len(args) < 2 {
+			return nil, nil
+		}
+		a, okA := toNumber(args[0])
+		b, okB := toNumber(args[1])
+		if !okA || !okB {
+			return nil, nil
+		}
+		return operation(a, b), nil
+	}
+}
+
+func jsonLogicIn(args []interface{}) (interface{}, error) {
+	if len(args) < 2 {
+		return false, nil
+	}
+	switch container := normalize(args[1]).(type) {
+	case string:
+		return strings.Contains(container, toString(args[0])), nil
+	case []interface{}:
+		for _, item := range container {
+			if strictEquals(args[0], item) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func jsonLogicCat(args []interface{}) (interface{}, error) {
+	var builder strings.Builder
+	for _, arg := range args {
+		builder.WriteString(toString(arg))
+	}
+	return builder.String(), nil
+}
+
+func jsonLogicSubstr(args []interface{}) (interface{}, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("substr expects at least 2 arguments")
+	}
+	runes := []rune(toString(args[0]))
+	start, _ := toNumber(args[1])
+	from := int(start)
+	if from < 0 {
+		from = max(len(runes)+from, 0)
+	}
+	from = min(from, len(runes))
+
+	to := len(runes)
+	if len(args) > 2 {
+		length, _ := toNumber(args[2])
+		if length < 0 {
+			to = max(len(runes)+int(length), from)
+		} else {
+			to = min(from+int(length), len(runes))
+		}
+	}
+	return string(runes[from:to]), nil
+}
+
+func jsonLogicMerge(args []interface{}) (interface{}, error) {
+	merged := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		if list, ok := normalize(arg).([]interface{}); ok {
+			merged = append(merged, list...)
+			continue
+		}
+		merged = append(merged, arg)
+	}
+	return merged, nil
+}
+
+// iterate evaluates the first argument as a list and call the callback with the evaluation of the
+// second argument for each item, the item being the data of the evaluation.
+func iterate(args []interface{}, data interface{}, callback func(item, result interface{}) bool) error {
+	if len(args) < 2 {
+		return fmt.Errorf("expects 2 arguments")
+	}
+	listValue, err := evaluateJSONLogic(args[0], data)
+	if err != nil {
+		return err
+	}
+	list, _ := normalize(listValue).([]interface{})
+	for _, item := range list {
+		result, err := evaluateJSONLogic(args[1], item)
+		if err != nil {
+			return err
+		}
+		if !callback(item, result) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func jsonLogicMap(args []interface{}, data interface{}) (interface{}, error) {
+	result := make([]interface{}, 0)
+	err := iterate(args, data, func(_, value interface{}) bool {
+		result = append(result, value)
+		return true
+	})
+	return result, err
+}
+
+func jsonLogicFilter(args []interface{}, data interface{}) (interface{}, error) {
+	result := make([]interface{}, 0)
+	err := iterate(args, data, func(item, value interface{}) bool {
+		if truthy(value) {
+			result = append(result, item)
+		}
+		return true
+	})
+	return result, err
+}
+
+func jsonLogicReduce(args []interface{}, data interface{}) (interface{}, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("reduce expects at least 2 arguments")
+	}
+	listValue, err := evaluateJSONLogic(args[0], data)
+	if err != nil {
+		return nil, err
+	}
+	var accumulator interface{}
+	if len(args) > 2 {
+		if accumulator, err = evaluateJSONLogic(args[2], data); err != nil {
+			return nil, err
+		}
+	}
+	list, _ := normalize(listValue).([]interface{})
+	for _, item := range list {
+		accumulator, err = evaluateJSONLogic(args[1], map[string]interface{}{
+			"current":     item,
+			"accumulator": accumulator,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return accumulator, nil
+}
+
+func jsonLogicAll(args []interface{}, data interface{}) (interface{}, error) {
+	count := 0
+	all := true
+	err := iterate(args, data, func(_, value interface{}) bool {
+		count++
+		all = truthy(value)
+		return all
+	})
+	return count > 0 && all, err
+}
+
+func jsonLogicSome(args []interface{}, data interface{}) (interface{}, error) {
+	some := false
+	err := iterate(args, data, func(_, value interface{}) bool {
+		some = truthy(value)
+		return !some
+	})
+	return some, err
+}
+
+func jsonLogicNone(args []interface{}, data interface{}) (interface{}, error) {
+	some, err := jsonLogicSome(args, data)
+	if err != nil {
+		return nil, err
+	}
+	return !some.(bool), nil
+}
+
+func jsonLogicStringOperation(operation func(s, value string) bool) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		if len(args) < 2 {
+			return false, nil
+		}
+		s, okS := args[0].(string)
+		value, okValue := args[1].(string)
+		return okS && okValue && operation(s, value), nil
+	}
+}
+
+// jsonLogicSemVer compares 2 semantic versions, the operators are =, !=, <, <=, >, >=,
+// ^ (same major version) and ~ (same minor version).
+func jsonLogicSemVer(args []interface{}) (interface{}, error) {
+	if len(args) != 3 {
+		return false, nil
+	}
+	version, okVersion := args[0].(string)
+	operator, okOperator := args[1].(string)
+	other, okOther := args[2].(string)
+	if !okVersion || !okOperator || !okOther {
+		return false, nil
+	}
+	match, err := MatchSemver(version, operator, other)
+	if err != nil {
+		return false, nil
+	}
+	return match, nil
+}
+
+// jsonLogicInCIDR checks if an IP address is part of an IPv4 or IPv6 network (ex: 10.0.0.0/8).
+func jsonLogicInCIDR(args []interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return false, nil
+	}
+	ip, okIP := args[0].(string)
+	cidr, okCIDR := args[1].(string)
+	if !okIP || !okCIDR {
+		return false, nil
+	}
+	match, err := MatchCIDR(ip, cidr)
+	if err != nil {
+		return false, nil
+	}
+	return match, nil
+}
+
+// jsonLogicFractional is the flagd "fractional" operator, it affects the evaluation context to one of the weighted
+// buckets and returns the name of this bucket (ex: {"fractional": [["red", 50], ["blue", 50]]}).
+// The first argument is the value used to compute the bucket if it is a string, otherwise the name of the flag
+// concatenated with the targeting key is used, like flagd.
+// A bucket is a list with its name and its weight, the weight is 1 if it is not set.
+func jsonLogicFractional(args []interface{}, data interface{}) (interface{}, error) {
+	evaluated, err := evaluateArgs(args, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(evaluated) < 2 {
+		return nil, fmt.Errorf("fractional: at least 2 arguments are expected")
+	}
+
+	bucketingValue, ok := evaluated[0].(string)
+	if ok {
+		evaluated = evaluated[1:]
+	} else {
+		if evaluated[0] == nil {
+			evaluated = evaluated[1:]
+		}
+		targetingKey, ok := lookup(data, targetingKeyAttribute)
+		if !ok {
+			return nil, fmt.Errorf("fractional: no bucketing value and no targeting key in the evaluation context")
+		}
+		flagKey, _ := lookup(data, FlagdProperties+".flagKey")
+		bucketingValue = toString(flagKey) + toString(targetingKey)
+	}
+
+	names := make([]string, 0, len(evaluated))
+	weights := make([]float64, 0, len(evaluated))
+	totalWeight := float64(0)
+	for _, arg := range evaluated {
+		bucket, ok := arg.([]interface{})
+		if !ok || len(bucket) == 0 {
+			return nil, fmt.Errorf("fractional: a bucket should be a list with a name and a weight")
+		}
+		name, ok := bucket[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("fractional: the name of a bucket should be a string")
+		}
+		weight := float64(1)
+		if len(bucket) > 1 {
+			if weight, ok = toFloat(bucket[1]); !ok || weight < 0 {
+				return nil, fmt.Errorf("fractional: the weight of the bucket %s should be a positive number", name)
+			}
+		}
+		names = append(names, name)
+		weights = append(weights, weight)
+		totalWeight += weight
+	}
+
+	// same computation as flagd: the hash is converted to a signed 32-bit integer and its ratio to the
+	// maximum value gives the position of the evaluation context between 0 and 100.
+	position := math.Abs(float64(int32(murmur3.Sum32([]byte(bucketingValue))))) / math.MaxInt32 * 100
+	end := float64(0)
+	for i, name := range names {
+		end += weights[i] * 100 / totalWeight
+		if position < end {
+			return name, nil
+		}
+	}
+	return nil, nil
+}
+
+// truthy follows the JSONLogic definition of truthiness.
+func truthy(value interface{}) bool {
+	switch v := normalize(value).(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case float64:
+		return v != 0
+	case int64:
+		return v != 0
+	case []interface{}:
+		return len(v) > 0
+	default:
+		return true
+	}
+}
+
+// toNumber converts a value to a number the same way as JSONLogic, strings are parsed.
+func toNumber(value interface{}) (float64, bool) {
+	if f, ok := toFloat(value); ok {
+		return f, true
+	}
+	switch v := normalize(value).(type) {
+	case string:
+		var f float64
+		if _, err := fmt.Sscan(strings.TrimSpace(v), &f); err != nil {
+			return 0, false
+		}
+		return f, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case nil:
+		return 0, true
+	}
+	return 0, false
+}
+
+// looseEquals follows the JSONLogic "==" operator, values of different types are converted before comparing them.
+func looseEquals(a, b interface{}) bool {
+	a, b = normalize(a), normalize(b)
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if isNumber(a) || isNumber(b) || isBool(a) || isBool(b) {
+		if _, isString := a.(string); isString {
+			if _, isString := b.(string); isString {
+				return a == b
+			}
+		}
+		numberA, okA := toNumber(a)
+		numberB, okB := toNumber(b)
+		return okA && okB && numberA == numberB
+	}
+	return strictEquals(a, b)
+}
+
+func isBool(value interface{}) bool {
+	_, ok := value.(bool)
+	return ok
+}
+
+// strictEquals follows the JSONLogic "===" operator, values should have the same type.
+func strictEquals(a, b interface{}) bool {
+	a, b = normalize(a), normalize(b)
+	numberA, okA := toFloat(a)
+	numberB, okB := toFloat(b)
+	if okA || okB {
+		return okA && okB && numberA == numberB
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// looseCompare compares 2 values as numbers, or as strings if both are strings.
+func looseCompare(a, b interface{}) (int, bool) {
+	stringA, okA := normalize(a).(string)
+	stringB, okB := normalize(b).(string)
+	if okA && okB {
+		return strings.Compare(stringA, stringB), true
+	}
+	numberA, okA := toNumber(a)
+	numberB, okB := toNumber(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	return compareNumbers(numberA, numberB), true
+}
@@ -0,0 +1,188 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/internal/query"
+)
+
+func TestCompileJSONLogic(t *testing.T) {
+	data := map[string]interface{}{
+		"key":     "user-1",
+		"email":   "john@gofeatureflag.org",
+		"age":     42,
+		"groups":  []string{"beta", "admin"},
+		"company": map[string]interface{}{"id": 1234, "plan": "enterprise"},
+		"version": "1.4.2",
+		"ip":      "2001:db8::1",
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		want       bool
+		wantErr    assert.ErrorAssertionFunc
+		wantCompil assert.ErrorAssertionFunc
+	}{
+		{
+			name:  "equality with a var",
+			query: `{"==": [{"var": "email"}, "john@gofeatureflag.org"]}`,
+			want:  true,
+		},
+		{
+			name:  "targetingKey is an alias of the key",
+			query: `{"==": [{"var": "targetingKey"}, "user-1"]}`,
+			want:  true,
+		},
+		{
+			name:  "nested attribute",
+			query: `{"and": [{"==": [{"var": "company.id"}, 1234]}, {"in": [{"var": "company.plan"}, ["enterprise", "pro"]]}]}`,
+			want:  true,
+		},
+		{
+			name:  "array intersection",
+			query: `{"some": [{"var": "groups"}, {"in": [{"var": ""}, ["admin", "staff"]]}]}`,
+			want:  true,
+		},
+		{
+			name:  "between",
+			query: `{"<": [18, {"var": "age"}, 40]}`,
+			want:  false,
+		},
+		{
+			name:  "missing attribute",
+			query: `{"==": [{"var": "country"}, "FR"]}`,
+			want:  false,
+		},
+		{
+			name:  "missing operator",
+			query: `{"missing": ["email", "country"]}`,
+			want:  true,
+		},
+		{
+			name:  "flagd starts_with",
+			query: `{"starts_with": [{"var": "email"}, "john@"]}`,
+			want:  true,
+		},
+		{
+			name:  "flagd ends_with",
+			query: `{"ends_with": [{"var": "email"}, "@example.com"]}`,
+			want:  false,
+		},
+		{
+			name:  "flagd sem_ver",
+			query: `{"sem_ver": [{"var": "version"}, ">=", "1.4.0"]}`,
+			want:  true,
+		},
+		{
+			name:  "IPv6 address in a network",
+			query: `{"in_cidr": [{"var": "ip"}, "2001:db8::/32"]}`,
+			want:  true,
+		},
+		{
+			name:  "loose equality between number and string",
+			query: `{"==": [{"var": "age"}, "42"]}`,
+			want:  true,
+		},
+		{
+			name:  "flagd fractional",
+			query: `{"==": [{"fractional": [{"var": "email"}, ["red", 50], ["blue", 50]]}, "blue"]}`,
+			want:  false,
+		},
+		{
+			name:    "flagd fractional with an invalid bucket",
+			query:   `{"==": [{"fractional": [{"var": "email"}, "red", "blue"]}, "red"]}`,
+			want:    false,
+			wantErr: assert.Error,
+		},
+		{
+			name:       "invalid JSON",
+			query:      `{"==": [`,
+			wantCompil: assert.Error,
+		},
+		{
+			name:       "unknown operator",
+			query:      `{"unknown": [1, 2]}`,
+			wantCompil: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantCompil == nil {
+				tt.wantCompil = assert.NoError
+			}
+			if tt.wantErr == nil {
+				tt.wantErr = assert.NoError
+			}
+			expression, err := query.CompileJSONLogic(tt.query)
+			if !tt.wantCompil(t, err) || err != nil {
+				return
+			}
+			got, err := expression.Evaluate(data)
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestJSONLogicFractional checks that the fractional operator affects the evaluation contexts to the same buckets
+// as flagd (the expected buckets come from the flagd test suite).
+func TestJSONLogicFractional(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		data  map[string]interface{}
+		want  string
+	}{
+		{
+			name: "bucketing value with the flag key",
+			query: `{"fractional": [{"cat": [{"var": "$flagd.flagKey"}, {"var": "email"}]}, ` +
+				`["red", 25], ["blue", 25], ["green", 25], ["yellow", 25]]}`,
+			data: map[string]interface{}{"email": "rachel@faas.com", "$flagd": map[string]interface{}{"flagKey": "headerColor"}},
+			want: "yellow",
+		},
+		{
+			name: "bucketing value with the flag key 2",
+			query: `{"fractional": [{"cat": [{"var": "$flagd.flagKey"}, {"var": "email"}]}, ` +
+				`["red", 25], ["blue", 25], ["green", 25], ["yellow", 25]]}`,
+			data: map[string]interface{}{"email": "monica@faas.com", "$flagd": map[string]interface{}{"flagKey": "headerColor"}},
+			want: "blue",
+		},
+		{
+			name: "bucketing value with the flag key 3",
+			query: `{"fractional": [{"cat": [{"var": "$flagd.flagKey"}, {"var": "email"}]}, ` +
+				`["red", 25], ["blue", 25], ["green", 25], ["yellow", 25]]}`,
+			data: map[string]interface{}{"email": "joey@faas.com", "$flagd": map[string]interface{}{"flagKey": "headerColor"}},
+			want: "red",
+		},
+		{
+			name: "bucketing value with the flag key 4",
+			query: `{"fractional": [{"cat": [{"var": "$flagd.flagKey"}, {"var": "email"}]}, ` +
+				`["red", 25], ["blue", 25], ["green", 25], ["yellow", 25]]}`,
+			data: map[string]interface{}{"email": "ross@faas.com", "$flagd": map[string]interface{}{"flagKey": "headerColor"}},
+			want: "green",
+		},
+		{
+			name:  "default bucketing value is the flag key and the targeting key",
+			query: `{"fractional": [["red", 25], ["blue", 25], ["green", 25], ["yellow", 25]]}`,
+			data:  map[string]interface{}{"key": "rachel@faas.com", "$flagd": map[string]interface{}{"flagKey": "headerColor"}},
+			want:  "yellow",
+		},
+		{
+			name:  "bucket without weight",
+			query: `{"fractional": [{"var": "email"}, ["red"], ["blue"]]}`,
+			data:  map[string]interface{}{"email": "rachel@faas.com"},
+			want:  "blue",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expression, err := query.CompileJSONLogic(`{"==": [` + tt.query + `, "` + tt.want + `"]}`)
+			assert.NoError(t, err)
+			got, err := expression.Evaluate(tt.data)
+			assert.NoError(t, err)
+			assert.True(t, got)
+		})
+	}
+}
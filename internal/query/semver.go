@@ -0,0 +1,70 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// canonicalSemver converts a version to the format expected by the semver package (ex: 1.2.3 -> v1.2.3).
+func canonicalSemver(version string) (string, error) {
+	v := strings.TrimSpace(version)
+	if !strings.HasPrefix(v, "v") {
+		v = "v" + v
+	}
+	if !semver.IsValid(v) {
+		return "", fmt.Errorf("invalid semantic version: %s", version)
+	}
+	return v, nil
+}
+
+// semverOperators are the operators available to compare 2 semantic versions.
+var semverOperators = map[string]bool{
+	"=": true, "==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true, "^": true, "~": true,
+}
+
+// CheckSemver checks that the operator exists and that the version is a valid semantic version.
+func CheckSemver(operator string, version string) error {
+	if !semverOperators[operator] {
+		return fmt.Errorf("unknown semantic version operator: %s", operator)
+	}
+	_, err := canonicalSemver(version)
+	return err
+}
+
+// MatchSemver compares 2 semantic versions with an operator: =, !=, <, <=, >, >=,
+// ^ (same major version) and ~ (same major and minor version).
+// A pre-release version is lower than the release (ex: 1.10.0-beta.1 < 1.10.0).
+func MatchSemver(version string, operator string, other string) (bool, error) {
+	v, err := canonicalSemver(version)
+	if err != nil {
+		return false, err
+	}
+	o, err := canonicalSemver(other)
+	if err != nil {
+		return false, err
+	}
+
+	c := semver.Compare(v, o)
+	switch operator {
+	case "=", "==":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	case "^":
+		return semver.Major(v) == semver.Major(o), nil
+	case "~":
+		return semver.MajorMinor(v) == semver.MajorMinor(o), nil
+	default:
+		return false, fmt.Errorf("unknown semantic version operator: %s", operator)
+	}
+}
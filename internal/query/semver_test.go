@@ -0,0 +1,36 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/internal/query"
+)
+
+func TestMatchSemver(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		operator string
+		other    string
+		want     bool
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{name: "numeric comparison", version: "1.10.0", operator: ">", other: "1.9.0", want: true, wantErr: assert.NoError},
+		{name: "with v prefix", version: "v1.2.3", operator: "=", other: "1.2.3", want: true, wantErr: assert.NoError},
+		{name: "pre-release lower than release", version: "1.10.0-beta.1", operator: "<", other: "1.10.0",
+			want: true, wantErr: assert.NoError},
+		{name: "pre-release ordering", version: "1.10.0-alpha", operator: "<", other: "1.10.0-beta",
+			want: true, wantErr: assert.NoError},
+		{name: "same major", version: "1.10.0", operator: "^", other: "1.2.0", want: true, wantErr: assert.NoError},
+		{name: "invalid version", version: "1.x", operator: "=", other: "1.2.0", want: false, wantErr: assert.Error},
+		{name: "unknown operator", version: "1.2.0", operator: "=>", other: "1.2.0", want: false, wantErr: assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := query.MatchSemver(tt.version, tt.operator, tt.other)
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
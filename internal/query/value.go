@@ -0,0 +1,146 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// targetingKeyAttribute is the name of the targeting key in the OpenFeature ecosystem,
+// in the evaluation context it is available as "key".
+const targetingKeyAttribute = "targetingKey"
+
+// lookup returns the value of an attribute of the evaluation context, nested attributes can be accessed
+// with a dot-separated path (ex: "company.id") and list items with their index (ex: "groups.0").
+func lookup(data interface{}, path string) (interface{}, bool) {
+	current := data
+	for i, part := range strings.Split(path, ".") {
+		switch value := normalize(current).(type) {
+		case map[string]interface{}:
+			next, ok := value[part]
+			if !ok && i == 0 && part == targetingKeyAttribute {
+				next, ok = value["key"]
+			}
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(value) {
+				return nil, false
+			}
+			current = value[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// normalize converts the values coming from the evaluation context to the types used by the evaluators:
+// float64 or int64 for numbers, []interface{} for lists and map[string]interface{} for objects.
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, bool, string, float64, int64, map[string]interface{}, []interface{}:
+		return v
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint64:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case []string:
+		list := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			list = append(list, item)
+		}
+		return list
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			list = append(list, rv.Index(i).Interface())
+		}
+		return list
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return value
+		}
+		m := make(map[string]interface{}, rv.Len())
+		for _, key := range rv.MapKeys() {
+			m[key.String()] = rv.MapIndex(key).Interface()
+		}
+		return m
+	default:
+		return value
+	}
+}
+
+// toFloat converts a number to a float64.
+func toFloat(value interface{}) (float64, bool) {
+	switch v := normalize(value).(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// isNumber checks if the value is a number.
+func isNumber(value interface{}) bool {
+	_, ok := toFloat(value)
+	return ok
+}
+
+// toString converts a value to its string representation.
+func toString(value interface{}) string {
+	switch v := normalize(value).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e21 {
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// compareNumbers compares two numbers, it returns -1, 0 or 1.
+func compareNumbers(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
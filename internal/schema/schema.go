@@ -0,0 +1,109 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Schema is a JSON schema, as it is written in the configuration file.
+//
+// All the keywords of the specification (draft 2020-12 if $schema is not set) are supported, including
+// $ref to the definitions of the schema and format. The references to other documents are not supported.
+type Schema = map[string]interface{}
+
+// schemaURL is the URL of the schema in the compiler, the references to other documents are relative to it.
+const schemaURL = "goff://flag/schema.json"
+
+// Check checks that the schema is a valid JSON schema.
+func Check(schema Schema) error {
+	_, err := compile(schema)
+	return err
+}
+
+// Validate checks that the value follows the schema, the error contains the location of the first invalid value.
+func Validate(schema Schema, value interface{}) error {
+	compiled, err := compile(schema)
+	if err != nil {
+		return err
+	}
+	instance, err := toJSONValue(value)
+	if err != nil {
+		return err
+	}
+	if err := compiled.Validate(instance); err != nil {
+		var validationErr *jsonschema.ValidationError
+		if errors.As(err, &validationErr) {
+			leaf := leafError(validationErr)
+			return fmt.Errorf("%s: %s", location(leaf.InstanceLocation), leaf.Message)
+		}
+		return err
+	}
+	return nil
+}
+
+// compile compiles the schema, the formats are validated and the references to other documents are rejected.
+func compile(schema Schema) (*jsonschema.Schema, error) {
+	content, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat = true
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("references to other documents are not supported: %s", url)
+	}
+	if err := compiler.AddResource(schemaURL, bytes.NewReader(content)); err != nil {
+		return nil, err
+	}
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		var schemaErr *jsonschema.SchemaError
+		if errors.As(err, &schemaErr) {
+			var validationErr *jsonschema.ValidationError
+			if errors.As(schemaErr.Err, &validationErr) {
+				leaf := leafError(validationErr)
+				return nil, fmt.Errorf("%s: %s", location(leaf.InstanceLocation), leaf.Message)
+			}
+			return nil, schemaErr.Err
+		}
+		return nil, err
+	}
+	return compiled, nil
+}
+
+// toJSONValue converts the value to the types produced by encoding/json (numbers are kept as json.Number),
+// the values decoded from YAML or TOML files use other types for the integers, the arrays and the objects.
+func toJSONValue(value interface{}) (interface{}, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("the value is not a JSON value: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var instance interface{}
+	if err := decoder.Decode(&instance); err != nil {
+		return nil, fmt.Errorf("the value is not a JSON value: %w", err)
+	}
+	return instance, nil
+}
+
+// leafError returns the first cause of the error, it is the most precise description of the invalid value.
+func leafError(err *jsonschema.ValidationError) *jsonschema.ValidationError {
+	for len(err.Causes) > 0 {
+		err = err.Causes[0]
+	}
+	return err
+}
+
+// location returns the JSON pointer of the invalid value, "/" for the value itself.
+func location(pointer string) string {
+	if pointer == "" {
+		return "/"
+	}
+	return pointer
+}
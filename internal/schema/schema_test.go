@@ -0,0 +1,218 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/internal/schema"
+)
+
+func TestValidate(t *testing.T) {
+	cdnSchema := schema.Schema{
+		"type":     "object",
+		"required": []interface{}{"url", "retries"},
+		"properties": map[string]interface{}{
+			"url":     map[string]interface{}{"type": "string", "pattern": "^https://"},
+			"retries": map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 5},
+			"mode":    map[string]interface{}{"enum": []interface{}{"fast", "safe"}},
+			"regions": map[string]interface{}{
+				"type":     "array",
+				"minItems": 1,
+				"items":    map[string]interface{}{"type": "string", "minLength": 2},
+			},
+		},
+		"additionalProperties": false,
+	}
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		wantErr string
+	}{
+		{
+			name: "valid value",
+			value: map[string]interface{}{
+				"url": "https://cdn.example.com", "retries": float64(3), "mode": "fast", "regions": []interface{}{"eu"},
+			},
+		},
+		{
+			name:    "not an object",
+			value:   "https://cdn.example.com",
+			wantErr: "/: expected object, but got string",
+		},
+		{
+			name:    "missing required property",
+			value:   map[string]interface{}{"url": "https://cdn.example.com"},
+			wantErr: "/: missing properties: 'retries'",
+		},
+		{
+			name:    "integer expected",
+			value:   map[string]interface{}{"url": "https://cdn.example.com", "retries": 1.5},
+			wantErr: "/retries: expected integer, but got number",
+		},
+		{
+			name:    "maximum",
+			value:   map[string]interface{}{"url": "https://cdn.example.com", "retries": 10},
+			wantErr: "/retries: must be <= 5 but found 10",
+		},
+		{
+			name:    "pattern",
+			value:   map[string]interface{}{"url": "http://cdn.example.com", "retries": 1},
+			wantErr: "/url: does not match pattern '^https://'",
+		},
+		{
+			name:    "enum",
+			value:   map[string]interface{}{"url": "https://cdn.example.com", "retries": 1, "mode": "slow"},
+			wantErr: `/mode: value must be one of "fast", "safe"`,
+		},
+		{
+			name: "items",
+			value: map[string]interface{}{
+				"url": "https://cdn.example.com", "retries": 1, "regions": []interface{}{"eu", "u"},
+			},
+			wantErr: "/regions/1: length must be >= 2, but got 1",
+		},
+		{
+			name:    "additional property",
+			value:   map[string]interface{}{"url": "https://cdn.example.com", "retries": 1, "timeout": 3},
+			wantErr: "/: additionalProperties 'timeout' not allowed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Validate(cdnSchema, tt.value)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  schema.Schema
+		wantErr string
+	}{
+		{
+			name: "valid schema",
+			schema: schema.Schema{
+				"$schema":    "https://json-schema.org/draft/2020-12/schema",
+				"type":       []interface{}{"object", "null"},
+				"properties": map[string]interface{}{"id": map[string]interface{}{"type": "string"}},
+			},
+		},
+		{
+			name:    "unknown type",
+			schema:  schema.Schema{"type": "int"},
+			wantErr: `/type: value must be one of "array", "boolean", "integer", "null", "number", "object", "string"`,
+		},
+		{
+			name: "invalid nested schema",
+			schema: schema.Schema{
+				"properties": map[string]interface{}{"id": map[string]interface{}{"pattern": "["}},
+			},
+			wantErr: "/properties/id/pattern: '[' is not valid 'regex'",
+		},
+		{
+			name:    "reference to another document",
+			schema:  schema.Schema{"$ref": "https://example.com/schema.json"},
+			wantErr: "references to other documents are not supported: https://example.com/schema.json",
+		},
+		{
+			name:    "invalid keyword value",
+			schema:  schema.Schema{"minLength": "2"},
+			wantErr: "/minLength: expected integer, but got string",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Check(tt.schema)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateCombinedSchemas(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  schema.Schema
+		value   interface{}
+		wantErr string
+	}{
+		{
+			name: "reference to a definition",
+			schema: schema.Schema{
+				"$defs": map[string]interface{}{"port": map[string]interface{}{"type": "integer", "maximum": 65535}},
+				"properties": map[string]interface{}{
+					"port": map[string]interface{}{"$ref": "#/$defs/port"},
+				},
+			},
+			value:   map[string]interface{}{"port": 70000},
+			wantErr: "/port: must be <= 65535 but found 70000",
+		},
+		{
+			name: "oneOf",
+			schema: schema.Schema{
+				"oneOf": []interface{}{
+					map[string]interface{}{"type": "string"},
+					map[string]interface{}{"type": "integer"},
+				},
+			},
+			value:   true,
+			wantErr: "/: expected string, but got boolean",
+		},
+		{
+			name: "anyOf",
+			schema: schema.Schema{
+				"anyOf": []interface{}{
+					map[string]interface{}{"type": "string", "maxLength": 3},
+					map[string]interface{}{"type": "integer"},
+				},
+			},
+			value: 42,
+		},
+		{
+			name: "allOf",
+			schema: schema.Schema{
+				"allOf": []interface{}{
+					map[string]interface{}{"required": []interface{}{"id"}},
+					map[string]interface{}{"required": []interface{}{"name"}},
+				},
+			},
+			value:   map[string]interface{}{"id": "1"},
+			wantErr: "/: missing properties: 'name'",
+		},
+		{
+			name:    "format",
+			schema:  schema.Schema{"type": "string", "format": "email"},
+			value:   "not-an-email",
+			wantErr: "/: 'not-an-email' is not valid 'email'",
+		},
+		{
+			name: "values decoded from a YAML file",
+			schema: schema.Schema{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "object", "required": []interface{}{"id"}},
+			},
+			value: []map[string]interface{}{{"id": int64(1)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, schema.Check(tt.schema))
+			err := schema.Validate(tt.schema, tt.value)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
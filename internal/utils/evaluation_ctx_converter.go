@@ -1,12 +1,78 @@
 package utils
 
-import "github.com/thomaspoignant/go-feature-flag/ffcontext"
+import (
+	"fmt"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/contextschema"
+)
 
 // ConvertEvaluationCtxFromRequest convert the result of an unmarshal request from the API to a ffcontext.Context
 // @param targetingKey the targeting key to use for the context
 // @param custom the custom attributes to add to the context
 // @return ffcontext.Context
 func ConvertEvaluationCtxFromRequest(targetingKey string, custom map[string]interface{}) ffcontext.Context {
+	return convertEvaluationCtx(targetingKey, custom)
+}
+
+// ConvertMultiContextFromRequest convert the result of an unmarshal request from the API to a ffcontext.Context
+// with the other kinds of the request. If there is no other kind, the context is the same as the one
+// returned by ConvertEvaluationCtxFromRequest, otherwise a ffcontext.MultiContext is created, the context of
+// the request being the kind "user".
+// @param targetingKey the targeting key to use for the context
+// @param custom the custom attributes to add to the context
+// @param kinds the attributes of the other kinds indexed by their names, the key of a kind is its attribute
+// "targetingKey" (or "key")
+// @return ffcontext.Context, error
+func ConvertMultiContextFromRequest(
+	targetingKey string, custom map[string]interface{}, kinds map[string]map[string]interface{},
+) (ffcontext.Context, error) {
+	ctx := convertEvaluationCtx(targetingKey, custom)
+	if len(kinds) == 0 {
+		return ctx, nil
+	}
+
+	contexts := map[string]ffcontext.EvaluationContext{ffcontext.DefaultKind: ctx}
+	for name, attributes := range kinds {
+		if name == ffcontext.DefaultKind {
+			return ctx, fmt.Errorf("the kind %s is the evaluation context of the request", name)
+		}
+		key, _ := attributes["targetingKey"].(string)
+		if key == "" {
+			key, _ = attributes["key"].(string)
+		}
+		contexts[name] = convertEvaluationCtx(key, attributes)
+	}
+	return ffcontext.NewMultiContext(contexts)
+}
+
+// ConvertEvaluationCtxFromRequestWithSchema convert the result of an unmarshal request from the API to a
+// ffcontext.Context and validates it against the context schema of the configuration.
+// An error is returned only if the context is invalid and the schema is in strict mode, in lenient mode the
+// invalid context is reported when evaluating the flags.
+// @param targetingKey the targeting key to use for the context
+// @param custom the custom attributes to add to the context
+// @param kinds the attributes of the other kinds indexed by their names, nil for a single context
+// @param schema the context schema of the configuration, nil if there is none
+// @return ffcontext.Context, error
+func ConvertEvaluationCtxFromRequestWithSchema(
+	targetingKey string,
+	custom map[string]interface{},
+	kinds map[string]map[string]interface{},
+	schema *contextschema.Schema,
+) (ffcontext.Context, error) {
+	ctx, err := ConvertMultiContextFromRequest(targetingKey, custom, kinds)
+	if err != nil {
+		return ctx, err
+	}
+	if schema == nil || !schema.IsStrict() {
+		return ctx, nil
+	}
+	return ctx, schema.Validate(ContextToMap(ctx))
+}
+
+// convertEvaluationCtx creates a single context, the numbers without decimal part are converted to int.
+func convertEvaluationCtx(targetingKey string, custom map[string]interface{}) ffcontext.EvaluationContext {
 	ctx := ffcontext.NewEvaluationContextBuilder(targetingKey)
 	for k, v := range custom {
 		switch val := v.(type) {
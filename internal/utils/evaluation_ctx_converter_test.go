@@ -3,7 +3,9 @@ package utils_test
 import (
 	"github.com/stretchr/testify/assert"
 	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/contextschema"
 	"github.com/thomaspoignant/go-feature-flag/internal/utils"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
 	"testing"
 )
 
@@ -60,6 +62,19 @@ func Test_ConvertEvaluationCtxFromReq(t *testing.T) {
 				AddCustom("company_id", 1).
 				Build(),
 		},
+		{
+			name: "should keep a custom attribute named kinds as a custom attribute",
+			fields: fields{
+				Key: "2323f37b-eef7-4bbc-856f-7d16c67de3ae",
+				Custom: map[string]interface{}{
+					"kinds": map[string]interface{}{"organization": map[string]interface{}{"targetingKey": "org-1"}},
+				},
+			},
+			want: ffcontext.
+				NewEvaluationContextBuilder("2323f37b-eef7-4bbc-856f-7d16c67de3ae").
+				AddCustom("kinds", map[string]interface{}{"organization": map[string]interface{}{"targetingKey": "org-1"}}).
+				Build(),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -68,3 +83,111 @@ func Test_ConvertEvaluationCtxFromReq(t *testing.T) {
 		})
 	}
 }
+
+func Test_ConvertMultiContextFromRequest(t *testing.T) {
+	user := ffcontext.NewEvaluationContextBuilder("2323f37b-eef7-4bbc-856f-7d16c67de3ae").
+		AddCustom("email", "john.doe@gofeatureflag.org").
+		Build()
+	multiContext, err := ffcontext.NewMultiContext(map[string]ffcontext.EvaluationContext{
+		"user": user,
+		"organization": ffcontext.NewEvaluationContextBuilder("org-1").
+			AddCustom("targetingKey", "org-1").
+			AddCustom("seats", 10).
+			Build(),
+		"device": ffcontext.NewEvaluationContextBuilder("device-1").
+			AddCustom("key", "device-1").
+			Build(),
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		custom  map[string]interface{}
+		kinds   map[string]map[string]interface{}
+		want    ffcontext.Context
+		wantErr string
+	}{
+		{
+			name:   "no kinds",
+			custom: map[string]interface{}{"email": "john.doe@gofeatureflag.org"},
+			want:   user,
+		},
+		{
+			name:   "several kinds",
+			custom: map[string]interface{}{"email": "john.doe@gofeatureflag.org"},
+			kinds: map[string]map[string]interface{}{
+				"organization": {"targetingKey": "org-1", "seats": 10.0},
+				"device":       {"key": "device-1"},
+			},
+			want: multiContext,
+		},
+		{
+			name:    "kind user in the kinds",
+			custom:  map[string]interface{}{"email": "john.doe@gofeatureflag.org"},
+			kinds:   map[string]map[string]interface{}{"user": {"targetingKey": "user-2"}},
+			wantErr: "the kind user is the evaluation context of the request",
+		},
+		{
+			name:   "kind colliding with an attribute of the request",
+			custom: map[string]interface{}{"organization": "acme"},
+			kinds:  map[string]map[string]interface{}{"organization": {"targetingKey": "org-1"}},
+			wantErr: "impossible to create the multi-kind context: " +
+				"the kind organization is also an attribute of the kind user",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := utils.ConvertMultiContextFromRequest("2323f37b-eef7-4bbc-856f-7d16c67de3ae", tt.custom, tt.kinds)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ConvertEvaluationCtxFromRequestWithSchema(t *testing.T) {
+	attributes := map[string]contextschema.Attribute{
+		"plan": {Type: testconvert.String(contextschema.TypeString), Required: testconvert.Bool(true)},
+	}
+	tests := []struct {
+		name    string
+		custom  map[string]interface{}
+		schema  *contextschema.Schema
+		wantErr string
+	}{
+		{
+			name:   "no schema",
+			custom: map[string]interface{}{"Plan": "pro"},
+		},
+		{
+			name:   "valid context",
+			custom: map[string]interface{}{"plan": "pro"},
+			schema: &contextschema.Schema{Mode: testconvert.String(contextschema.ModeStrict), Attributes: attributes},
+		},
+		{
+			name:   "invalid context in lenient mode",
+			custom: map[string]interface{}{"Plan": "pro"},
+			schema: &contextschema.Schema{Attributes: attributes},
+		},
+		{
+			name:    "invalid context in strict mode",
+			custom:  map[string]interface{}{"Plan": "pro"},
+			schema:  &contextschema.Schema{Mode: testconvert.String(contextschema.ModeStrict), Attributes: attributes},
+			wantErr: "missing required attribute plan",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := utils.ConvertEvaluationCtxFromRequestWithSchema("user-key", tt.custom, nil, tt.schema)
+			assert.Equal(t, utils.ConvertEvaluationCtxFromRequest("user-key", tt.custom), got)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
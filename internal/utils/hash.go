@@ -1,6 +1,12 @@
 package utils
 
-import "hash/fnv"
+import (
+	"crypto/sha1" // nolint: gosec
+	"encoding/binary"
+	"hash/fnv"
+
+	"github.com/spaolacci/murmur3"
+)
 
 // Hash is taking a string and convert.
 func Hash(s string) uint32 {
@@ -12,3 +18,15 @@ func Hash(s string) uint32 {
 	}
 	return h.Sum32()
 }
+
+// Murmur3Hash returns the 32 bits MurmurHash3 (x86 variant, seed 0) of the string.
+// It is the hash used by flagd and most of the feature flag vendors to compute percentage buckets.
+func Murmur3Hash(s string) uint32 {
+	return murmur3.Sum32([]byte(s))
+}
+
+// SHA1Hash returns the first 4 bytes of the SHA-1 of the string, as a big endian number.
+func SHA1Hash(s string) uint32 {
+	sum := sha1.Sum([]byte(s)) // nolint: gosec
+	return binary.BigEndian.Uint32(sum[:4])
+}
@@ -25,3 +25,75 @@ func TestHash(t *testing.T) {
 		})
 	}
 }
+
+func TestMurmur3Hash(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want uint32
+	}{
+		{
+			name: "empty string",
+			s:    "",
+			want: 0,
+		},
+		{
+			name: "reference value",
+			s:    "The quick brown fox jumps over the lazy dog",
+			want: 0x2e4ff723,
+		},
+		{
+			name: "string with a tail",
+			s:    "hello",
+			want: 0x248bfa47,
+		},
+		{
+			name: "string of one block",
+			s:    "test",
+			want: 0xba6bd213,
+		},
+		{
+			name: "string with a tail of one byte",
+			s:    "Hello, world!",
+			want: 0xc0363e43,
+		},
+		{
+			name: "flag name and user key",
+			s:    "flagNameUserKey",
+			want: 487932685,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Murmur3Hash(tt.s); got != tt.want {
+				t.Errorf("Murmur3Hash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSHA1Hash(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want uint32
+	}{
+		{
+			name: "empty string",
+			s:    "",
+			want: 3661210606,
+		},
+		{
+			name: "flag name and user key",
+			s:    "flagNameUserKey",
+			want: 2631474360,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SHA1Hash(tt.s); got != tt.want {
+				t.Errorf("SHA1Hash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
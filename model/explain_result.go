@@ -0,0 +1,10 @@
+package model
+
+import "github.com/thomaspoignant/go-feature-flag/internal/flag"
+
+// ExplainResult is the result of the evaluation of a flag, with the step-by-step trace of the evaluation.
+// This is used by ffclient.Explain functions.
+type ExplainResult struct {
+	RawVarResult
+	Trace flag.EvaluationTrace `json:"trace"`
+}
@@ -9,27 +9,39 @@ type JSONType interface {
 
 // VariationResult contains all the field available in a flag variation result.
 type VariationResult[T JSONType] struct {
-	TrackEvents   bool                   `json:"trackEvents"`
-	VariationType string                 `json:"variationType"`
-	Failed        bool                   `json:"failed"`
-	Version       string                 `json:"version"`
-	Reason        flag.ResolutionReason  `json:"reason"`
-	ErrorCode     flag.ErrorCode         `json:"errorCode"`
-	Value         T                      `json:"value"`
-	Cacheable     bool                   `json:"cacheable"`
-	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	TrackEvents    bool                   `json:"trackEvents"`
+	VariationType  string                 `json:"variationType"`
+	Failed         bool                   `json:"failed"`
+	Version        string                 `json:"version"`
+	Reason         flag.ResolutionReason  `json:"reason"`
+	ErrorCode      flag.ErrorCode         `json:"errorCode"`
+	ErrorDetails   string                 `json:"errorDetails,omitempty"`
+	Value          T                      `json:"value"`
+	Cacheable      bool                   `json:"cacheable"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	BucketingKey   string                 `json:"bucketingKey,omitempty"`
+	BucketingValue string                 `json:"bucketingValue,omitempty"`
+	Layer          string                 `json:"layer,omitempty"`
+	LayerSlice     string                 `json:"layerSlice,omitempty"`
+	ValueType      string                 `json:"valueType,omitempty"`
 }
 
 // RawVarResult is the result of the raw variation call.
 // This is used by ffclient.RawVariation functions, this should be used only by internal calls.
 type RawVarResult struct {
-	TrackEvents   bool                   `json:"trackEvents"`
-	VariationType string                 `json:"variationType"`
-	Failed        bool                   `json:"failed"`
-	Version       string                 `json:"version"`
-	Reason        flag.ResolutionReason  `json:"reason"`
-	ErrorCode     flag.ErrorCode         `json:"errorCode"`
-	Value         interface{}            `json:"value"`
-	Cacheable     bool                   `json:"cacheable"`
-	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	TrackEvents    bool                   `json:"trackEvents"`
+	VariationType  string                 `json:"variationType"`
+	Failed         bool                   `json:"failed"`
+	Version        string                 `json:"version"`
+	Reason         flag.ResolutionReason  `json:"reason"`
+	ErrorCode      flag.ErrorCode         `json:"errorCode"`
+	ErrorDetails   string                 `json:"errorDetails,omitempty"`
+	Value          interface{}            `json:"value"`
+	Cacheable      bool                   `json:"cacheable"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	BucketingKey   string                 `json:"bucketingKey,omitempty"`
+	BucketingValue string                 `json:"bucketingValue,omitempty"`
+	Layer          string                 `json:"layer,omitempty"`
+	LayerSlice     string                 `json:"layerSlice,omitempty"`
+	ValueType      string                 `json:"valueType,omitempty"`
 }
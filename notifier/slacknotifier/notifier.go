@@ -8,10 +8,13 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"reflect"
+	"regexp"
 	"sort"
 	"strings"
 	"sync"
 
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
 	"github.com/thomaspoignant/go-feature-flag/notifier"
 
 	"github.com/gdexlab/go-render/render"
@@ -28,6 +31,32 @@ const (
 	longSlackAttachment = 35
 )
 
+// omitNilFieldsRegexp finds the fields tagged with `render:"omitnil"` that are not set in the rendering of a flag.
+// Those fields are optional fields added to the flag format over time, they are only displayed when they are set
+// so the notifications of the flags not using them do not change.
+var omitNilFieldsRegexp = regexp.MustCompile(`, (?:` +
+	strings.Join(omitNilFields(flag.InternalFlag{}, flag.Rule{}, flag.ProgressiveRollout{}), "|") +
+	`):(?:\([^()]*\)|[\w.*\[\]]+)\(nil\)`)
+
+// omitNilFields returns the names of the fields tagged with `render:"omitnil"` in the structs.
+func omitNilFields(structs ...interface{}) []string {
+	fields := make([]string, 0)
+	for _, s := range structs {
+		t := reflect.TypeOf(s)
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).Tag.Get("render") == "omitnil" {
+				fields = append(fields, t.Field(i).Name)
+			}
+		}
+	}
+	return fields
+}
+
+// renderValue renders a value of a change in a flag, without the optional fields that are not set.
+func renderValue(value interface{}) string {
+	return omitNilFieldsRegexp.ReplaceAllString(render.Render(value), "")
+}
+
 type Notifier struct {
 	SlackWebhookURL string
 
@@ -119,7 +148,7 @@ func convertUpdatedFlagsToSlackMessage(diffCache notifier.DiffCache) []attachmen
 		changelog, _ := diff.Diff(value.Before, value.After, diff.AllowTypeMismatch(true))
 		for _, change := range changelog {
 			if change.Type == "update" {
-				value := fmt.Sprintf("%s => %s", render.Render(change.From), render.Render(change.To))
+				value := fmt.Sprintf("%s => %s", renderValue(change.From), renderValue(change.To))
 				short := len(value) < longSlackAttachment
 				attachment.Fields = append(
 					attachment.Fields,
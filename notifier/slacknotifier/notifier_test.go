@@ -213,3 +213,52 @@ func TestSlackNotifier_Notify(t *testing.T) {
 		})
 	}
 }
+
+func Test_renderValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{
+			name:  "optional fields not set are not rendered",
+			value: flag.Rule{Name: testconvert.String("rule1"), VariationResult: testconvert.String("A")},
+			want: `flag.Rule{Name:(*string)("rule1"), Query:(*string)(nil), VariationResult:(*string)("A"), ` +
+				`Percentages:(*map[string]float64)(nil), ProgressiveRollout:(*flag.ProgressiveRollout)(nil), ` +
+				`Disable:(*bool)(nil)}`,
+		},
+		{
+			name: "optional fields set are rendered",
+			value: flag.Rule{
+				Name:        testconvert.String("rule1"),
+				QueryFormat: testconvert.String("cel"),
+				Segment:     testconvert.String("beta"),
+			},
+			want: `flag.Rule{Name:(*string)("rule1"), Query:(*string)(nil), QueryFormat:(*string)("cel"), ` +
+				`Segment:(*string)("beta"), VariationResult:(*string)(nil), Percentages:(*map[string]float64)(nil), ` +
+				`ProgressiveRollout:(*flag.ProgressiveRollout)(nil), Disable:(*bool)(nil)}`,
+		},
+		{
+			name: "targets are rendered without their index",
+			value: func() *[]flag.Target {
+				f := flag.InternalFlag{
+					Targets: &[]flag.Target{{Variation: testconvert.String("A"), Values: &[]string{"user-1"}}},
+				}
+				f.IndexTargets()
+				return f.Targets
+			}(),
+			want: `(*[]flag.Target){flag.Target{Name:(*string)(nil), Variation:(*string)("A"), ` +
+				`Attribute:(*string)(nil), Values:(*[]string){"user-1"}}}`,
+		},
+		{
+			name:  "value that is not a flag",
+			value: testconvert.String("value"),
+			want:  `(*string)("value")`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, renderValue(tt.value))
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package filestore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/stickybucketing"
+)
+
+// defaultFlushInterval is the interval between 2 writes of the file if FlushInterval is not set.
+const defaultFlushInterval = time.Second
+
+// Store keeps the sticky assignments in a local JSON file.
+// The file is read at the first use, the changes are kept in memory and written in the background
+// every FlushInterval and when the store is closed.
+// The file is replaced atomically, it is never left partially written.
+type Store struct {
+	// Path is the location of the file, it is created if it does not exist.
+	Path string
+
+	// TTL (optional) is how long an assignment is kept,
+	// after it the evaluation context is bucketed again.
+	// Default: 0, the assignments never expire
+	TTL time.Duration
+
+	// FlushInterval (optional) is the interval between 2 writes of the changes in the file.
+	// Default: 1 second
+	FlushInterval time.Duration
+
+	mutex       sync.Mutex
+	assignments map[string]map[string]stickybucketing.Assignment
+	changed     bool
+
+	// writeMutex ensures that the file is written by one flush at a time.
+	writeMutex sync.Mutex
+	startOnce  sync.Once
+	stopOnce   sync.Once
+	stop       chan struct{}
+	stopped    chan struct{}
+}
+
+// Get returns the variation assigned to the bucketing value for the flag.
+func (s *Store) Get(_ context.Context, flagKey string, bucketingValue string) (string, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.load(); err != nil {
+		return "", false, err
+	}
+	assignment, ok := s.assignments[flagKey][bucketingValue]
+	if !ok || assignment.IsExpired(time.Now()) {
+		return "", false, nil
+	}
+	return assignment.Variation, true, nil
+}
+
+// Set assigns the variation to the bucketing value for the flag.
+func (s *Store) Set(_ context.Context, flagKey string, bucketingValue string, variation string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	if s.assignments[flagKey] == nil {
+		s.assignments[flagKey] = make(map[string]stickybucketing.Assignment)
+	}
+	s.assignments[flagKey][bucketingValue] = stickybucketing.NewAssignment(variation, s.TTL)
+	s.markChanged()
+	return nil
+}
+
+// Reset removes all the assignments of the flag.
+func (s *Store) Reset(_ context.Context, flagKey string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	delete(s.assignments, flagKey)
+	s.markChanged()
+	return nil
+}
+
+// Close stops the background writes and writes the last changes in the file,
+// the store should not be used after it is closed.
+func (s *Store) Close() error {
+	s.mutex.Lock()
+	stop, stopped := s.stop, s.stopped
+	s.mutex.Unlock()
+	s.stopOnce.Do(func() {
+		if stop != nil {
+			close(stop)
+			<-stopped
+		}
+	})
+	return s.flush()
+}
+
+// load reads the assignments from the file if they are not loaded yet.
+func (s *Store) load() error {
+	if s.assignments != nil {
+		return nil
+	}
+	content, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.assignments = make(map[string]map[string]stickybucketing.Assignment)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("impossible to read the sticky bucketing file %s: %w", s.Path, err)
+	}
+
+	assignments := make(map[string]map[string]stickybucketing.Assignment)
+	if err := json.Unmarshal(content, &assignments); err != nil {
+		return fmt.Errorf("invalid sticky bucketing file %s: %w", s.Path, err)
+	}
+	s.assignments = assignments
+	return nil
+}
+
+// markChanged records that the assignments have to be written, and starts the background writes
+// at the first change.
+func (s *Store) markChanged() {
+	s.changed = true
+	s.startOnce.Do(func() {
+		s.stop = make(chan struct{})
+		s.stopped = make(chan struct{})
+		go s.flushPeriodically()
+	})
+}
+
+// flushPeriodically writes the changes in the file every FlushInterval until the store is closed.
+func (s *Store) flushPeriodically() {
+	defer close(s.stopped)
+	interval := s.FlushInterval
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			// a failed write is retried at the next tick, the changes are kept in memory.
+			_ = s.flush()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// flush writes all the assignments in the file if they have changed, the expired ones are removed.
+func (s *Store) flush() error {
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+
+	s.mutex.Lock()
+	if !s.changed {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.removeExpired()
+	content, err := json.Marshal(s.assignments)
+	s.changed = false
+	s.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := s.write(content); err != nil {
+		s.mutex.Lock()
+		s.changed = true
+		s.mutex.Unlock()
+		return fmt.Errorf("impossible to write the sticky bucketing file %s: %w", s.Path, err)
+	}
+	return nil
+}
+
+// removeExpired removes the expired assignments, it should be called with the mutex locked.
+func (s *Store) removeExpired() {
+	now := time.Now()
+	for flagKey, flagAssignments := range s.assignments {
+		for bucketingValue, assignment := range flagAssignments {
+			if assignment.IsExpired(now) {
+				delete(flagAssignments, bucketingValue)
+			}
+		}
+		if len(flagAssignments) == 0 {
+			delete(s.assignments, flagKey)
+		}
+	}
+}
+
+// write replaces the file with the content, the content is written in a temporary file of the same directory
+// and renamed, so the file is never partially written.
+func (s *Store) write(content []byte) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+
+	if _, err := tmpFile.Write(content); err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), s.Path)
+}
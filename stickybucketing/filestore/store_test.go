@@ -0,0 +1,92 @@
+package filestore_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/stickybucketing/filestore"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "assignments.json")
+	store := &filestore.Store{Path: path}
+
+	_, ok, err := store.Get(ctx, "flag1", "user-1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, store.Set(ctx, "flag1", "user-1", "A"))
+	assert.NoError(t, store.Set(ctx, "flag2", "user-1", "B"))
+	assert.NoError(t, store.Close())
+
+	// a new store reads the assignments from the file
+	reloaded := &filestore.Store{Path: path}
+	variation, ok, err := reloaded.Get(ctx, "flag1", "user-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "A", variation)
+
+	assert.NoError(t, reloaded.Reset(ctx, "flag1"))
+	assert.NoError(t, reloaded.Close())
+	reloaded = &filestore.Store{Path: path}
+	_, ok, err = reloaded.Get(ctx, "flag1", "user-1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	variation, ok, err = reloaded.Get(ctx, "flag2", "user-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "B", variation)
+}
+
+func TestStore_FlushInterval(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "assignments.json")
+	store := &filestore.Store{Path: path, FlushInterval: 10 * time.Millisecond}
+	defer func() { _ = store.Close() }()
+
+	// the changes are written in the background, without closing the store
+	assert.NoError(t, store.Set(ctx, "flag1", "user-1", "A"))
+	assert.Eventually(t, func() bool {
+		content, err := os.ReadFile(path)
+		return err == nil && string(content) != ""
+	}, time.Second, 10*time.Millisecond)
+	reloaded := &filestore.Store{Path: path}
+	variation, ok, err := reloaded.Get(ctx, "flag1", "user-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "A", variation)
+
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1, "no temporary file should be left in the directory")
+}
+
+func TestStore_TTL(t *testing.T) {
+	ctx := context.Background()
+	store := &filestore.Store{Path: filepath.Join(t.TempDir(), "assignments.json"), TTL: 50 * time.Millisecond}
+	defer func() { _ = store.Close() }()
+
+	assert.NoError(t, store.Set(ctx, "flag1", "user-1", "A"))
+	_, ok, _ := store.Get(ctx, "flag1", "user-1")
+	assert.True(t, ok)
+
+	time.Sleep(60 * time.Millisecond)
+	_, ok, _ = store.Get(ctx, "flag1", "user-1")
+	assert.False(t, ok)
+}
+
+func TestStore_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "assignments.json")
+	assert.NoError(t, os.WriteFile(path, []byte("not a json"), 0600))
+	store := &filestore.Store{Path: path}
+
+	_, _, err := store.Get(context.Background(), "flag1", "user-1")
+	assert.Error(t, err)
+	assert.Error(t, store.Set(context.Background(), "flag1", "user-1", "A"))
+}
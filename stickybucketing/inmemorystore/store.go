@@ -0,0 +1,53 @@
+package inmemorystore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/stickybucketing"
+)
+
+// Store keeps the sticky assignments in memory, they are lost when the application stops.
+type Store struct {
+	// TTL (optional) is how long an assignment is kept,
+	// after it the evaluation context is bucketed again.
+	// Default: 0, the assignments never expire
+	TTL time.Duration
+
+	mutex       sync.RWMutex
+	assignments map[string]map[string]stickybucketing.Assignment
+}
+
+// Get returns the variation assigned to the bucketing value for the flag.
+func (s *Store) Get(_ context.Context, flagKey string, bucketingValue string) (string, bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	assignment, ok := s.assignments[flagKey][bucketingValue]
+	if !ok || assignment.IsExpired(time.Now()) {
+		return "", false, nil
+	}
+	return assignment.Variation, true, nil
+}
+
+// Set assigns the variation to the bucketing value for the flag.
+func (s *Store) Set(_ context.Context, flagKey string, bucketingValue string, variation string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.assignments == nil {
+		s.assignments = make(map[string]map[string]stickybucketing.Assignment)
+	}
+	if s.assignments[flagKey] == nil {
+		s.assignments[flagKey] = make(map[string]stickybucketing.Assignment)
+	}
+	s.assignments[flagKey][bucketingValue] = stickybucketing.NewAssignment(variation, s.TTL)
+	return nil
+}
+
+// Reset removes all the assignments of the flag.
+func (s *Store) Reset(_ context.Context, flagKey string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.assignments, flagKey)
+	return nil
+}
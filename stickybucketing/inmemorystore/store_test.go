@@ -0,0 +1,48 @@
+package inmemorystore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/stickybucketing/inmemorystore"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	store := &inmemorystore.Store{}
+
+	_, ok, err := store.Get(ctx, "flag1", "user-1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, store.Set(ctx, "flag1", "user-1", "A"))
+	assert.NoError(t, store.Set(ctx, "flag2", "user-1", "B"))
+	variation, ok, err := store.Get(ctx, "flag1", "user-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "A", variation)
+
+	assert.NoError(t, store.Reset(ctx, "flag1"))
+	_, ok, err = store.Get(ctx, "flag1", "user-1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	variation, ok, err = store.Get(ctx, "flag2", "user-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "B", variation)
+}
+
+func TestStore_TTL(t *testing.T) {
+	ctx := context.Background()
+	store := &inmemorystore.Store{TTL: 50 * time.Millisecond}
+
+	assert.NoError(t, store.Set(ctx, "flag1", "user-1", "A"))
+	_, ok, _ := store.Get(ctx, "flag1", "user-1")
+	assert.True(t, ok)
+
+	time.Sleep(60 * time.Millisecond)
+	_, ok, _ = store.Get(ctx, "flag1", "user-1")
+	assert.False(t, ok)
+}
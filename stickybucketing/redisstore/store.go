@@ -0,0 +1,94 @@
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+var (
+	// keyEscaper escapes the ":" of the flag keys, so the keys of a flag never start with the keys of another flag
+	// (ex: the flags "a" and "a:b").
+	keyEscaper = strings.NewReplacer(`\`, `\\`, `:`, `\:`)
+	// globEscaper escapes the special characters of the patterns of the SCAN command.
+	globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+)
+
+// Store keeps the sticky assignments in Redis, so they are shared between all the instances of the application.
+// Each assignment is stored in a key named <Prefix><flagKey>:<bucketingValue> containing the variation,
+// the "\" and ":" of the flag key are escaped with a "\".
+type Store struct {
+	// Options to connect to Redis
+	Options *redis.Options
+
+	// Prefix (optional) is the prefix of the keys created in Redis.
+	// Default: ""
+	Prefix string
+
+	// TTL (optional) is how long an assignment is kept,
+	// after it the evaluation context is bucketed again.
+	// Default: 0, the assignments never expire
+	TTL time.Duration
+
+	initOnce sync.Once
+	client   *redis.Client
+}
+
+// Get returns the variation assigned to the bucketing value for the flag.
+func (s *Store) Get(ctx context.Context, flagKey string, bucketingValue string) (string, bool, error) {
+	variation, err := s.getClient().Get(ctx, s.key(flagKey, bucketingValue)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return variation, true, nil
+}
+
+// Set assigns the variation to the bucketing value for the flag.
+func (s *Store) Set(ctx context.Context, flagKey string, bucketingValue string, variation string) error {
+	return s.getClient().Set(ctx, s.key(flagKey, bucketingValue), variation, s.TTL).Err()
+}
+
+// Reset removes all the assignments of the flag.
+func (s *Store) Reset(ctx context.Context, flagKey string) error {
+	client := s.getClient()
+	iter := client.Scan(ctx, 0, globEscaper.Replace(s.flagKeyPrefix(flagKey))+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		if err := client.Del(ctx, iter.Val()).Err(); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// Close closes the connection to Redis.
+func (s *Store) Close() error {
+	if s.client == nil {
+		return nil
+	}
+	return s.client.Close()
+}
+
+// getClient creates the Redis client at the first use.
+func (s *Store) getClient() *redis.Client {
+	s.initOnce.Do(func() {
+		s.client = redis.NewClient(s.Options)
+	})
+	return s.client
+}
+
+// key returns the name of the Redis key containing the assignment.
+func (s *Store) key(flagKey string, bucketingValue string) string {
+	return s.flagKeyPrefix(flagKey) + bucketingValue
+}
+
+// flagKeyPrefix returns the beginning of the names of all the Redis keys of the flag.
+func (s *Store) flagKeyPrefix(flagKey string) string {
+	return s.Prefix + keyEscaper.Replace(flagKey) + ":"
+}
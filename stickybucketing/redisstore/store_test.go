@@ -0,0 +1,76 @@
+//go:build docker
+// +build docker
+
+package redisstore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+	testcontainerRedis "github.com/testcontainers/testcontainers-go/modules/redis"
+	"github.com/thomaspoignant/go-feature-flag/stickybucketing/redisstore"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	redisContainer, err := testcontainerRedis.RunContainer(ctx, testcontainers.WithImage("docker.io/redis:7"))
+	assert.NoError(t, err)
+	defer func() { _ = redisContainer.Terminate(ctx) }()
+	address, err := redisContainer.Endpoint(ctx, "")
+	assert.NoError(t, err)
+
+	store := &redisstore.Store{Options: &redis.Options{Addr: address}, Prefix: "goff:sticky:"}
+	defer func() { _ = store.Close() }()
+
+	_, ok, err := store.Get(ctx, "flag1", "user-1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, store.Set(ctx, "flag1", "user-1", "A"))
+	assert.NoError(t, store.Set(ctx, "flag1", "user-2", "B"))
+	assert.NoError(t, store.Set(ctx, "flag2", "user-1", "B"))
+	variation, ok, err := store.Get(ctx, "flag1", "user-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "A", variation)
+
+	assert.NoError(t, store.Reset(ctx, "flag1"))
+	_, ok, err = store.Get(ctx, "flag1", "user-1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	_, ok, err = store.Get(ctx, "flag1", "user-2")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	variation, ok, err = store.Get(ctx, "flag2", "user-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "B", variation)
+}
+
+func TestStore_ResetFlagKeysWithSpecialCharacters(t *testing.T) {
+	ctx := context.Background()
+	redisContainer, err := testcontainerRedis.RunContainer(ctx, testcontainers.WithImage("docker.io/redis:7"))
+	assert.NoError(t, err)
+	defer func() { _ = redisContainer.Terminate(ctx) }()
+	address, err := redisContainer.Endpoint(ctx, "")
+	assert.NoError(t, err)
+
+	store := &redisstore.Store{Options: &redis.Options{Addr: address}, Prefix: "goff:sticky:"}
+	defer func() { _ = store.Close() }()
+
+	flagKeys := []string{"a", "a:b", "a*", `a\`, "[a]"}
+	for _, flagKey := range flagKeys {
+		assert.NoError(t, store.Set(ctx, flagKey, "user-1", "A"))
+	}
+	for index, flagKey := range flagKeys {
+		assert.NoError(t, store.Reset(ctx, flagKey))
+		for otherIndex, otherFlagKey := range flagKeys {
+			_, ok, err := store.Get(ctx, otherFlagKey, "user-1")
+			assert.NoError(t, err)
+			assert.Equal(t, otherIndex > index, ok, "reset of %s, assignment of %s", flagKey, otherFlagKey)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package stickybucketing
+
+import (
+	"context"
+	"time"
+)
+
+// Store persists the variation served to an evaluation context for a flag.
+// When a store is configured, an evaluation context keeps the first variation it received from a percentage rule,
+// even if the percentages or the variations of the flag are changed later.
+type Store interface {
+	// Get returns the variation assigned to the bucketing value for the flag,
+	// ok is false if the bucketing value has no assignment.
+	Get(ctx context.Context, flagKey string, bucketingValue string) (variation string, ok bool, err error)
+
+	// Set assigns the variation to the bucketing value for the flag.
+	Set(ctx context.Context, flagKey string, bucketingValue string, variation string) error
+
+	// Reset removes all the assignments of the flag,
+	// the evaluation contexts will be bucketed again at their next evaluation.
+	Reset(ctx context.Context, flagKey string) error
+}
+
+// Assignment is the variation assigned to an evaluation context.
+type Assignment struct {
+	// Variation is the name of the variation assigned.
+	Variation string `json:"variation"`
+
+	// ExpiresAt (optional) is the date after which the assignment is not used anymore.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// NewAssignment creates an assignment for the variation, expiring after the ttl.
+// If the ttl is 0 the assignment never expires.
+func NewAssignment(variation string, ttl time.Duration) Assignment {
+	assignment := Assignment{Variation: variation}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		assignment.ExpiresAt = &expiresAt
+	}
+	return assignment
+}
+
+// IsExpired checks if the assignment should not be used anymore.
+func (a Assignment) IsExpired(now time.Time) bool {
+	return a.ExpiresAt != nil && !now.Before(*a.ExpiresAt)
+}
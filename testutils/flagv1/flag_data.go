@@ -356,6 +356,11 @@ func (f *FlagData) GetVariationValue(variationName string) interface{} {
 	}
 }
 
+// GetType return the declared type of the values of the flag, this format has no declared type
+func (f *FlagData) GetType() string {
+	return ""
+}
+
 func (f *FlagData) GetDefaultVariation() string {
 	return VariationDefault
 }
@@ -0,0 +1,39 @@
+package mock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// StickyBucketingStore records the calls done to a sticky bucketing store, it never has an assignment.
+type StickyBucketingStore struct {
+	Calls     int
+	Deadlines []time.Time
+
+	mutex sync.Mutex
+}
+
+func (s *StickyBucketingStore) Get(ctx context.Context, _ string, _ string) (string, bool, error) {
+	s.record(ctx)
+	return "", false, nil
+}
+
+func (s *StickyBucketingStore) Set(ctx context.Context, _ string, _ string, _ string) error {
+	s.record(ctx)
+	return nil
+}
+
+func (s *StickyBucketingStore) Reset(ctx context.Context, _ string) error {
+	s.record(ctx)
+	return nil
+}
+
+func (s *StickyBucketingStore) record(ctx context.Context) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.Calls++
+	if deadline, ok := ctx.Deadline(); ok {
+		s.Deadlines = append(s.Deadlines, deadline)
+	}
+}
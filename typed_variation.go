@@ -0,0 +1,210 @@
+package ffclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/copystructure"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/model"
+)
+
+// DecodeMode is the way the value of a flag is decoded into the type requested by Variation.
+type DecodeMode int
+
+const (
+	// DecodeLenient ignores the fields of the value that do not exist in the requested type.
+	DecodeLenient DecodeMode = iota
+	// DecodeStrict fails if the value contains a field that does not exist in the requested type.
+	DecodeStrict
+)
+
+// decodedVariationKey identifies a value decoded for a flag, a type and a decode mode.
+type decodedVariationKey struct {
+	flagKey   string
+	valueType reflect.Type
+	mode      DecodeMode
+}
+
+// decodedVariation is the last value decoded for a decodedVariationKey, it is reused as long as the
+// flag is evaluated to the same variation of the same version of the flag.
+type decodedVariation struct {
+	version     string
+	variant     string
+	cacheUpdate time.Time
+	value       interface{}
+}
+
+// Variation return the value of the flag decoded into the type T (ex: a struct for a JSON flag).
+// The value is decoded with DecodeLenient, unless another DecodeMode is provided.
+// An error is return if you don't have init the library before calling the function.
+// If the key does not exist or if the value cannot be decoded into T, we return the default value.
+//
+// The decoded value is kept for the next calls evaluating the same variation, a copy of it is returned.
+func Variation[T any](flagKey string, ctx ffcontext.Context, defaultValue T, mode ...DecodeMode) (T, error) {
+	return ClientVariation(ff, flagKey, ctx, defaultValue, mode...)
+}
+
+// ClientVariation return the value of the flag decoded into the type T (ex: a struct for a JSON flag).
+// The value is decoded with DecodeLenient, unless another DecodeMode is provided.
+// An error is return if you don't have init the library before calling the function.
+// If the key does not exist or if the value cannot be decoded into T, we return the default value.
+// Note: Use this function only if you are using multiple go-feature-flag instances.
+func ClientVariation[T any](g *GoFeatureFlag, flagKey string, ctx ffcontext.Context, defaultValue T,
+	mode ...DecodeMode) (T, error) {
+	res, err := ClientVariationDetails(g, flagKey, ctx, defaultValue, mode...)
+	return res.Value, err
+}
+
+// VariationDetails return the details of the evaluation of a flag, the value being decoded into the type T.
+// If the value cannot be decoded into T, the error code is TYPE_MISMATCH and the error contains the
+// field that cannot be decoded.
+// An error is return if you don't have init the library before calling the function.
+// If the key does not exist we return the default value.
+func VariationDetails[T any](flagKey string, ctx ffcontext.Context, defaultValue T, mode ...DecodeMode) (
+	model.VariationResult[T], error) {
+	return ClientVariationDetails(ff, flagKey, ctx, defaultValue, mode...)
+}
+
+// ClientVariationDetails return the details of the evaluation of a flag, the value being decoded into the type T.
+// If the value cannot be decoded into T, the error code is TYPE_MISMATCH and the error contains the
+// field that cannot be decoded.
+// An error is return if you don't have init the library before calling the function.
+// If the key does not exist we return the default value.
+// Note: Use this function only if you are using multiple go-feature-flag instances.
+func ClientVariationDetails[T any](g *GoFeatureFlag, flagKey string, ctx ffcontext.Context, defaultValue T,
+	mode ...DecodeMode) (model.VariationResult[T], error) {
+	decodeMode := DecodeLenient
+	if len(mode) > 0 {
+		decodeMode = mode[0]
+	}
+
+	raw, evaluationCtx, err := getVariation[interface{}](g, flagKey, ctx, defaultValue, "interface{}")
+	res := model.VariationResult[T]{
+		Value:          defaultValue,
+		VariationType:  raw.VariationType,
+		Reason:         raw.Reason,
+		ErrorCode:      raw.ErrorCode,
+		ErrorDetails:   raw.ErrorDetails,
+		Failed:         raw.Failed,
+		TrackEvents:    raw.TrackEvents,
+		Version:        raw.Version,
+		Cacheable:      raw.Cacheable,
+		Metadata:       raw.Metadata,
+		BucketingKey:   raw.BucketingKey,
+		BucketingValue: raw.BucketingValue,
+		Layer:          raw.Layer,
+		LayerSlice:     raw.LayerSlice,
+		ValueType:      raw.ValueType,
+	}
+	if err != nil || raw.VariationType == flag.VariationSDKDefault {
+		notifyVariation(g, flagKey, evaluationCtx, raw)
+		return res, err
+	}
+
+	value, decodeErr := decodeVariationWithCache[T](g, flagKey, raw, decodeMode)
+	if decodeErr != nil {
+		res.VariationType = flag.VariationSDKDefault
+		res.Reason = flag.ReasonError
+		res.ErrorCode = flag.ErrorCodeTypeMismatch
+		res.ErrorDetails = decodeErr.Error()
+		res.Failed = true
+		res.Cacheable = false
+		raw.Value = defaultValue
+		raw.VariationType = res.VariationType
+		raw.Failed = true
+		notifyVariation(g, flagKey, evaluationCtx, raw)
+		return res, fmt.Errorf(errorWrongVariation+": %w", flagKey, decodeErr)
+	}
+	res.Value = value
+	notifyVariation(g, flagKey, evaluationCtx, raw)
+	return res, nil
+}
+
+// decodeVariationWithCache decodes the value of the evaluation into T, the decoded value is kept and reused
+// while the flag is evaluated to the same variation of the same version and the cache has not been refreshed.
+// The values depending on the evaluation context (ex: templates) are never kept.
+func decodeVariationWithCache[T any](
+	g *GoFeatureFlag, flagKey string, raw model.VariationResult[interface{}], mode DecodeMode,
+) (T, error) {
+	if !raw.Cacheable || g.cache == nil {
+		return decodeVariation[T](raw.Value, mode)
+	}
+
+	key := decodedVariationKey{flagKey: flagKey, valueType: reflect.TypeOf((*T)(nil)).Elem(), mode: mode}
+	cacheUpdate := g.cache.GetLatestUpdateDate()
+	if cached, ok := g.decodedVariations.Load(key); ok {
+		d := cached.(decodedVariation)
+		if d.version == raw.Version && d.variant == raw.VariationType && d.cacheUpdate.Equal(cacheUpdate) {
+			if value, err := copyVariation(d.value.(T)); err == nil {
+				return value, nil
+			}
+		}
+	}
+
+	value, err := decodeVariation[T](raw.Value, mode)
+	if err != nil {
+		return value, err
+	}
+	g.decodedVariations.Store(key, decodedVariation{
+		version:     raw.Version,
+		variant:     raw.VariationType,
+		cacheUpdate: cacheUpdate,
+		value:       value,
+	})
+	return copyVariation[T](value)
+}
+
+// copyVariation returns a deep copy of a decoded value, the caller can modify it without changing the value
+// kept for the next evaluations.
+func copyVariation[T any](value T) (T, error) {
+	copied, err := copystructure.Copy(value)
+	if err != nil {
+		return value, fmt.Errorf("impossible to copy the value: %w", err)
+	}
+	if copied == nil {
+		var zero T
+		return zero, nil
+	}
+	return copied.(T), nil
+}
+
+// decodeVariation converts the value of a flag into T, using a JSON round trip if the value is not already a T.
+// The value returned never shares its maps and slices with the flag.
+func decodeVariation[T any](value interface{}, mode DecodeMode) (T, error) {
+	var decoded T
+	if v, ok := value.(T); ok {
+		return copyVariation(v)
+	}
+
+	content, err := json.Marshal(value)
+	if err != nil {
+		return decoded, fmt.Errorf("impossible to encode the value: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	if mode == DecodeStrict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(&decoded); err != nil {
+		return decoded, decodeError(err)
+	}
+	return decoded, nil
+}
+
+// decodeError converts an error of the JSON decoder into an error naming the field that cannot be decoded.
+func decodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		if typeErr.Field == "" {
+			return fmt.Errorf("cannot decode %s into %s", typeErr.Value, typeErr.Type)
+		}
+		return fmt.Errorf("field %s: cannot decode %s into %s", typeErr.Field, typeErr.Value, typeErr.Type)
+	}
+	return errors.New(strings.TrimPrefix(err.Error(), "json: "))
+}
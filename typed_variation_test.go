@@ -0,0 +1,235 @@
+package ffclient
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/cache"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+)
+
+type cdnConfig struct {
+	URL     string `json:"url"`
+	Retries int    `json:"retries"`
+}
+
+// countingConfig counts the number of times it is decoded.
+type countingConfig struct {
+	Retries int `json:"retries"`
+}
+
+var countingConfigDecodes int
+
+func (c *countingConfig) UnmarshalJSON(data []byte) error {
+	countingConfigDecodes++
+	type alias countingConfig
+	return json.Unmarshal(data, (*alias)(c))
+}
+
+// fixedDateCacheMock is a cacheMock with a latest update date that changes only when updated by the test.
+type fixedDateCacheMock struct {
+	cacheMock
+	latestUpdate time.Time
+}
+
+func (c *fixedDateCacheMock) GetLatestUpdateDate() time.Time {
+	return c.latestUpdate
+}
+
+func newTypedVariationFlag(value interface{}) *flag.InternalFlag {
+	return &flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"config": testconvert.Interface(value),
+		},
+		DefaultRule: &flag.Rule{
+			VariationResult: testconvert.String("config"),
+		},
+	}
+}
+
+func TestVariationDetails(t *testing.T) {
+	defaultValue := cdnConfig{URL: "https://default.example.com"}
+	tests := []struct {
+		name             string
+		flagKey          string
+		cacheMock        cache.Manager
+		mode             []DecodeMode
+		want             cdnConfig
+		wantVariation    string
+		wantErrorCode    flag.ErrorCode
+		wantErrorDetails string
+		wantErr          string
+	}{
+		{
+			name:    "decode the object in a struct",
+			flagKey: "cdn",
+			cacheMock: NewCacheMock(newTypedVariationFlag(map[string]interface{}{
+				"url": "https://cdn.example.com", "retries": 3,
+			}), nil),
+			want:          cdnConfig{URL: "https://cdn.example.com", Retries: 3},
+			wantVariation: "config",
+		},
+		{
+			name:    "lenient mode ignores the unknown fields",
+			flagKey: "cdn",
+			cacheMock: NewCacheMock(newTypedVariationFlag(map[string]interface{}{
+				"url": "https://cdn.example.com", "retries": 3, "timeout": 10,
+			}), nil),
+			mode:          []DecodeMode{DecodeLenient},
+			want:          cdnConfig{URL: "https://cdn.example.com", Retries: 3},
+			wantVariation: "config",
+		},
+		{
+			name:    "strict mode fails on the unknown fields",
+			flagKey: "cdn",
+			cacheMock: NewCacheMock(newTypedVariationFlag(map[string]interface{}{
+				"url": "https://cdn.example.com", "retries": 3, "timeout": 10,
+			}), nil),
+			mode:             []DecodeMode{DecodeStrict},
+			want:             defaultValue,
+			wantVariation:    flag.VariationSDKDefault,
+			wantErrorCode:    flag.ErrorCodeTypeMismatch,
+			wantErrorDetails: `unknown field "timeout"`,
+			wantErr:          `wrong variation used for flag cdn: unknown field "timeout"`,
+		},
+		{
+			name:    "field of the wrong type",
+			flagKey: "cdn",
+			cacheMock: NewCacheMock(newTypedVariationFlag(map[string]interface{}{
+				"url": "https://cdn.example.com", "retries": "three",
+			}), nil),
+			want:             defaultValue,
+			wantVariation:    flag.VariationSDKDefault,
+			wantErrorCode:    flag.ErrorCodeTypeMismatch,
+			wantErrorDetails: "field retries: cannot decode string into int",
+			wantErr:          "wrong variation used for flag cdn: field retries: cannot decode string into int",
+		},
+		{
+			name:             "value that is not an object",
+			flagKey:          "cdn",
+			cacheMock:        NewCacheMock(newTypedVariationFlag(true), nil),
+			want:             defaultValue,
+			wantVariation:    flag.VariationSDKDefault,
+			wantErrorCode:    flag.ErrorCodeTypeMismatch,
+			wantErrorDetails: "cannot decode bool into ffclient.cdnConfig",
+			wantErr:          "wrong variation used for flag cdn: cannot decode bool into ffclient.cdnConfig",
+		},
+		{
+			name:          "flag disabled",
+			flagKey:       "cdn",
+			cacheMock:     NewCacheMock(&flag.InternalFlag{Disable: testconvert.Bool(true)}, nil),
+			want:          defaultValue,
+			wantVariation: flag.VariationSDKDefault,
+			wantErrorCode: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ff = &GoFeatureFlag{cache: tt.cacheMock}
+			defer func() { ff = nil }()
+
+			got, err := VariationDetails(tt.flagKey, ffcontext.NewEvaluationContext("user-key"), defaultValue, tt.mode...)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.True(t, got.Failed)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got.Value)
+			assert.Equal(t, tt.wantVariation, got.VariationType)
+			assert.Equal(t, tt.wantErrorCode, got.ErrorCode)
+			assert.Equal(t, tt.wantErrorDetails, got.ErrorDetails)
+		})
+	}
+}
+
+func TestVariation(t *testing.T) {
+	ff = &GoFeatureFlag{cache: NewCacheMock(newTypedVariationFlag(float64(3)), nil)}
+	defer func() { ff = nil }()
+
+	got, err := Variation("retries", ffcontext.NewEvaluationContext("user-key"), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, got)
+
+	_, err = Variation[string]("retries", ffcontext.NewEvaluationContext("user-key"), "")
+	assert.EqualError(t, err, "wrong variation used for flag retries: cannot decode number into string")
+}
+
+func TestVariation_NotInitialised(t *testing.T) {
+	got, err := Variation("cdn", ffcontext.NewEvaluationContext("user-key"), cdnConfig{Retries: 1})
+	assert.Error(t, err)
+	assert.Equal(t, cdnConfig{Retries: 1}, got)
+}
+
+func TestVariation_DecodedValueCache(t *testing.T) {
+	cacheMock := &fixedDateCacheMock{
+		cacheMock:    cacheMock{flag: newTypedVariationFlag(map[string]interface{}{"retries": 3})},
+		latestUpdate: time.Now(),
+	}
+	goff := &GoFeatureFlag{cache: cacheMock}
+	ctx := ffcontext.NewEvaluationContext("user-key")
+	countingConfigDecodes = 0
+
+	for i := 0; i < 3; i++ {
+		got, err := ClientVariation(goff, "cdn", ctx, countingConfig{})
+		assert.NoError(t, err)
+		assert.Equal(t, countingConfig{Retries: 3}, got)
+	}
+	assert.Equal(t, 1, countingConfigDecodes, "the value should be decoded only once")
+
+	_, err := ClientVariation(goff, "cdn", ctx, countingConfig{}, DecodeStrict)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, countingConfigDecodes, "each decode mode has its own decoded value")
+
+	// a refresh of the flags invalidates the decoded value
+	cacheMock.latestUpdate = cacheMock.latestUpdate.Add(time.Minute)
+	cacheMock.flag = newTypedVariationFlag(map[string]interface{}{"retries": 5})
+	got, err := ClientVariation(goff, "cdn", ctx, countingConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, countingConfig{Retries: 5}, got)
+	assert.Equal(t, 3, countingConfigDecodes)
+}
+
+type mutableConfig struct {
+	Hosts   []string          `json:"hosts"`
+	Headers map[string]string `json:"headers"`
+	Backup  *cdnConfig        `json:"backup"`
+}
+
+func TestVariation_DecodedValueCopy(t *testing.T) {
+	value := map[string]interface{}{
+		"hosts":   []interface{}{"a.example.com"},
+		"headers": map[string]interface{}{"x-env": "prod"},
+		"backup":  map[string]interface{}{"url": "https://backup.example.com", "retries": 1},
+	}
+	goff := &GoFeatureFlag{cache: &fixedDateCacheMock{
+		cacheMock:    cacheMock{flag: newTypedVariationFlag(value)},
+		latestUpdate: time.Now(),
+	}}
+	ctx := ffcontext.NewEvaluationContext("user-key")
+	want := mutableConfig{
+		Hosts:   []string{"a.example.com"},
+		Headers: map[string]string{"x-env": "prod"},
+		Backup:  &cdnConfig{URL: "https://backup.example.com", Retries: 1},
+	}
+
+	for i := 0; i < 3; i++ {
+		got, err := ClientVariation(goff, "cdn", ctx, mutableConfig{})
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, "the modifications of a returned value should not change the next evaluations")
+		got.Hosts[0] = "changed.example.com"
+		got.Headers["x-env"] = "changed"
+		got.Backup.Retries = 10
+	}
+
+	got, err := ClientVariation(goff, "cdn", ctx, map[string]interface{}{})
+	assert.NoError(t, err)
+	got["hosts"].([]interface{})[0] = "changed.example.com"
+	got, err = ClientVariation(goff, "cdn", ctx, map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"a.example.com"}, got["hosts"])
+}
@@ -1,6 +1,8 @@
 package ffclient
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"maps"
 	"time"
@@ -18,6 +20,14 @@ const (
 	errorWrongVariation   = "wrong variation used for flag %v"
 )
 
+var (
+	// ErrFlagNotFound is returned by Explain when the flag is not present in the configuration or disabled.
+	ErrFlagNotFound = errors.New("flag not found")
+	// ErrInvalidEvaluationContext is returned by Explain when the evaluation context is rejected by the
+	// context schema in strict mode.
+	ErrInvalidEvaluationContext = errors.New("invalid evaluation context")
+)
+
 // BoolVariation return the value of the flag in boolean.
 // An error is return if you don't have init the library before calling the function.
 // If the key does not exist we return the default value.
@@ -48,8 +58,8 @@ func BoolVariationDetails(flagKey string, ctx ffcontext.Context, defaultValue bo
 // Note: Use this function only if you are using multiple go-feature-flag instances.
 func (g *GoFeatureFlag) BoolVariationDetails(flagKey string, ctx ffcontext.Context, defaultValue bool,
 ) (model.VariationResult[bool], error) {
-	res, err := getVariation[bool](g, flagKey, ctx, defaultValue, "bool")
-	notifyVariation(g, flagKey, ctx, res)
+	res, evaluationCtx, err := getVariation[bool](g, flagKey, ctx, defaultValue, "bool")
+	notifyVariation(g, flagKey, evaluationCtx, res)
 	return res, err
 }
 
@@ -82,8 +92,8 @@ func IntVariationDetails(flagKey string, ctx ffcontext.Context, defaultValue int
 // Note: Use this function only if you are using multiple go-feature-flag instances.
 func (g *GoFeatureFlag) IntVariationDetails(flagKey string, ctx ffcontext.Context, defaultValue int,
 ) (model.VariationResult[int], error) {
-	res, err := getVariation[int](g, flagKey, ctx, defaultValue, "int")
-	notifyVariation(g, flagKey, ctx, res)
+	res, evaluationCtx, err := getVariation[int](g, flagKey, ctx, defaultValue, "int")
+	notifyVariation(g, flagKey, evaluationCtx, res)
 	return res, err
 }
 
@@ -117,8 +127,8 @@ func Float64VariationDetails(flagKey string, ctx ffcontext.Context, defaultValue
 // Note: Use this function only if you are using multiple go-feature-flag instances.
 func (g *GoFeatureFlag) Float64VariationDetails(flagKey string, ctx ffcontext.Context, defaultValue float64,
 ) (model.VariationResult[float64], error) {
-	res, err := getVariation[float64](g, flagKey, ctx, defaultValue, "float64")
-	notifyVariation(g, flagKey, ctx, res)
+	res, evaluationCtx, err := getVariation[float64](g, flagKey, ctx, defaultValue, "float64")
+	notifyVariation(g, flagKey, evaluationCtx, res)
 	return res, err
 }
 
@@ -152,8 +162,8 @@ func StringVariationDetails(flagKey string, ctx ffcontext.Context, defaultValue
 // Note: Use this function only if you are using multiple go-feature-flag instances.
 func (g *GoFeatureFlag) StringVariationDetails(flagKey string, ctx ffcontext.Context, defaultValue string,
 ) (model.VariationResult[string], error) {
-	res, err := getVariation[string](g, flagKey, ctx, defaultValue, "string")
-	notifyVariation(g, flagKey, ctx, res)
+	res, evaluationCtx, err := getVariation[string](g, flagKey, ctx, defaultValue, "string")
+	notifyVariation(g, flagKey, evaluationCtx, res)
 	return res, err
 }
 
@@ -189,8 +199,8 @@ func JSONArrayVariationDetails(flagKey string, ctx ffcontext.Context, defaultVal
 // Note: Use this function only if you are using multiple go-feature-flag instances.
 func (g *GoFeatureFlag) JSONArrayVariationDetails(flagKey string, ctx ffcontext.Context, defaultValue []interface{},
 ) (model.VariationResult[[]interface{}], error) {
-	res, err := getVariation[[]interface{}](g, flagKey, ctx, defaultValue, "[]interface{}")
-	notifyVariation(g, flagKey, ctx, res)
+	res, evaluationCtx, err := getVariation[[]interface{}](g, flagKey, ctx, defaultValue, "[]interface{}")
+	notifyVariation(g, flagKey, evaluationCtx, res)
 	return res, err
 }
 
@@ -228,8 +238,8 @@ func JSONVariationDetails(flagKey string, ctx ffcontext.Context, defaultValue ma
 // Note: Use this function only if you are using multiple go-feature-flag instances.
 func (g *GoFeatureFlag) JSONVariationDetails(flagKey string, ctx ffcontext.Context, defaultValue map[string]interface{},
 ) (model.VariationResult[map[string]interface{}], error) {
-	res, err := getVariation[map[string]interface{}](g, flagKey, ctx, defaultValue, "bool")
-	notifyVariation(g, flagKey, ctx, res)
+	res, evaluationCtx, err := getVariation[map[string]interface{}](g, flagKey, ctx, defaultValue, "bool")
+	notifyVariation(g, flagKey, evaluationCtx, res)
 	return res, err
 }
 
@@ -263,14 +273,14 @@ func (g *GoFeatureFlag) AllFlagsState(evaluationCtx ffcontext.Context) flagstate
 		}
 	}
 
+	if err := g.validateEvaluationContext(evaluationCtx, nil); err != nil {
+		// in strict mode, no flag is evaluated for an invalid evaluation context.
+		return invalidContextFlagsState(flags)
+	}
+
 	allFlags := flagstate.NewAllFlags()
 	for key, currentFlag := range flags {
-		flagCtx := flag.Context{
-			EvaluationContextEnrichment: g.config.EvaluationContextEnrichment,
-			DefaultSdkValue:             nil,
-		}
-		flagCtx.AddIntoEvaluationContextEnrichment("env", g.config.Environment)
-		flagValue, resolutionDetails := currentFlag.Value(key, evaluationCtx, flagCtx)
+		flagValue, resolutionDetails := currentFlag.Value(key, evaluationCtx, g.newFlagContext(nil, nil))
 
 		// if the flag is disabled, we are ignoring it.
 		if resolutionDetails.Reason == flag.ReasonDisabled {
@@ -285,12 +295,21 @@ func (g *GoFeatureFlag) AllFlagsState(evaluationCtx ffcontext.Context) flagstate
 			continue
 		}
 
+		// when the flag declares its type, the value is checked and converted to this type.
+		if valueType := currentFlag.GetType(); valueType != "" {
+			if !flag.MatchValueType(valueType, flagValue) {
+				flagValue = nil
+			}
+			flagValue = flag.ConvertToValueType(valueType, flagValue)
+		}
+
 		switch v := flagValue; v.(type) {
 		case int, float64, bool, string, []interface{}, map[string]interface{}:
 			allFlags.AddFlag(key, flagstate.FlagState{
 				Value:         v,
 				Timestamp:     time.Now().Unix(),
 				VariationType: resolutionDetails.Variant,
+				ValueType:     currentFlag.GetType(),
 				TrackEvents:   currentFlag.IsTrackEvents(),
 				Failed:        resolutionDetails.ErrorCode != "",
 				ErrorCode:     resolutionDetails.ErrorCode,
@@ -307,6 +326,7 @@ func (g *GoFeatureFlag) AllFlagsState(evaluationCtx ffcontext.Context) flagstate
 					Value:         defaultVariationValue,
 					Timestamp:     time.Now().Unix(),
 					VariationType: defaultVariationName,
+					ValueType:     currentFlag.GetType(),
 					TrackEvents:   currentFlag.IsTrackEvents(),
 					Failed:        true,
 					ErrorCode:     flag.ErrorCodeTypeMismatch,
@@ -332,11 +352,112 @@ func (g *GoFeatureFlag) GetFlagsFromCache() (map[string]flag.Flag, error) {
 // Note: Use this function only if you are using multiple go-feature-flag instances.
 func (g *GoFeatureFlag) RawVariation(flagKey string, ctx ffcontext.Context, sdkDefaultValue interface{},
 ) (model.RawVarResult, error) {
-	res, err := getVariation[interface{}](g, flagKey, ctx, sdkDefaultValue, "interface{}")
-	notifyVariation(g, flagKey, ctx, res)
+	res, evaluationCtx, err := getVariation[interface{}](g, flagKey, ctx, sdkDefaultValue, "interface{}")
+	notifyVariation(g, flagKey, evaluationCtx, res)
 	return model.RawVarResult(res), err
 }
 
+// RawVariationAt return the raw value of the flag (without any types) as it would be evaluated
+// at the evaluation date.
+// It allows to preview what an evaluation context will receive during a scheduled rollout, a progressive rollout,
+// an experimentation or outside the time windows of a flag.
+// No event is sent to the exporter for these evaluations.
+func (g *GoFeatureFlag) RawVariationAt(flagKey string, ctx ffcontext.Context, sdkDefaultValue interface{},
+	evaluationDate time.Time,
+) (model.RawVarResult, error) {
+	if evaluationDate.IsZero() {
+		// a preview never uses the sticky bucketing store, only the evaluations at the current date do.
+		evaluationDate = time.Now()
+	}
+	res, err := getVariationAt[interface{}](
+		context.Background(), g, flagKey, ctx, sdkDefaultValue, "interface{}", evaluationDate, nil)
+	return model.RawVarResult(res), err
+}
+
+// Explain evaluates a flag for an evaluation context and returns the result with a step-by-step trace
+// of the evaluation (checks done, rules matching or not, bucket of the evaluation context, ...).
+// It is more expensive than a variation and does not send any event to the exporter,
+// it should only be used to understand why an evaluation context receives a variation.
+func Explain(flagKey string, ctx ffcontext.Context, sdkDefaultValue interface{}) (model.ExplainResult, error) {
+	return ff.Explain(flagKey, ctx, sdkDefaultValue)
+}
+
+// Explain evaluates a flag for an evaluation context and returns the result with a step-by-step trace
+// of the evaluation (checks done, rules matching or not, bucket of the evaluation context, ...).
+// It is more expensive than a variation and does not send any event to the exporter,
+// it should only be used to understand why an evaluation context receives a variation.
+// Note: Use this function only if you are using multiple go-feature-flag instances.
+func (g *GoFeatureFlag) Explain(flagKey string, ctx ffcontext.Context, sdkDefaultValue interface{},
+) (model.ExplainResult, error) {
+	if g == nil {
+		return model.ExplainResult{}, fmt.Errorf("go-feature-flag is not initialised")
+	}
+	if g.config.Offline {
+		return model.ExplainResult{}, fmt.Errorf("go-feature-flag is offline, impossible to explain the flag %v", flagKey)
+	}
+
+	if err := g.validateEvaluationContext(ctx, nil); err != nil {
+		return model.ExplainResult{}, fmt.Errorf("%w: %w", ErrInvalidEvaluationContext, err)
+	}
+	f, err := g.getFlagFromCache(flagKey)
+	if err != nil {
+		return model.ExplainResult{}, fmt.Errorf("%w: %w", ErrFlagNotFound, err)
+	}
+	explainer, ok := f.(flag.Explainer)
+	if !ok {
+		return model.ExplainResult{}, fmt.Errorf("impossible to explain the evaluation of the flag %v", flagKey)
+	}
+
+	flagCtx := g.newFlagContext(sdkDefaultValue, nil)
+	if g.config.StickyBucketingStore != nil {
+		// the sticky assignments are read to explain the variation served, an explanation never assigns a variation.
+		storeCtx, cancel := context.WithTimeout(context.Background(), g.config.StickyBucketingTimeout)
+		defer cancel()
+		flagCtx.StickyBucketingStore = g.config.StickyBucketingStore
+		flagCtx.Context = storeCtx
+	}
+	flagValue, resolutionDetails, trace := explainer.Explain(flagKey, ctx, flagCtx)
+	return model.ExplainResult{
+		RawVarResult: model.RawVarResult{
+			Value:          flagValue,
+			VariationType:  resolutionDetails.Variant,
+			Reason:         resolutionDetails.Reason,
+			ErrorCode:      resolutionDetails.ErrorCode,
+			Failed:         resolutionDetails.ErrorCode != "",
+			TrackEvents:    f.IsTrackEvents(),
+			Version:        f.GetVersion(),
+			Cacheable:      resolutionDetails.Cacheable,
+			Metadata:       constructMetadata(f, resolutionDetails),
+			BucketingKey:   resolutionDetails.BucketingKey,
+			BucketingValue: resolutionDetails.BucketingValue,
+			Layer:          resolutionDetails.Layer,
+			LayerSlice:     resolutionDetails.LayerSlice,
+			ValueType:      f.GetType(),
+		},
+		Trace: trace,
+	}, nil
+}
+
+// newFlagContext creates the flag.Context used to evaluate a flag.
+// The request attributes are added to the enrichment of the evaluation context,
+// they override the attributes of Config.EvaluationContextEnrichment with the same name.
+func (g *GoFeatureFlag) newFlagContext(sdkDefaultValue interface{}, requestAttributes map[string]interface{},
+) flag.Context {
+	flagCtx := flag.Context{
+		DefaultSdkValue:             sdkDefaultValue,
+		EvaluationContextEnrichment: maps.Clone(g.config.EvaluationContextEnrichment),
+		FlagGetter:                  g.cache.GetFlag,
+	}
+	if g.membershipLists != nil {
+		flagCtx.MembershipLists = g.membershipLists
+	}
+	for name, value := range requestAttributes {
+		flagCtx.AddIntoEvaluationContextEnrichment(name, value)
+	}
+	flagCtx.AddIntoEvaluationContextEnrichment("env", g.config.Environment)
+	return flagCtx
+}
+
 // getFlagFromCache try to get the flag from the cache.
 // It returns an error if the cache is not init or if the flag is not present or disabled.
 func (g *GoFeatureFlag) getFlagFromCache(flagKey string) (flag.Flag, error) {
@@ -366,14 +487,36 @@ func notifyVariation[T model.JSONType](
 	if result.TrackEvents {
 		event := exporter.NewFeatureEvent(ctx, flagKey, result.Value, result.VariationType, result.Failed, result.Version,
 			"SERVER")
+		event.BucketingKey = result.BucketingKey
+		event.BucketingValue = result.BucketingValue
+		event.Layer = result.Layer
+		event.LayerSlice = result.LayerSlice
 		g.CollectEventData(event)
 	}
 }
 
 // getVariation is the internal generic func that handle the logic of a variation the result will always
-// contain a valid model.VariationResult
+// contain a valid model.VariationResult, the hooks of the configuration are called around the evaluation.
+// It returns the evaluation context used for the evaluation (see getVariationWithHooks).
 func getVariation[T model.JSONType](
 	g *GoFeatureFlag, flagKey string, evaluationCtx ffcontext.Context, sdkDefaultValue T, expectedType string,
+) (model.VariationResult[T], ffcontext.Context, error) {
+	ctx := context.Background()
+	if g != nil && g.config.Context != nil {
+		ctx = g.config.Context
+	}
+	return getVariationWithHooks(ctx, g, flagKey, evaluationCtx, sdkDefaultValue, expectedType, nil)
+}
+
+// getVariationAt is evaluating the flag as it would be evaluated at the evaluation date,
+// a zero evaluation date means that we evaluate the flag at the current date.
+// The request attributes are added to the evaluation context (see ffcontext.WithRequestAttributes).
+// Only the evaluations at the current date use the sticky bucketing store, the calls to the store are done
+// with ctx and limited to Config.StickyBucketingTimeout.
+func getVariationAt[T model.JSONType](
+	ctx context.Context, g *GoFeatureFlag, flagKey string, evaluationCtx ffcontext.Context, sdkDefaultValue T,
+	expectedType string,
+	evaluationDate time.Time, requestAttributes map[string]interface{},
 ) (model.VariationResult[T], error) {
 	if g == nil {
 		return model.VariationResult[T]{
@@ -413,12 +556,20 @@ func getVariation[T model.JSONType](
 		return varResult, err
 	}
 
-	flagCtx := flag.Context{
-		DefaultSdkValue:             sdkDefaultValue,
-		EvaluationContextEnrichment: maps.Clone(g.config.EvaluationContextEnrichment),
+	if err := g.validateEvaluationContext(evaluationCtx, requestAttributes); err != nil {
+		return invalidContextResult(f, sdkDefaultValue, err),
+			fmt.Errorf("invalid evaluation context for the flag %v: %w", flagKey, err)
 	}
-	flagCtx.AddIntoEvaluationContextEnrichment("env", g.config.Environment)
-	flagValue, resolutionDetails := f.Value(flagKey, evaluationCtx, flagCtx)
+
+	flagCtx := g.newFlagContext(sdkDefaultValue, requestAttributes)
+	flagCtx.EvaluationDate = evaluationDate
+	if evaluationDate.IsZero() && g.config.StickyBucketingStore != nil {
+		storeCtx, cancel := context.WithTimeout(ctx, g.config.StickyBucketingTimeout)
+		defer cancel()
+		flagCtx.StickyBucketingStore = g.config.StickyBucketingStore
+		flagCtx.Context = storeCtx
+	}
+	flagValue, resolutionDetails := g.evaluateFlag(f, flagKey, evaluationCtx, flagCtx)
 
 	var convertedValue interface{}
 	switch value := flagValue.(type) {
@@ -432,6 +583,10 @@ func getVariation[T model.JSONType](
 	default:
 		convertedValue = value
 	}
+	if expectedType == "interface{}" {
+		// the raw value is converted to the declared type of the flag (ex: 3.0 is returned as 3 for an int flag).
+		convertedValue = flag.ConvertToValueType(f.GetType(), convertedValue)
+	}
 
 	var v T
 	switch val := convertedValue.(type) {
@@ -448,34 +603,45 @@ func getVariation[T model.JSONType](
 				TrackEvents:   f.IsTrackEvents(),
 				Version:       f.GetVersion(),
 				Metadata:      f.GetMetadata(),
+				ValueType:     f.GetType(),
 			}, fmt.Errorf(errorWrongVariation, flagKey)
 		}
 	}
 
 	return model.VariationResult[T]{
-		Value:         v,
-		VariationType: resolutionDetails.Variant,
-		Reason:        resolutionDetails.Reason,
-		ErrorCode:     resolutionDetails.ErrorCode,
-		Failed:        resolutionDetails.ErrorCode != "",
-		TrackEvents:   f.IsTrackEvents(),
-		Version:       f.GetVersion(),
-		Cacheable:     resolutionDetails.Cacheable,
-		Metadata:      constructMetadata(f, resolutionDetails),
+		Value:          v,
+		VariationType:  resolutionDetails.Variant,
+		Reason:         resolutionDetails.Reason,
+		ErrorCode:      resolutionDetails.ErrorCode,
+		Failed:         resolutionDetails.ErrorCode != "",
+		TrackEvents:    f.IsTrackEvents(),
+		Version:        f.GetVersion(),
+		Cacheable:      resolutionDetails.Cacheable,
+		Metadata:       constructMetadata(f, resolutionDetails),
+		BucketingKey:   resolutionDetails.BucketingKey,
+		BucketingValue: resolutionDetails.BucketingValue,
+		Layer:          resolutionDetails.Layer,
+		LayerSlice:     resolutionDetails.LayerSlice,
+		ValueType:      f.GetType(),
 	}, nil
 }
 
 // constructMetadata is the internal generic func used to enhance model.VariationResult adding
-// the targeting.rule's name (from configuration) to the Metadata.
+// the targeting.rule's name (from configuration) and the name of the matching target list to the Metadata.
 // That way, it is possible to see when a targeting rule is match during the evaluation process.
 func constructMetadata(f flag.Flag, resolutionDetails flag.ResolutionDetails) map[string]interface{} {
 	metadata := maps.Clone(f.GetMetadata())
-	if resolutionDetails.RuleName == nil || *resolutionDetails.RuleName == "" {
-		return metadata
+	if resolutionDetails.RuleName != nil && *resolutionDetails.RuleName != "" {
+		if metadata == nil {
+			metadata = make(map[string]interface{})
+		}
+		metadata["evaluatedRuleName"] = *resolutionDetails.RuleName
 	}
-	if metadata == nil {
-		metadata = make(map[string]interface{})
+	if resolutionDetails.TargetName != nil && *resolutionDetails.TargetName != "" {
+		if metadata == nil {
+			metadata = make(map[string]interface{})
+		}
+		metadata["evaluatedTargetName"] = *resolutionDetails.TargetName
 	}
-	metadata["evaluatedRuleName"] = *resolutionDetails.RuleName
 	return metadata
 }
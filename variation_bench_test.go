@@ -0,0 +1,78 @@
+package ffclient
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/dto"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+)
+
+// flagsCacheMock is a cache returning a fixed set of flags.
+type flagsCacheMock struct {
+	cacheMock
+	flags map[string]flag.Flag
+}
+
+func (c *flagsCacheMock) AllFlags() (map[string]flag.Flag, error) {
+	return c.flags, nil
+}
+
+// benchmarkQuery is a query representative of the targeting rules used in production.
+const benchmarkQuery = `(key sw "user-1" and email ew "@gofeatureflag.org") or (company eq "acme" and beta eq true)`
+
+// BenchmarkAllFlagsState_300FlagsWithQueries compares the evaluation of flags with queries parsed at each
+// evaluation (flags not loaded by the cache) with the evaluation of flags with queries compiled when loading
+// them in the cache.
+func BenchmarkAllFlagsState_300FlagsWithQueries(b *testing.B) {
+	var buf bytes.Buffer
+	for i := 0; i < 300; i++ {
+		_, _ = fmt.Fprintf(&buf, `flag-%d:
+  variations:
+    enabled: true
+    disabled: false
+  targeting:
+    - query: %s
+      variation: enabled
+  defaultRule:
+    variation: disabled
+`, i, strconv.Quote(benchmarkQuery))
+	}
+	config, err := dto.Unmarshal(buf.Bytes(), "yaml")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		compile bool
+	}{
+		{name: "queries parsed at each evaluation", compile: false},
+		{name: "queries compiled when loading", compile: true},
+	}
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			flags := make(map[string]flag.Flag, len(config.Flags))
+			for key, flagDto := range config.Flags {
+				f := flagDto.Convert()
+				if tt.compile {
+					f.CompileRules()
+				}
+				flags[key] = &f
+			}
+			goff := &GoFeatureFlag{cache: &flagsCacheMock{flags: flags}}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				user := ffcontext.NewEvaluationContextBuilder(fmt.Sprintf("user-%d", i)).
+					AddCustom("email", "john@gofeatureflag.org").
+					AddCustom("company", "acme").
+					Build()
+				_ = goff.AllFlagsState(user)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package ffclient
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// tracerName is the name of the tracer used to create the spans of the evaluations.
+const tracerName = "go-feature-flag"
+
+// providerName is the name of the provider set in the spans of the evaluations.
+const providerName = "GO Feature Flag"
+
+// The attributes of the OpenTelemetry semantic conventions for feature flags that are not part of the
+// semconv package version used.
+const (
+	featureFlagReasonKey  = attribute.Key("feature_flag.evaluation.reason")
+	featureFlagVersionKey = attribute.Key("feature_flag.version")
+	errorTypeKey          = attribute.Key("error.type")
+)
+
+// BoolVariationWithContext return the value of the flag in boolean, the evaluation being part of ctx.
+// An error is return if you don't have init the library before calling the function.
+// If the key does not exist we return the default value.
+// See BoolVariationDetailsWithContext for the usage of ctx.
+func (g *GoFeatureFlag) BoolVariationWithContext(
+	ctx context.Context, flagKey string, evaluationCtx ffcontext.Context, defaultValue bool,
+) (bool, error) {
+	res, err := g.BoolVariationDetailsWithContext(ctx, flagKey, evaluationCtx, defaultValue)
+	return res.Value, err
+}
+
+// BoolVariationDetailsWithContext return the details of the evaluation for boolean flag,
+// the evaluation being part of ctx:
+// - a span is created if Config.TracerProvider is set,
+// - the request attributes registered in ctx with ffcontext.WithRequestAttributes are added to the evaluation context,
+// - the default value is returned if ctx is already done.
+func (g *GoFeatureFlag) BoolVariationDetailsWithContext(
+	ctx context.Context, flagKey string, evaluationCtx ffcontext.Context, defaultValue bool,
+) (model.VariationResult[bool], error) {
+	res, evaluatedCtx, err := getVariationWithContext[bool](ctx, g, flagKey, evaluationCtx, defaultValue, "bool")
+	notifyVariation(g, flagKey, evaluatedCtx, res)
+	return res, err
+}
+
+// IntVariationWithContext return the value of the flag in int, the evaluation being part of ctx.
+// An error is return if you don't have init the library before calling the function.
+// If the key does not exist we return the default value.
+// See IntVariationDetailsWithContext for the usage of ctx.
+func (g *GoFeatureFlag) IntVariationWithContext(
+	ctx context.Context, flagKey string, evaluationCtx ffcontext.Context, defaultValue int,
+) (int, error) {
+	res, err := g.IntVariationDetailsWithContext(ctx, flagKey, evaluationCtx, defaultValue)
+	return res.Value, err
+}
+
+// IntVariationDetailsWithContext return the details of the evaluation for int flag,
+// the evaluation being part of ctx:
+// - a span is created if Config.TracerProvider is set,
+// - the request attributes registered in ctx with ffcontext.WithRequestAttributes are added to the evaluation context,
+// - the default value is returned if ctx is already done.
+func (g *GoFeatureFlag) IntVariationDetailsWithContext(
+	ctx context.Context, flagKey string, evaluationCtx ffcontext.Context, defaultValue int,
+) (model.VariationResult[int], error) {
+	res, evaluatedCtx, err := getVariationWithContext[int](ctx, g, flagKey, evaluationCtx, defaultValue, "int")
+	notifyVariation(g, flagKey, evaluatedCtx, res)
+	return res, err
+}
+
+// Float64VariationWithContext return the value of the flag in float64, the evaluation being part of ctx.
+// An error is return if you don't have init the library before calling the function.
+// If the key does not exist we return the default value.
+// See Float64VariationDetailsWithContext for the usage of ctx.
+func (g *GoFeatureFlag) Float64VariationWithContext(
+	ctx context.Context, flagKey string, evaluationCtx ffcontext.Context, defaultValue float64,
+) (float64, error) {
+	res, err := g.Float64VariationDetailsWithContext(ctx, flagKey, evaluationCtx, defaultValue)
+	return res.Value, err
+}
+
+// Float64VariationDetailsWithContext return the details of the evaluation for float64 flag,
+// the evaluation being part of ctx:
+// - a span is created if Config.TracerProvider is set,
+// - the request attributes registered in ctx with ffcontext.WithRequestAttributes are added to the evaluation context,
+// - the default value is returned if ctx is already done.
+func (g *GoFeatureFlag) Float64VariationDetailsWithContext(
+	ctx context.Context, flagKey string, evaluationCtx ffcontext.Context, defaultValue float64,
+) (model.VariationResult[float64], error) {
+	res, evaluatedCtx, err := getVariationWithContext[float64](ctx, g, flagKey, evaluationCtx, defaultValue, "float64")
+	notifyVariation(g, flagKey, evaluatedCtx, res)
+	return res, err
+}
+
+// StringVariationWithContext return the value of the flag in string, the evaluation being part of ctx.
+// An error is return if you don't have init the library before calling the function.
+// If the key does not exist we return the default value.
+// See StringVariationDetailsWithContext for the usage of ctx.
+func (g *GoFeatureFlag) StringVariationWithContext(
+	ctx context.Context, flagKey string, evaluationCtx ffcontext.Context, defaultValue string,
+) (string, error) {
+	res, err := g.StringVariationDetailsWithContext(ctx, flagKey, evaluationCtx, defaultValue)
+	return res.Value, err
+}
+
+// StringVariationDetailsWithContext return the details of the evaluation for string flag,
+// the evaluation being part of ctx:
+// - a span is created if Config.TracerProvider is set,
+// - the request attributes registered in ctx with ffcontext.WithRequestAttributes are added to the evaluation context,
+// - the default value is returned if ctx is already done.
+func (g *GoFeatureFlag) StringVariationDetailsWithContext(
+	ctx context.Context, flagKey string, evaluationCtx ffcontext.Context, defaultValue string,
+) (model.VariationResult[string], error) {
+	res, evaluatedCtx, err := getVariationWithContext[string](ctx, g, flagKey, evaluationCtx, defaultValue, "string")
+	notifyVariation(g, flagKey, evaluatedCtx, res)
+	return res, err
+}
+
+// JSONArrayVariationWithContext return the value of the flag in []interface{}, the evaluation being part of ctx.
+// An error is return if you don't have init the library before calling the function.
+// If the key does not exist we return the default value.
+// See JSONArrayVariationDetailsWithContext for the usage of ctx.
+func (g *GoFeatureFlag) JSONArrayVariationWithContext(
+	ctx context.Context, flagKey string, evaluationCtx ffcontext.Context, defaultValue []interface{},
+) ([]interface{}, error) {
+	res, err := g.JSONArrayVariationDetailsWithContext(ctx, flagKey, evaluationCtx, defaultValue)
+	return res.Value, err
+}
+
+// JSONArrayVariationDetailsWithContext return the details of the evaluation for []interface{} flag,
+// the evaluation being part of ctx:
+// - a span is created if Config.TracerProvider is set,
+// - the request attributes registered in ctx with ffcontext.WithRequestAttributes are added to the evaluation context,
+// - the default value is returned if ctx is already done.
+func (g *GoFeatureFlag) JSONArrayVariationDetailsWithContext(
+	ctx context.Context, flagKey string, evaluationCtx ffcontext.Context, defaultValue []interface{},
+) (model.VariationResult[[]interface{}], error) {
+	res, evaluatedCtx, err := getVariationWithContext[[]interface{}](
+		ctx, g, flagKey, evaluationCtx, defaultValue, "[]interface{}")
+	notifyVariation(g, flagKey, evaluatedCtx, res)
+	return res, err
+}
+
+// JSONVariationWithContext return the value of the flag in map[string]interface{}, the evaluation being part of ctx.
+// An error is return if you don't have init the library before calling the function.
+// If the key does not exist we return the default value.
+// See JSONVariationDetailsWithContext for the usage of ctx.
+func (g *GoFeatureFlag) JSONVariationWithContext(
+	ctx context.Context, flagKey string, evaluationCtx ffcontext.Context, defaultValue map[string]interface{},
+) (map[string]interface{}, error) {
+	res, err := g.JSONVariationDetailsWithContext(ctx, flagKey, evaluationCtx, defaultValue)
+	return res.Value, err
+}
+
+// JSONVariationDetailsWithContext return the details of the evaluation for map[string]interface{} flag,
+// the evaluation being part of ctx:
+// - a span is created if Config.TracerProvider is set,
+// - the request attributes registered in ctx with ffcontext.WithRequestAttributes are added to the evaluation context,
+// - the default value is returned if ctx is already done.
+func (g *GoFeatureFlag) JSONVariationDetailsWithContext(
+	ctx context.Context, flagKey string, evaluationCtx ffcontext.Context, defaultValue map[string]interface{},
+) (model.VariationResult[map[string]interface{}], error) {
+	res, evaluatedCtx, err := getVariationWithContext[map[string]interface{}](
+		ctx, g, flagKey, evaluationCtx, defaultValue, "map[string]interface{}")
+	notifyVariation(g, flagKey, evaluatedCtx, res)
+	return res, err
+}
+
+// RawVariationWithContext return the raw value of the flag (without any types), the evaluation being part of ctx.
+// This raw result is mostly used by software built on top of go-feature-flag such as
+// go-feature-flag relay proxy.
+// See BoolVariationDetailsWithContext for the usage of ctx.
+func (g *GoFeatureFlag) RawVariationWithContext(
+	ctx context.Context, flagKey string, evaluationCtx ffcontext.Context, sdkDefaultValue interface{},
+) (model.RawVarResult, error) {
+	res, evaluatedCtx, err := getVariationWithContext[interface{}](
+		ctx, g, flagKey, evaluationCtx, sdkDefaultValue, "interface{}")
+	notifyVariation(g, flagKey, evaluatedCtx, res)
+	return model.RawVarResult(res), err
+}
+
+// getVariationWithContext is evaluating the flag as part of ctx, the evaluation is traced in a span
+// and uses the request attributes registered in ctx.
+// It returns the evaluation context used for the evaluation (see getVariationWithHooks).
+func getVariationWithContext[T model.JSONType](
+	ctx context.Context, g *GoFeatureFlag, flagKey string, evaluationCtx ffcontext.Context, sdkDefaultValue T,
+	expectedType string,
+) (model.VariationResult[T], ffcontext.Context, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	_, span := g.tracer().Start(ctx, "flagEvaluation",
+		trace.WithAttributes(semconv.FeatureFlagKey(flagKey), semconv.FeatureFlagProviderName(providerName)))
+	defer span.End()
+
+	var res model.VariationResult[T]
+	var err error
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		res = model.VariationResult[T]{
+			Value:         sdkDefaultValue,
+			VariationType: flag.VariationSDKDefault,
+			Failed:        true,
+			Reason:        flag.ReasonError,
+			ErrorCode:     flag.ErrorCodeGeneral,
+			Cacheable:     false,
+		}
+		err = fmt.Errorf("impossible to evaluate the flag %v: %w", flagKey, ctxErr)
+	} else {
+		requestAttributes := ffcontext.RequestAttributesFromContext(ctx)
+		if len(requestAttributes) > 0 {
+			// the attributes are added to a copy, so they are not kept in the context of the caller.
+			evaluationCtx = ffcontext.Clone(evaluationCtx)
+		}
+		res, evaluationCtx, err = getVariationWithHooks(
+			ctx, g, flagKey, evaluationCtx, sdkDefaultValue, expectedType, requestAttributes)
+	}
+
+	span.SetAttributes(
+		semconv.FeatureFlagVariant(res.VariationType),
+		featureFlagReasonKey.String(res.Reason),
+	)
+	if res.Version != "" {
+		span.SetAttributes(featureFlagVersionKey.String(res.Version))
+	}
+	if res.ErrorCode != "" {
+		span.SetAttributes(errorTypeKey.String(res.ErrorCode))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return res, evaluationCtx, err
+}
+
+// tracer returns the tracer used to trace the evaluations, a no-op tracer if no TracerProvider is configured.
+func (g *GoFeatureFlag) tracer() trace.Tracer {
+	if g == nil || g.config.TracerProvider == nil {
+		return noop.NewTracerProvider().Tracer(tracerName)
+	}
+	return g.config.TracerProvider.Tracer(tracerName)
+}
@@ -0,0 +1,126 @@
+package ffclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/internal/flag"
+	"github.com/thomaspoignant/go-feature-flag/testutils/testconvert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newPlanFlag() *flag.InternalFlag {
+	return &flag.InternalFlag{
+		Variations: &map[string]*interface{}{
+			"enabled":  testconvert.Interface(true),
+			"disabled": testconvert.Interface(false),
+		},
+		Rules: &[]flag.Rule{
+			{
+				Query:           testconvert.String(`tenant eq "acme"`),
+				VariationResult: testconvert.String("enabled"),
+			},
+		},
+		DefaultRule: &flag.Rule{
+			VariationResult: testconvert.String("disabled"),
+		},
+	}
+}
+
+func TestBoolVariationDetailsWithContext(t *testing.T) {
+	tests := []struct {
+		name          string
+		ctx           func() (context.Context, context.CancelFunc)
+		want          bool
+		wantVariation string
+		wantReason    flag.ResolutionReason
+		wantErr       bool
+	}{
+		{
+			name: "context without request attributes",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			want:          false,
+			wantVariation: "disabled",
+			wantReason:    flag.ReasonDefault,
+		},
+		{
+			name: "request attributes used by the rules",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(
+					ffcontext.WithRequestAttributes(context.Background(), map[string]interface{}{"tenant": "acme"}))
+			},
+			want:          true,
+			wantVariation: "enabled",
+			wantReason:    flag.ReasonTargetingMatch,
+		},
+		{
+			name: "context already cancelled",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(
+					ffcontext.WithRequestAttributes(context.Background(), map[string]interface{}{"tenant": "acme"}))
+				cancel()
+				return ctx, cancel
+			},
+			want:          false,
+			wantVariation: flag.VariationSDKDefault,
+			wantReason:    flag.ReasonError,
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			goff := &GoFeatureFlag{
+				cache: NewCacheMock(newPlanFlag(), nil),
+				config: Config{
+					TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+				},
+			}
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			got, err := goff.BoolVariationDetailsWithContext(ctx, "my-flag", ffcontext.NewEvaluationContext("user-key"), false)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got.Value)
+			assert.Equal(t, tt.wantVariation, got.VariationType)
+			assert.Equal(t, tt.wantReason, got.Reason)
+
+			spans := recorder.Ended()
+			assert.Len(t, spans, 1)
+			assert.Equal(t, "flagEvaluation", spans[0].Name())
+			assert.Contains(t, spans[0].Attributes(), attribute.String("feature_flag.key", "my-flag"))
+			assert.Contains(t, spans[0].Attributes(), attribute.String("feature_flag.provider_name", "GO Feature Flag"))
+			assert.Contains(t, spans[0].Attributes(), attribute.String("feature_flag.variant", tt.wantVariation))
+			assert.Contains(t, spans[0].Attributes(), attribute.String("feature_flag.evaluation.reason", tt.wantReason))
+			if tt.wantErr {
+				assert.Equal(t, codes.Error, spans[0].Status().Code)
+				assert.Contains(t, spans[0].Attributes(), attribute.String("error.type", flag.ErrorCodeGeneral))
+			}
+		})
+	}
+}
+
+func TestVariationWithContext_WithoutTracerProvider(t *testing.T) {
+	goff := &GoFeatureFlag{cache: NewCacheMock(newPlanFlag(), nil)}
+	evaluationCtx := ffcontext.NewEvaluationContextBuilder("user-key").AddCustom("tenant", "other").Build()
+	ctx := ffcontext.WithRequestAttributes(context.Background(), map[string]interface{}{"tenant": "acme"})
+
+	got, err := goff.BoolVariationWithContext(ctx, "my-flag", evaluationCtx, false)
+	assert.NoError(t, err)
+	assert.True(t, got, "the request attributes override the attributes of the evaluation context")
+
+	raw, err := goff.RawVariationWithContext(context.Background(), "my-flag", evaluationCtx, false)
+	assert.NoError(t, err)
+	assert.Equal(t, false, raw.Value)
+}
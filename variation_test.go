@@ -17,6 +17,7 @@ import (
 	"github.com/thomaspoignant/go-feature-flag/exporter/logsexporter"
 	"github.com/thomaspoignant/go-feature-flag/ffcontext"
 	"github.com/thomaspoignant/go-feature-flag/internal/cache"
+	"github.com/thomaspoignant/go-feature-flag/internal/contextschema"
 	"github.com/thomaspoignant/go-feature-flag/internal/dto"
 	"github.com/thomaspoignant/go-feature-flag/internal/flag"
 	"github.com/thomaspoignant/go-feature-flag/model"
@@ -27,8 +28,9 @@ import (
 )
 
 type cacheMock struct {
-	flag flag.Flag
-	err  error
+	flag          flag.Flag
+	err           error
+	contextSchema *contextschema.Schema
 }
 
 func NewCacheMock(flag flag.Flag, err error) cache.Manager {
@@ -42,10 +44,10 @@ func (c *cacheMock) GetLatestUpdateDate() time.Time {
 	return time.Now()
 }
 
-func (c *cacheMock) ConvertToFlagStruct(loadedFlags []byte, fileFormat string) (map[string]dto.DTO, error) {
-	return nil, nil
+func (c *cacheMock) ConvertToFlagStruct(loadedFlags []byte, fileFormat string) (dto.Configuration, error) {
+	return dto.Configuration{}, nil
 }
-func (c *cacheMock) UpdateCache(newFlags map[string]dto.DTO, log *log.Logger) error {
+func (c *cacheMock) UpdateCache(newConfig dto.Configuration, log *log.Logger) error {
 	return nil
 }
 func (c *cacheMock) Close() {}
@@ -53,6 +55,9 @@ func (c *cacheMock) GetFlag(key string) (flag.Flag, error) {
 	return c.flag, c.err
 }
 func (c *cacheMock) AllFlags() (map[string]flag.Flag, error) { return nil, nil }
+func (c *cacheMock) GetContextSchema() *contextschema.Schema {
+	return c.contextSchema
+}
 
 func TestBoolVariation(t *testing.T) {
 	type args struct {
@@ -3990,3 +3995,90 @@ func Test_constructMetadataParallel(t *testing.T) {
 		})
 	}
 }
+
+func TestExplain(t *testing.T) {
+	goff := &GoFeatureFlag{
+		bgUpdater: newBackgroundUpdater(500, true),
+		cache: NewCacheMock(&flag.InternalFlag{
+			Variations: &map[string]*interface{}{
+				"A": testconvert.Interface("A"),
+				"B": testconvert.Interface("B"),
+			},
+			Rules: &[]flag.Rule{
+				{
+					Name:            testconvert.String("admins"),
+					Query:           testconvert.String(`role eq "admin"`),
+					VariationResult: testconvert.String("A"),
+				},
+			},
+			DefaultRule: &flag.Rule{
+				Percentages: &map[string]float64{"A": 30, "B": 70},
+			},
+		}, nil),
+		config: Config{PollingInterval: 0},
+	}
+
+	got, err := goff.Explain("my-flag", ffcontext.NewEvaluationContext("user-1"), "default")
+	assert.NoError(t, err)
+	assert.Equal(t, flag.ReasonSplit, got.Reason)
+	assert.Equal(t, "my-flag", got.Trace.FlagKey)
+	assert.Len(t, got.Trace.Steps, 3)
+	assert.Equal(t, flag.TraceStepDisabled, got.Trace.Steps[0].Type)
+	assert.Equal(t, flag.TraceStepRule, got.Trace.Steps[1].Type)
+	assert.False(t, got.Trace.Steps[1].Rule.Matched)
+	assert.Equal(t, []string{"role"}, got.Trace.Steps[1].Rule.MissingAttributes)
+	assert.Equal(t, flag.TraceStepDefaultRule, got.Trace.Steps[2].Type)
+	assert.Equal(t, got.VariationType, got.Trace.Steps[2].Rule.Variation)
+	assert.Len(t, got.Trace.Steps[2].Rule.Bucketing.Buckets, 2)
+
+	goff.cache.(*cacheMock).contextSchema = newTenantSchema(contextschema.ModeStrict)
+	_, err = goff.Explain("my-flag", ffcontext.NewEvaluationContext("user-1"), "default")
+	assert.ErrorIs(t, err, ErrInvalidEvaluationContext)
+	goff.cache.(*cacheMock).contextSchema = nil
+
+	goff.cache.(*cacheMock).err = errors.New("flag not found in the cache")
+	_, err = goff.Explain("my-flag", ffcontext.NewEvaluationContext("user-1"), "default")
+	assert.ErrorIs(t, err, ErrFlagNotFound)
+
+	goff.config.Offline = true
+	_, err = goff.Explain("my-flag", ffcontext.NewEvaluationContext("user-1"), "default")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrFlagNotFound)
+
+	var notInitialised *GoFeatureFlag
+	_, err = notInitialised.Explain("my-flag", ffcontext.NewEvaluationContext("user-1"), "default")
+	assert.Error(t, err)
+}
+
+func TestRawVariationAt(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	goff := &GoFeatureFlag{
+		bgUpdater: newBackgroundUpdater(500, true),
+		cache: NewCacheMock(&flag.InternalFlag{
+			Variations: &map[string]*interface{}{
+				"A": testconvert.Interface("A"),
+				"B": testconvert.Interface("B"),
+			},
+			DefaultRule: &flag.Rule{VariationResult: testconvert.String("A")},
+			Scheduled: &[]flag.ScheduledStep{
+				{
+					InternalFlag: flag.InternalFlag{
+						DefaultRule: &flag.Rule{VariationResult: testconvert.String("B")},
+					},
+					Date: testconvert.Time(start),
+				},
+			},
+		}, nil),
+		config: Config{PollingInterval: 0},
+	}
+
+	before, err := goff.RawVariationAt("my-flag", ffcontext.NewEvaluationContext("user-1"), "default",
+		start.Add(-1*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, "A", before.Value)
+
+	after, err := goff.RawVariationAt("my-flag", ffcontext.NewEvaluationContext("user-1"), "default",
+		start.Add(1*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, "B", after.Value)
+}